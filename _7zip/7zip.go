@@ -22,7 +22,9 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/bodgit/sevenzip"
 	"golang.org/x/text/encoding"
@@ -32,10 +34,20 @@ import (
 
 type ReadCloser struct {
 	_7z     *sevenzip.ReadCloser
+	solid   *solidReader // nil if the folders are decoded by sevenzip
 	entries map[string]*compress.DirIndex
 	dirs    map[string]int
 	files   map[string]int
 	index   []*File
+	folders map[int][]*File // the entries of each solid folder by offset
+
+	mu        sync.Mutex
+	cursor    *cursor
+	cache     *folderCache
+	cacheSize int64
+
+	match compress.LookupOption
+	names *compress.NameIndex
 
 	root fs.FileInfo
 }
@@ -46,6 +58,32 @@ func (rc *ReadCloser) SetRootInfo(info os.FileInfo) { rc.root = info }
 
 func (rc *ReadCloser) SetCharset(_ []encoding.Encoding, _ bool) {}
 
+// SetLookup set the matching of the names which are not found as is.
+func (rc *ReadCloser) SetLookup(opt compress.LookupOption) { rc.match = opt }
+
+// SetCacheSize set the memory limit of the entries cached when decoding the solid folders,
+// which make the random access not decode the folder from the start again.
+//
+// * size 0 is DefaultCacheSize, size < 0 is disable the cache.
+func (rc *ReadCloser) SetCacheSize(size int64) {
+	rc.cacheSize = size
+	if rc.cache != nil {
+		rc.mu.Lock()
+		rc.cache.limit = rc.cacheLimit()
+		rc.mu.Unlock()
+	}
+}
+
+func (rc *ReadCloser) cacheLimit() int64 {
+	switch {
+	case rc.cacheSize == 0:
+		return DefaultCacheSize
+	case rc.cacheSize < 0:
+		return 0
+	}
+	return rc.cacheSize
+}
+
 // OpenReader will open the 7-zip file specified by name and return a
 // ReadCloser. If name has a ".001" suffix it is assumed there are multiple
 // volumes and each sequential volume will be opened.
@@ -63,19 +101,34 @@ func (rc *ReadCloser) OpenReaderWithPassword(path, pwd string) (fs.FS, error) {
 		return nil, err
 	}
 
+	// the folders are decoded by sevenzip if they can not be read
+	solid, err := openSolid(path, pwd)
+	if err == nil && len(solid.files) != len(_7zip.File) {
+		_ = solid.Close()
+		err = errCorruptHeader
+	}
+	if err != nil {
+		solid = nil
+	}
+
 	maxIdx := len(_7zip.File) + 1
 	res := &ReadCloser{_7z: _7zip,
+		solid: solid,
 		entries: map[string]*compress.DirIndex{
 			compress.DefaultArchiverRoot: compress.NewDirEntries(),
 		},
-		dirs:  map[string]int{},
-		files: map[string]int{},
-		index: make([]*File, maxIdx),
-		root:  rc.root,
+		dirs:      map[string]int{},
+		files:     map[string]int{},
+		index:     make([]*File, maxIdx),
+		folders:   map[int][]*File{},
+		cacheSize: rc.cacheSize,
+		match:     rc.match,
+		root:      rc.root,
 	}
+	res.cache = newFolderCache(res.cacheLimit())
 	for idx, file := range res._7z.File {
 		mode := file.FileHeader.Mode()
-		entry := &File{f: file, size: 0, mode: mode}
+		entry := &File{f: file, size: 0, mode: mode, folder: -1, fileOpen: res.openEntry}
 		if mode.IsDir() {
 			entry.isDir = true
 			entry.name = strings.TrimRight(file.Name, "/")
@@ -85,6 +138,10 @@ func (rc *ReadCloser) OpenReaderWithPassword(path, pwd string) (fs.FS, error) {
 		} else {
 			entry.name = file.Name
 			entry.size = int64(file.FileHeader.UncompressedSize)
+			entry.folder, entry.offset = solid.folderOf(idx, file.UncompressedSize)
+			if entry.folder >= 0 {
+				res.folders[entry.folder] = append(res.folders[entry.folder], entry)
+			}
 			res.files[entry.name] = idx
 			// Add index to dir entries
 			dir := filepath.Dir(entry.name)
@@ -95,6 +152,9 @@ func (rc *ReadCloser) OpenReaderWithPassword(path, pwd string) (fs.FS, error) {
 		}
 		res.index[idx] = entry
 	}
+	for _, entries := range res.folders {
+		sort.Slice(entries, func(i, j int) bool { return entries[i].offset < entries[j].offset })
+	}
 	// Set root info
 	rootIdx := maxIdx - 1
 	res.dirs[compress.DefaultArchiverRoot] = rootIdx
@@ -104,6 +164,7 @@ func (rc *ReadCloser) OpenReaderWithPassword(path, pwd string) (fs.FS, error) {
 		isDir:      true,
 		dirEntries: res.GetDirEntries,
 	}
+	res.indexNames()
 
 	return res, nil
 }
@@ -123,7 +184,88 @@ func (rc *ReadCloser) Open(name string) (fs.File, error) {
 	if ok {
 		return rc.getFile(idx)
 	}
-	return nil, &fs.PathError{Op: "info", Path: name, Err: fs.ErrNotExist}
+	found, err := rc.resolve("info", name)
+	if err != nil {
+		return nil, err
+	}
+	return rc.Open(found)
+}
+
+// Lstat returns the fs.FileInfo of the named file, the symbolic link is not followed.
+func (rc *ReadCloser) Lstat(name string) (fs.FileInfo, error) {
+	file, err := rc.lookup("lstat", name)
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// ReadLink returns the destination of the named symbolic link.
+func (rc *ReadCloser) ReadLink(name string) (string, error) {
+	file, err := rc.lookup("readlink", name)
+	if err != nil {
+		return "", err
+	}
+	if file.mode&os.ModeSymlink == 0 {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+	target, err := file.readLink()
+	if err != nil {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: err}
+	}
+	return target, nil
+}
+
+// lookup returns the named file without opening it.
+func (rc *ReadCloser) lookup(op, name string) (*File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	if idx, ok := rc.dirs[name]; ok {
+		return rc.index[idx], nil
+	}
+	if idx, ok := rc.files[name]; ok {
+		return rc.index[idx], nil
+	}
+	found, err := rc.resolve(op, name)
+	if err != nil {
+		return nil, err
+	}
+	return rc.lookup(op, found)
+}
+
+// indexNames builds the index of the names matched by the lookup options.
+func (rc *ReadCloser) indexNames() {
+	if rc.match == 0 {
+		return
+	}
+	rc.names = compress.NewNameIndex(rc.match)
+	for name := range rc.dirs {
+		rc.names.Add(name)
+	}
+	for name := range rc.files {
+		rc.names.Add(name)
+	}
+}
+
+// resolve returns the name of the archive matching name by the lookup options.
+func (rc *ReadCloser) resolve(op, name string) (string, error) {
+	if rc.names == nil {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	found, err := rc.names.Find(name)
+	if err != nil {
+		return "", &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	return found, nil
+}
+
+// Collisions returns the names of the archive which match the same name by the lookup options.
+func (rc *ReadCloser) Collisions() [][]string {
+	if rc.names == nil {
+		return nil
+	}
+	return rc.names.Collisions()
 }
 
 func (rc *ReadCloser) GetDirEntries(path string, n int) ([]fs.DirEntry, error) {
@@ -147,11 +289,8 @@ func (rc *ReadCloser) GetDirEntries(path string, n int) ([]fs.DirEntry, error) {
 	if di, ok := rc.entries[path]; ok {
 		entries = make([]fs.DirEntry, di.Len())
 		for idx, fIdx := range di.Entries() {
-			file, err := rc.getFile(fIdx)
-			if err != nil {
-				return nil, err
-			}
-			entries[idx] = file
+			// the entries are not opened to be listed
+			entries[idx] = rc.index[fIdx]
 			if n > 0 && idx >= n {
 				break
 			}
@@ -170,7 +309,7 @@ func (rc *ReadCloser) GetDirEntries(path string, n int) ([]fs.DirEntry, error) {
 }
 
 func (rc *ReadCloser) getFile(idx int) (*File, error) {
-	if idx > len(rc.index) || idx < 0 {
+	if idx >= len(rc.index) || idx < 0 {
 		return nil, fs.ErrInvalid
 	}
 	file := rc.index[idx]
@@ -189,6 +328,11 @@ func (rc *ReadCloser) Reset() {
 
 // Close closes the 7-zip file or volumes, rendering them unusable for I/O.
 func (rc *ReadCloser) Close() error {
+	rc.cursor = nil
+	if rc.solid != nil {
+		_ = rc.solid.Close()
+		rc.solid = nil
+	}
 	if rc._7z != nil {
 		err := rc._7z.Close()
 		return err
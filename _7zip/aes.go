@@ -0,0 +1,178 @@
+// Package _7zip
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package _7zip
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+	"unicode/utf16"
+)
+
+// aesCycles is the power of 2 of the SHA-256 rounds to derive the key, same as 7-Zip.
+const aesCycles = 19
+
+var methodAES = []byte{0x06, 0xf1, 0x07, 0x01}
+
+// aesKey derive the AES-256 key from password (7zAES).
+func aesKey(password string, cycles int, salt []byte) []byte {
+	b := append([]byte(nil), salt...)
+	for _, c := range utf16.Encode([]rune(password)) {
+		b = append(b, byte(c), byte(c>>8))
+	}
+	h := sha256.New()
+	var counter [8]byte
+	for i := uint64(0); i < 1<<cycles; i++ {
+		_, _ = h.Write(b)
+		binary.LittleEndian.PutUint64(counter[:], i)
+		_, _ = h.Write(counter[:])
+	}
+	return h.Sum(nil)
+}
+
+// aesMaxCycles is the limit of the key derivation rounds of the archives read, same as 7-Zip.
+const aesMaxCycles = 24
+
+var errAESProps = errors.New("7zip: unsupported AES properties")
+
+// aesProps returns the key cycles, the salt and the IV of the 7zAES coder properties.
+func aesProps(props []byte) (int, []byte, []byte, error) {
+	if len(props) == 0 {
+		return 0, nil, nil, errAESProps
+	}
+	cycles := int(props[0] & 0x3f)
+	if cycles > aesMaxCycles {
+		return 0, nil, nil, errAESProps
+	}
+	if props[0]&0xc0 == 0 {
+		return cycles, nil, make([]byte, aes.BlockSize), nil
+	}
+	if len(props) < 2 {
+		return 0, nil, nil, errAESProps
+	}
+	saltSize := int(props[0]>>7&1 + props[1]>>4)
+	ivSize := int(props[0]>>6&1 + props[1]&0x0f)
+	if len(props) < 2+saltSize+ivSize {
+		return 0, nil, nil, errAESProps
+	}
+	iv := make([]byte, aes.BlockSize)
+	copy(iv, props[2+saltSize:2+saltSize+ivSize])
+	return cycles, props[2 : 2+saltSize], iv, nil
+}
+
+// aesCipher is the key and the salt of the encrypted folders.
+//
+// A random salt makes the key unique for each archive, the key is derived once
+// and each folder has a random IV, same as 7-Zip.
+type aesCipher struct {
+	key  []byte
+	salt []byte
+}
+
+func newAESCipher(password string) (*aesCipher, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return &aesCipher{key: aesKey(password, aesCycles, salt), salt: salt}, nil
+}
+
+// aesWriter encrypts by AES-256-CBC, the last block is padded with zeros at Close.
+type aesWriter struct {
+	w    io.Writer
+	mode cipher.BlockMode
+	buf  []byte
+}
+
+// newWriter returns the writer of a folder with a random IV, and the coder properties.
+func (c *aesCipher) newWriter(w io.Writer) (*aesWriter, []byte, error) {
+	block, err := aes.NewCipher(c.key)
+	if err != nil {
+		return nil, nil, err
+	}
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(iv); err != nil {
+		return nil, nil, err
+	}
+	// 16 bytes salt and 16 bytes IV
+	props := append([]byte{aesCycles | 0xc0, 0xff}, c.salt...)
+	props = append(props, iv...)
+	return &aesWriter{w: w, mode: cipher.NewCBCEncrypter(block, iv)}, props, nil
+}
+
+func (a *aesWriter) Write(p []byte) (int, error) {
+	a.buf = append(a.buf, p...)
+	n := len(a.buf) - len(a.buf)%aes.BlockSize
+	if n == 0 {
+		return len(p), nil
+	}
+	a.mode.CryptBlocks(a.buf[:n], a.buf[:n])
+	if _, err := a.w.Write(a.buf[:n]); err != nil {
+		return 0, err
+	}
+	a.buf = append(a.buf[:0], a.buf[n:]...)
+	return len(p), nil
+}
+
+func (a *aesWriter) Close() error {
+	if len(a.buf) == 0 {
+		return nil
+	}
+	pad := make([]byte, aes.BlockSize-len(a.buf))
+	_, err := a.Write(pad)
+	return err
+}
+
+// aesReader decrypts AES-256-CBC, the padding of the last block is cut by the
+// output size of the coder.
+type aesReader struct {
+	r    io.Reader
+	mode cipher.BlockMode
+	buf  []byte
+	out  []byte
+}
+
+func newAESReader(r io.Reader, key, iv []byte) (*aesReader, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return &aesReader{r: r, mode: cipher.NewCBCDecrypter(block, iv), buf: make([]byte, 32<<10)}, nil
+}
+
+func (a *aesReader) Read(p []byte) (int, error) {
+	if len(a.out) == 0 {
+		n, err := io.ReadFull(a.r, a.buf)
+		n -= n % aes.BlockSize
+		if n == 0 {
+			if err == nil || err == io.ErrUnexpectedEOF {
+				err = io.EOF
+			}
+			return 0, err
+		}
+		a.mode.CryptBlocks(a.buf[:n], a.buf[:n])
+		a.out = a.buf[:n]
+	}
+	n := copy(p, a.out)
+	a.out = a.out[n:]
+	return n, nil
+}
@@ -0,0 +1,434 @@
+// Package _7zip
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package _7zip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/ulikunitz/xz/lzma"
+
+	"github.com/pashifika/compress"
+)
+
+// DefaultDictCap is the default LZMA dictionary size.
+const DefaultDictCap = 8 << 20
+
+var (
+	methodCopy  = []byte{0x00}
+	methodLZMA  = []byte{0x03, 0x01, 0x01}
+	methodLZMA2 = []byte{0x21}
+)
+
+type WriteCloser struct {
+	extensions    map[string]struct{}
+	nonSolid      bool
+	plainHeader   bool
+	password      string
+	encryptHeader bool
+	dictCap       int
+	close         func() error
+}
+
+func (wc *WriteCloser) Name() string { return "7zip" }
+
+// SetCompressedExt set the file extensions which are stored without compression.
+func (wc *WriteCloser) SetCompressedExt(ext map[string]struct{}) { wc.extensions = ext }
+
+// SetSolid set the files are compressed in one solid block (default),
+// or each file is compressed alone.
+func (wc *WriteCloser) SetSolid(solid bool) { wc.nonSolid = !solid }
+
+// SetHeaderCompression set the archive header is compressed (default).
+func (wc *WriteCloser) SetHeaderCompression(enable bool) { wc.plainHeader = !enable }
+
+// SetPassword set the password to encrypt the files by AES-256,
+// encryptHeader also encrypt the header (the file names).
+//
+// * password "" is disable the encryption.
+// * the encrypted files are compressed by LZMA instead of LZMA2, which sevenzip
+// can not read behind AES.
+func (wc *WriteCloser) SetPassword(password string, encryptHeader bool) {
+	wc.password, wc.encryptHeader = password, encryptHeader
+}
+
+// SetDictCap set the LZMA dictionary size, 0 is DefaultDictCap.
+func (wc *WriteCloser) SetDictCap(size int) { wc.dictCap = size }
+
+// item is an entry to write, in the archive order.
+type item struct {
+	index      int
+	name       string
+	body       io.Reader
+	isDir      bool
+	empty      bool // has no data
+	stored     bool // is not compressed
+	modTime    time.Time
+	attributes uint32
+}
+
+// Create writes the 7z archive of entries to w, the archive header is written
+// at the start of w so it is seeked back if w is an io.WriteSeeker,
+// otherwise the data are spooled to a temporary file.
+func (wc *WriteCloser) Create(w io.Writer, entries []compress.ArchiverFile) error {
+	items, err := wc.items(entries)
+	if err != nil {
+		return err
+	}
+	var key *aesCipher
+	if wc.password != "" {
+		if key, err = newAESCipher(wc.password); err != nil {
+			return err
+		}
+	}
+
+	var (
+		base         int64
+		ws, seekable = w.(io.WriteSeeker)
+		spool        *os.File
+	)
+	packed := &countWriter{}
+	if seekable {
+		if base, err = ws.Seek(0, io.SeekCurrent); err != nil {
+			return err
+		}
+		// the start header is written at last
+		if _, err = w.Write(make([]byte, 32)); err != nil {
+			return err
+		}
+		packed.w = w
+	} else {
+		if spool, err = os.CreateTemp("", "7zip-*"); err != nil {
+			return err
+		}
+		defer func() {
+			_ = spool.Close()
+			_ = os.Remove(spool.Name())
+		}()
+		packed.w = spool
+	}
+
+	folders, err := wc.writeFolders(packed, items, key)
+	if err != nil {
+		return err
+	}
+
+	var hw headerWriter
+	hw.WriteByte(idHeader)
+	if len(folders) > 0 {
+		hw.WriteByte(idMainStreamsInfo)
+		hw.streamsInfo(0, folders, true)
+	}
+	hw.filesInfo(items)
+	hw.WriteByte(idEnd)
+	header := hw.Bytes()
+	if !wc.plainHeader || key != nil && wc.encryptHeader {
+		var headerKey *aesCipher
+		if wc.encryptHeader {
+			headerKey = key
+		}
+		fw, err := wc.newFolder(packed, false, headerKey)
+		if err != nil {
+			return err
+		}
+		if _, err = fw.Write(header); err != nil {
+			return fmt.Errorf("7zip writing header\n  error: %w", err)
+		}
+		f, err := fw.Close()
+		if err != nil {
+			return fmt.Errorf("7zip writing header\n  error: %w", err)
+		}
+		f.crc = crc32.ChecksumIEEE(header)
+		var ew headerWriter
+		ew.WriteByte(idEncodedHeader)
+		ew.streamsInfo(uint64(packed.count)-f.packSize, []*folderInfo{f}, false)
+		header = ew.Bytes()
+	}
+
+	start := startHeader(uint64(packed.count), header)
+	if seekable {
+		if _, err = w.Write(header); err != nil {
+			return err
+		}
+		if _, err = ws.Seek(base, io.SeekStart); err != nil {
+			return err
+		}
+		if _, err = w.Write(start); err != nil {
+			return err
+		}
+		_, err = ws.Seek(base+32+packed.count+int64(len(header)), io.SeekStart)
+		return err
+	}
+	if _, err = w.Write(start); err != nil {
+		return err
+	}
+	if _, err = spool.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err = io.Copy(w, spool); err != nil {
+		return err
+	}
+	_, err = w.Write(header)
+	return err
+}
+
+// items returns the entries in the archive order: the entries without data,
+// the compressed files and the stored files.
+func (wc *WriteCloser) items(entries []compress.ArchiverFile) ([]*item, error) {
+	var empty, compressed, stored []*item
+	for i, entry := range entries {
+		it := &item{
+			index:      i,
+			name:       strings.TrimRight(entry.Root(), "/"),
+			body:       entry,
+			isDir:      entry.IsDir(),
+			modTime:    entry.ModTime(),
+			attributes: attributes(entry.Mode()),
+		}
+		if entry.Mode()&fs.ModeSymlink != 0 {
+			// symbolic links store the link target as body
+			if l, ok := entry.(compress.SymlinkFile); ok {
+				target, err := l.LinkTarget()
+				if err != nil {
+					return nil, fmt.Errorf("reading link [%d] %s\n  error: %w", i, it.name, err)
+				}
+				it.body = strings.NewReader(target)
+			}
+		} else if it.isDir {
+			it.empty = true
+			empty = append(empty, it)
+			continue
+		} else if entry.Size() == 0 {
+			// the size of a pipe or a lazily sized file is 0, the body is read to
+			// know whether it is empty
+			var b [1]byte
+			n, err := io.ReadFull(entry, b[:])
+			if err != nil && err != io.EOF {
+				return nil, fmt.Errorf("reading file [%d] %s\n  error: %w", i, it.name, err)
+			}
+			if n == 0 {
+				it.empty = true
+				empty = append(empty, it)
+				continue
+			}
+			it.body = io.MultiReader(bytes.NewReader(b[:n]), entry)
+		}
+		if _, ok := wc.extensions[strings.ToLower(path.Ext(it.name))]; ok {
+			it.stored = true
+			stored = append(stored, it)
+		} else {
+			compressed = append(compressed, it)
+		}
+	}
+	return append(append(empty, compressed...), stored...), nil
+}
+
+// writeFolders compress the files to w, in one solid folder for the compressed
+// files and one for the stored files, or one folder for each file if not solid.
+func (wc *WriteCloser) writeFolders(w io.Writer, items []*item, key *aesCipher) ([]*folderInfo, error) {
+	var (
+		folders []*folderInfo
+		fw      *folderWriter
+	)
+	closeFolder := func() error {
+		if fw == nil {
+			return nil
+		}
+		f, err := fw.Close()
+		if err != nil {
+			return err
+		}
+		folders = append(folders, f)
+		fw = nil
+		return nil
+	}
+	for _, it := range items {
+		if it.empty {
+			continue
+		}
+		if fw != nil && (wc.nonSolid || fw.stored != it.stored) {
+			if err := closeFolder(); err != nil {
+				return nil, err
+			}
+		}
+		if fw == nil {
+			var err error
+			if fw, err = wc.newFolder(w, it.stored, key); err != nil {
+				return nil, err
+			}
+		}
+		hash := crc32.NewIEEE()
+		n, err := io.Copy(io.MultiWriter(fw, hash), it.body)
+		if err != nil {
+			return nil, fmt.Errorf("writing file [%d] %s\n  error: %w", it.index, it.name, err)
+		}
+		fw.streams = append(fw.streams, uint64(n))
+		fw.digests = append(fw.digests, hash.Sum32())
+	}
+	if err := closeFolder(); err != nil {
+		return nil, err
+	}
+	return folders, nil
+}
+
+// folderWriter compress a folder: data -> LZMA2 or Copy -> AES (if key) -> packed.
+//
+// The encrypted folders and header are compressed by LZMA instead of LZMA2: the
+// sevenzip reader fails on the uncompressed chunks of LZMA2 behind AES, when a read
+// of the cipher returns no data, and it decodes the encrypted header itself.
+type folderWriter struct {
+	folderInfo
+	stored  bool
+	packed  *countWriter
+	coded   *countWriter
+	aes     *aesWriter
+	enc     io.WriteCloser
+	in      io.Writer
+	written int64
+}
+
+func (wc *WriteCloser) newFolder(w io.Writer, stored bool, key *aesCipher) (*folderWriter, error) {
+	fw := &folderWriter{stored: stored, packed: &countWriter{w: w}}
+	fw.coded = &countWriter{w: fw.packed}
+	if key != nil {
+		aw, props, err := key.newWriter(fw.packed)
+		if err != nil {
+			return nil, err
+		}
+		fw.aes = aw
+		fw.coded.w = aw
+		fw.coders = append(fw.coders, coderInfo{id: methodAES, props: props})
+	}
+	if stored {
+		fw.in = fw.coded
+		fw.coders = append(fw.coders, coderInfo{id: methodCopy})
+		return fw, nil
+	}
+
+	dictCap := wc.dictCap
+	if dictCap <= 0 {
+		dictCap = DefaultDictCap
+	}
+	if key != nil {
+		// the header of the classic format is not written, the size is unknown
+		// so the stream is ended by the EOS marker
+		props := lzma.Properties{LC: 3, LP: 0, PB: 2}
+		lw, err := lzma.WriterConfig{Properties: &props, DictCap: dictCap, EOSMarker: true}.
+			NewWriter(&skipWriter{w: fw.coded, n: lzmaHeaderLen})
+		if err != nil {
+			return nil, err
+		}
+		fw.enc, fw.in = lw, lw
+		coder := coderInfo{id: methodLZMA, props: make([]byte, 5)}
+		coder.props[0] = props.Code()
+		binary.LittleEndian.PutUint32(coder.props[1:], uint32(dictCap))
+		fw.coders = append(fw.coders, coder)
+		return fw, nil
+	}
+	// LZMA2 dictionary property, same as Lzma2Enc.c
+	prop := byte(0)
+	for prop < 40 && (2|int(prop&1))<<(prop/2+11) < dictCap {
+		prop++
+	}
+	lw, err := lzma.Writer2Config{DictCap: dictCap}.NewWriter2(fw.coded)
+	if err != nil {
+		return nil, err
+	}
+	fw.enc, fw.in = lw, lw
+	fw.coders = append(fw.coders, coderInfo{id: methodLZMA2, props: []byte{prop}})
+	return fw, nil
+}
+
+func (fw *folderWriter) Write(p []byte) (int, error) {
+	n, err := fw.in.Write(p)
+	fw.written += int64(n)
+	return n, err
+}
+
+// Close flush the folder, and returns its info.
+func (fw *folderWriter) Close() (*folderInfo, error) {
+	if fw.enc != nil {
+		if err := fw.enc.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if fw.aes != nil {
+		if err := fw.aes.Close(); err != nil {
+			return nil, err
+		}
+		fw.sizes = append(fw.sizes, uint64(fw.coded.count))
+	}
+	fw.sizes = append(fw.sizes, uint64(fw.written))
+	fw.packSize = uint64(fw.packed.count)
+	return &fw.folderInfo, nil
+}
+
+func (wc *WriteCloser) Close() error {
+	if wc.close != nil {
+		return wc.close()
+	}
+	return nil
+}
+
+func (wc *WriteCloser) Reset() {
+	*wc = WriteCloser{}
+}
+
+type countWriter struct {
+	w     io.Writer
+	count int64
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.count += int64(n)
+	return n, err
+}
+
+// lzmaHeaderLen is the size of the LZMA classic format header.
+const lzmaHeaderLen = 13
+
+// skipWriter discards the first n bytes.
+type skipWriter struct {
+	w io.Writer
+	n int
+}
+
+func (s *skipWriter) Write(p []byte) (int, error) {
+	l := len(p)
+	if s.n > 0 {
+		if len(p) <= s.n {
+			s.n -= len(p)
+			return l, nil
+		}
+		p = p[s.n:]
+		s.n = 0
+	}
+	if _, err := s.w.Write(p); err != nil {
+		return 0, err
+	}
+	return l, nil
+}
@@ -18,6 +18,7 @@
 package _7zip
 
 import (
+	"io"
 	"io/fs"
 	"path"
 	"time"
@@ -34,8 +35,13 @@ type File struct {
 	size  int64
 	mode  fs.FileMode
 
+	// the solid folder and the offset in it, folder is -1 if f has no data
+	folder int
+	offset int64
+
 	dirReadAt  int
 	dirEntries func(path string, n int) ([]fs.DirEntry, error)
+	fileOpen   func(f *File) (io.ReadCloser, error)
 	rcRead     func(p []byte) (n int, err error)
 	close      func() error
 }
@@ -51,7 +57,7 @@ func (f *File) Write(_ []byte) (n int, err error) {
 }
 
 func (f *File) OpenFile() error {
-	rc, err := f.f.Open()
+	rc, err := f.fileOpen(f)
 	if err != nil {
 		return err
 	}
@@ -60,21 +66,33 @@ func (f *File) OpenFile() error {
 		err := rc.Close()
 		f.rcRead = nil
 		f.close = nil
-		f.f = nil
 		return err
 	}
 	return nil
 }
 
-// ------ to fs.FileInfo ------
-
-func (f *File) Mode() fs.FileMode {
-	if f.f == nil {
-		return f.mode
+// readLink returns the symbolic link target, which is stored as the file data.
+//
+// The target is read by fileOpen as the other files: sevenzip overwrites the IV of
+// the AES properties when it derives the key, so it opens an encrypted folder once.
+func (f *File) readLink() (string, error) {
+	rc, err := f.fileOpen(f)
+	if err != nil {
+		return "", err
 	}
-	return f.f.Mode()
+	//goland:noinspection ALL
+	defer rc.Close()
+	target, err := io.ReadAll(rc)
+	if err != nil {
+		return "", err
+	}
+	return string(target), nil
 }
 
+// ------ to fs.FileInfo ------
+
+func (f *File) Mode() fs.FileMode { return f.mode }
+
 func (f *File) ModTime() time.Time {
 	if f.f == nil {
 		return time.Time{}
@@ -111,7 +129,7 @@ func (f *File) Name() string {
 	return path.Base(f.name)
 }
 
-func (f *File) Type() fs.FileMode { return f.mode }
+func (f *File) Type() fs.FileMode { return f.mode.Type() }
 
 func (f *File) Info() (fs.FileInfo, error) { return f, nil }
 
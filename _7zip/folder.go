@@ -0,0 +1,822 @@
+// Package _7zip
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package _7zip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/ulikunitz/xz/lzma"
+)
+
+var errCorruptHeader = errors.New("7zip: corrupt header")
+
+// solidFolder is a folder decoded from the packed stream at offset.
+type solidFolder struct {
+	folderInfo
+	offset int64
+}
+
+// location is the folder of a file and its offset in the folder,
+// folder is -1 if the file has no data.
+type location struct {
+	folder       int
+	offset, size uint64
+}
+
+// solidReader decodes the solid folders of the archive from its header,
+// sevenzip decodes a folder from the start again for each file and does not
+// export the folders of the files.
+type solidReader struct {
+	f        *os.File
+	size     int64
+	password string
+	keys     map[string][]byte // the AES keys by cycles and salt
+
+	folders []*solidFolder // nil if a coder is not supported
+	files   []location
+}
+
+// openSolid reads the folders of the 7z archive at path, it fails for the
+// multi-volume archives.
+func openSolid(path, password string) (*solidReader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	s := &solidReader{f: f, size: info.Size(), password: password, keys: map[string][]byte{}}
+	if err := s.readHeader(); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *solidReader) Close() error { return s.f.Close() }
+
+// folderOf returns the folder of the file at idx and its offset in the folder,
+// the folder is -1 if the file has no data or the folder can not be decoded.
+func (s *solidReader) folderOf(idx int, size uint64) (int, int64) {
+	if s == nil || idx >= len(s.files) {
+		return -1, 0
+	}
+	loc := s.files[idx]
+	if loc.folder < 0 || loc.size != size || s.folders[loc.folder] == nil {
+		return -1, 0
+	}
+	return loc.folder, int64(loc.offset)
+}
+
+// open returns the decoded stream of the folder from its start.
+func (s *solidReader) open(folder int) (io.Reader, error) {
+	if folder < 0 || folder >= len(s.folders) || s.folders[folder] == nil {
+		return nil, errCorruptHeader
+	}
+	return s.decode(s.folders[folder])
+}
+
+// readHeader reads the start header and the header, which is decoded first if it
+// is encoded.
+func (s *solidReader) readHeader() error {
+	start := make([]byte, 32)
+	if _, err := s.f.ReadAt(start, 0); err != nil {
+		return err
+	}
+	if !bytes.Equal(start[:len(signature)], signature) {
+		return errCorruptHeader
+	}
+	offset, size := binary.LittleEndian.Uint64(start[12:]), binary.LittleEndian.Uint64(start[20:])
+	if s.size < 32 || offset > uint64(s.size-32) || size > uint64(s.size-32)-offset {
+		return errCorruptHeader
+	}
+	next := make([]byte, size)
+	if _, err := s.f.ReadAt(next, int64(32+offset)); err != nil {
+		return err
+	}
+	for {
+		h := headerReader{bytes.NewReader(next)}
+		id, err := h.ReadByte()
+		if err != nil {
+			return errCorruptHeader
+		}
+		switch id {
+		case idHeader:
+			return s.readMain(h)
+		case idEncodedHeader:
+			si, err := h.streamsInfo()
+			if err != nil {
+				return err
+			}
+			folders := s.solidFolders(si)
+			if len(folders) == 0 || folders[0] == nil {
+				return errCorruptHeader
+			}
+			r, err := s.decode(folders[0])
+			if err != nil {
+				return err
+			}
+			unpacked := folders[0].sizes[len(folders[0].sizes)-1]
+			if next, err = ioutil.ReadAll(r); err != nil {
+				return err
+			}
+			if uint64(len(next)) != unpacked {
+				return errCorruptHeader
+			}
+		default:
+			return errCorruptHeader
+		}
+	}
+}
+
+// readMain reads the folders of the main streams and the files which have no data.
+func (s *solidReader) readMain(h headerReader) error {
+	var (
+		si    *streamsInfo
+		empty []bool
+		files = -1
+	)
+	for {
+		id, err := h.ReadByte()
+		if err != nil {
+			return errCorruptHeader
+		}
+		switch id {
+		case idArchiveProps:
+			if err := h.skipProperties(); err != nil {
+				return err
+			}
+		case idAdditionalInfo:
+			if _, err := h.streamsInfo(); err != nil {
+				return err
+			}
+		case idMainStreamsInfo:
+			if si, err = h.streamsInfo(); err != nil {
+				return err
+			}
+		case idFilesInfo:
+			if files, empty, err = h.filesInfo(); err != nil {
+				return err
+			}
+		case idEnd:
+			if files < 0 {
+				return errCorruptHeader
+			}
+			if si == nil {
+				// only the files without data
+				si = &streamsInfo{}
+			}
+			s.folders = s.solidFolders(si)
+			return s.locate(si, files, empty)
+		default:
+			return errCorruptHeader
+		}
+	}
+}
+
+// locate sets the folder and offset of the files in the order of the folder streams.
+func (s *solidReader) locate(si *streamsInfo, files int, empty []bool) error {
+	s.files = make([]location, files)
+	folder, stream := 0, 0
+	var offset uint64
+	for i := range s.files {
+		if i < len(empty) && empty[i] {
+			s.files[i].folder = -1
+			continue
+		}
+		for folder < len(si.streams) && stream >= len(si.streams[folder]) {
+			folder, stream, offset = folder+1, 0, 0
+		}
+		if folder >= len(si.streams) {
+			return errCorruptHeader
+		}
+		size := si.streams[folder][stream]
+		s.files[i] = location{folder: folder, offset: offset, size: size}
+		offset += size
+		stream++
+	}
+	return nil
+}
+
+// solidFolders returns the folders of si with their packed streams in the archive,
+// the folders which can not be decoded are nil.
+func (s *solidReader) solidFolders(si *streamsInfo) []*solidFolder {
+	folders := make([]*solidFolder, len(si.folders))
+	pack := 0
+	offset := 32 + si.packPos
+	for i, f := range si.folders {
+		if pack+f.packed > len(si.packSizes) {
+			break
+		}
+		start := offset
+		for _, size := range si.packSizes[pack : pack+f.packed] {
+			offset += size
+		}
+		pack += f.packed
+		if start < si.packPos || offset < start || offset > uint64(s.size) {
+			break
+		}
+		coders, sizes := f.chain()
+		if coders == nil || f.packed != 1 || !s.supported(coders) {
+			continue
+		}
+		folders[i] = &solidFolder{
+			folderInfo: folderInfo{coders: coders, sizes: sizes, packSize: offset - start},
+			offset:     int64(start),
+		}
+	}
+	return folders
+}
+
+// supported reports whether the coders can be decoded.
+func (s *solidReader) supported(coders []coderInfo) bool {
+	for _, c := range coders {
+		switch {
+		case bytes.Equal(c.id, methodCopy):
+		case bytes.Equal(c.id, methodLZMA):
+			if len(c.props) != 5 {
+				return false
+			}
+		case bytes.Equal(c.id, methodLZMA2):
+			if len(c.props) != 1 || c.props[0] > 40 {
+				return false
+			}
+		case bytes.Equal(c.id, methodAES):
+			if _, _, _, err := aesProps(c.props); err != nil || s.password == "" {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// decode returns the output of the last coder of f.
+func (s *solidReader) decode(f *solidFolder) (io.Reader, error) {
+	var r io.Reader = io.NewSectionReader(s.f, f.offset, int64(f.packSize))
+	for i, c := range f.coders {
+		size := f.sizes[i]
+		var err error
+		switch {
+		case bytes.Equal(c.id, methodLZMA):
+			header := make([]byte, 13)
+			header[0] = c.props[0]
+			binary.LittleEndian.PutUint32(header[1:], uint32(dictCap(uint64(binary.LittleEndian.Uint32(c.props[1:])), size)))
+			binary.LittleEndian.PutUint64(header[5:], size)
+			r, err = lzma.ReaderConfig{DictCap: lzma.MinDictCap}.NewReader(io.MultiReader(bytes.NewReader(header), r))
+		case bytes.Equal(c.id, methodLZMA2):
+			dict := uint64(0xffffffff)
+			if p := c.props[0]; p < 40 {
+				dict = uint64(2|p&1) << (p/2 + 11)
+			}
+			r, err = lzma.Reader2Config{DictCap: dictCap(dict, size)}.NewReader2(r)
+		case bytes.Equal(c.id, methodAES):
+			r, err = s.aesReader(r, c.props)
+		}
+		if err != nil {
+			return nil, err
+		}
+		r = io.LimitReader(r, int64(size))
+	}
+	return r, nil
+}
+
+// dictCap returns the dictionary size bounded by the output size,
+// a larger dictionary is never used.
+func dictCap(dict, size uint64) int {
+	if dict > size {
+		dict = size
+	}
+	if dict < lzma.MinDictCap {
+		dict = lzma.MinDictCap
+	}
+	return int(dict)
+}
+
+// aesReader returns the decrypted r, the key of each salt is derived once.
+func (s *solidReader) aesReader(r io.Reader, props []byte) (io.Reader, error) {
+	cycles, salt, iv, err := aesProps(props)
+	if err != nil {
+		return nil, err
+	}
+	id := string(append([]byte{byte(cycles)}, salt...))
+	key, ok := s.keys[id]
+	if !ok {
+		key = aesKey(s.password, cycles, salt)
+		s.keys[id] = key
+	}
+	return newAESReader(r, key, iv)
+}
+
+// rawFolder is a folder as stored in the header.
+type rawFolder struct {
+	coders []rawCoder
+	pairs  [][2]uint64 // the in and out streams bound together
+	packed int         // the number of packed streams
+	sizes  []uint64    // the size of each out stream
+	crc    bool
+}
+
+type rawCoder struct {
+	coderInfo
+	in, out uint64
+}
+
+// chain returns the coders in the decoding order with their output sizes,
+// nil if a coder has more than one input or output.
+func (f *rawFolder) chain() ([]coderInfo, []uint64) {
+	n := uint64(len(f.coders))
+	if n == 0 || uint64(len(f.pairs)) != n-1 || uint64(len(f.sizes)) != n {
+		return nil, nil
+	}
+	for _, c := range f.coders {
+		if c.in != 1 || c.out != 1 {
+			return nil, nil
+		}
+	}
+	// the coder reading the packed stream is the one whose input is not bound
+	bound := make([]bool, n)
+	for _, p := range f.pairs {
+		if p[0] >= n || p[1] >= n {
+			return nil, nil
+		}
+		bound[p[0]] = true
+	}
+	cur := -1
+	for i, b := range bound {
+		if !b {
+			cur = i
+			break
+		}
+	}
+	coders := make([]coderInfo, 0, n)
+	sizes := make([]uint64, 0, n)
+	seen := make([]bool, n)
+	for cur >= 0 && !seen[cur] {
+		seen[cur] = true
+		coders = append(coders, f.coders[cur].coderInfo)
+		sizes = append(sizes, f.sizes[cur])
+		next := -1
+		for _, p := range f.pairs {
+			if p[1] == uint64(cur) {
+				next = int(p[0])
+			}
+		}
+		cur = next
+	}
+	if uint64(len(coders)) != n || cur >= 0 {
+		return nil, nil
+	}
+	return coders, sizes
+}
+
+// unpackSize returns the size of the out stream which is not bound.
+func (f *rawFolder) unpackSize() uint64 {
+	for i := len(f.sizes) - 1; i >= 0; i-- {
+		bound := false
+		for _, p := range f.pairs {
+			bound = bound || p[1] == uint64(i)
+		}
+		if !bound {
+			return f.sizes[i]
+		}
+	}
+	return 0
+}
+
+// streamsInfo is the main or header streams of the archive.
+type streamsInfo struct {
+	packPos   uint64
+	packSizes []uint64
+	folders   []*rawFolder
+	streams   [][]uint64 // the size of each file of each folder
+}
+
+// headerReader reads the 7z header structures written by headerWriter.
+type headerReader struct {
+	*bytes.Reader
+}
+
+// number reads the 7z variable length number.
+func (h headerReader) number() (uint64, error) {
+	first, err := h.ReadByte()
+	if err != nil {
+		return 0, errCorruptHeader
+	}
+	var v uint64
+	mask := byte(0x80)
+	for i := 0; i < 8; i++ {
+		if first&mask == 0 {
+			return v | uint64(first&(mask-1))<<(8*i), nil
+		}
+		b, err := h.ReadByte()
+		if err != nil {
+			return 0, errCorruptHeader
+		}
+		v |= uint64(b) << (8 * i)
+		mask >>= 1
+	}
+	return v, nil
+}
+
+// count reads a number of items, which are at least a bit each.
+func (h headerReader) count() (int, error) {
+	n, err := h.number()
+	if err != nil {
+		return 0, err
+	}
+	if n > uint64(h.Len())*8 {
+		return 0, errCorruptHeader
+	}
+	return int(n), nil
+}
+
+// next returns the next n bytes.
+func (h headerReader) next(n uint64) ([]byte, error) {
+	if n > uint64(h.Len()) {
+		return nil, errCorruptHeader
+	}
+	b := make([]byte, n)
+	_, _ = h.Read(b)
+	return b, nil
+}
+
+func (h headerReader) expect(id byte) error {
+	if b, err := h.ReadByte(); err != nil || b != id {
+		return errCorruptHeader
+	}
+	return nil
+}
+
+func (h headerReader) bits(n int) ([]bool, error) {
+	b, err := h.next(uint64(n+7) / 8)
+	if err != nil {
+		return nil, err
+	}
+	v := make([]bool, n)
+	for i := range v {
+		v[i] = b[i/8]&(0x80>>(i%8)) != 0
+	}
+	return v, nil
+}
+
+// defined reads the bits which may be all set.
+func (h headerReader) defined(n int) ([]bool, error) {
+	all, err := h.ReadByte()
+	if err != nil {
+		return nil, errCorruptHeader
+	}
+	if all == 0 {
+		return h.bits(n)
+	}
+	v := make([]bool, n)
+	for i := range v {
+		v[i] = true
+	}
+	return v, nil
+}
+
+// digests skips the CRC of n streams, and returns which are defined.
+func (h headerReader) digests(n int) ([]bool, error) {
+	defined, err := h.defined(n)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range defined {
+		if d {
+			if _, err := h.next(4); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return defined, nil
+}
+
+func (h headerReader) skipProperties() error {
+	for {
+		id, err := h.ReadByte()
+		if err != nil {
+			return errCorruptHeader
+		}
+		if id == idEnd {
+			return nil
+		}
+		size, err := h.number()
+		if err != nil {
+			return err
+		}
+		if _, err := h.next(size); err != nil {
+			return err
+		}
+	}
+}
+
+func (h headerReader) streamsInfo() (*streamsInfo, error) {
+	si := &streamsInfo{}
+	for {
+		id, err := h.ReadByte()
+		if err != nil {
+			return nil, errCorruptHeader
+		}
+		switch id {
+		case idPackInfo:
+			err = h.packInfo(si)
+		case idUnpackInfo:
+			err = h.unpackInfo(si)
+		case idSubStreamsInfo:
+			err = h.subStreamsInfo(si)
+		case idEnd:
+			if si.streams == nil {
+				// one file in each folder
+				si.streams = make([][]uint64, len(si.folders))
+				for i, f := range si.folders {
+					si.streams[i] = []uint64{f.unpackSize()}
+				}
+			}
+			return si, nil
+		default:
+			return nil, errCorruptHeader
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (h headerReader) packInfo(si *streamsInfo) error {
+	var err error
+	if si.packPos, err = h.number(); err != nil {
+		return err
+	}
+	n, err := h.count()
+	if err != nil {
+		return err
+	}
+	for {
+		id, err := h.ReadByte()
+		if err != nil {
+			return errCorruptHeader
+		}
+		switch id {
+		case idSize:
+			si.packSizes = make([]uint64, n)
+			for i := range si.packSizes {
+				if si.packSizes[i], err = h.number(); err != nil {
+					return err
+				}
+			}
+		case idCRC:
+			if _, err := h.digests(n); err != nil {
+				return err
+			}
+		case idEnd:
+			if len(si.packSizes) != n {
+				return errCorruptHeader
+			}
+			return nil
+		default:
+			return errCorruptHeader
+		}
+	}
+}
+
+func (h headerReader) unpackInfo(si *streamsInfo) error {
+	if err := h.expect(idFolder); err != nil {
+		return err
+	}
+	n, err := h.count()
+	if err != nil {
+		return err
+	}
+	if err := h.expect(0); err != nil { // external
+		return err
+	}
+	si.folders = make([]*rawFolder, n)
+	for i := range si.folders {
+		if si.folders[i], err = h.folder(); err != nil {
+			return err
+		}
+	}
+	if err := h.expect(idCodersUnpackSize); err != nil {
+		return err
+	}
+	for _, f := range si.folders {
+		for i := range f.sizes {
+			if f.sizes[i], err = h.number(); err != nil {
+				return err
+			}
+		}
+	}
+	for {
+		id, err := h.ReadByte()
+		if err != nil {
+			return errCorruptHeader
+		}
+		switch id {
+		case idCRC:
+			defined, err := h.digests(n)
+			if err != nil {
+				return err
+			}
+			for i, d := range defined {
+				si.folders[i].crc = d
+			}
+		case idEnd:
+			return nil
+		default:
+			return errCorruptHeader
+		}
+	}
+}
+
+func (h headerReader) folder() (*rawFolder, error) {
+	n, err := h.count()
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, errCorruptHeader
+	}
+	f := &rawFolder{coders: make([]rawCoder, n)}
+	var in, out uint64
+	for i := range f.coders {
+		c := &f.coders[i]
+		flag, err := h.ReadByte()
+		if err != nil || flag&0x80 != 0 {
+			return nil, errCorruptHeader
+		}
+		if c.id, err = h.next(uint64(flag & 0x0f)); err != nil {
+			return nil, err
+		}
+		c.in, c.out = 1, 1
+		if flag&0x10 != 0 {
+			if c.in, err = h.number(); err != nil {
+				return nil, err
+			}
+			if c.out, err = h.number(); err != nil {
+				return nil, err
+			}
+		}
+		if flag&0x20 != 0 {
+			size, err := h.number()
+			if err != nil {
+				return nil, err
+			}
+			if c.props, err = h.next(size); err != nil {
+				return nil, err
+			}
+		}
+		in, out = in+c.in, out+c.out
+		if in > uint64(h.Len()) || out > uint64(h.Len()) {
+			return nil, errCorruptHeader
+		}
+	}
+	if out == 0 || in < out-1 {
+		return nil, errCorruptHeader
+	}
+	f.pairs = make([][2]uint64, out-1)
+	for i := range f.pairs {
+		if f.pairs[i][0], err = h.number(); err != nil {
+			return nil, err
+		}
+		if f.pairs[i][1], err = h.number(); err != nil {
+			return nil, err
+		}
+	}
+	f.packed = int(in - (out - 1))
+	if f.packed > 1 {
+		for i := 0; i < f.packed; i++ {
+			if _, err := h.number(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	f.sizes = make([]uint64, out)
+	return f, nil
+}
+
+func (h headerReader) subStreamsInfo(si *streamsInfo) error {
+	counts := make([]int, len(si.folders))
+	for i := range counts {
+		counts[i] = 1
+	}
+	si.streams = make([][]uint64, len(si.folders))
+	sized := false
+	for {
+		id, err := h.ReadByte()
+		if err != nil {
+			return errCorruptHeader
+		}
+		switch id {
+		case idNumUnpackStream:
+			for i := range counts {
+				if counts[i], err = h.count(); err != nil {
+					return err
+				}
+			}
+		case idSize:
+			if err := h.streamSizes(si, counts); err != nil {
+				return err
+			}
+			sized = true
+		case idCRC:
+			n := 0
+			for i, count := range counts {
+				if count != 1 || !si.folders[i].crc {
+					n += count
+				}
+			}
+			if _, err := h.digests(n); err != nil {
+				return err
+			}
+		case idEnd:
+			if !sized {
+				return h.streamSizes(si, counts)
+			}
+			return nil
+		default:
+			return errCorruptHeader
+		}
+	}
+}
+
+// streamSizes sets the size of the files of each folder, the last one is the
+// rest of the folder which is not stored.
+func (h headerReader) streamSizes(si *streamsInfo, counts []int) error {
+	for i, f := range si.folders {
+		if counts[i] == 0 {
+			si.streams[i] = nil
+			continue
+		}
+		sizes := make([]uint64, counts[i])
+		var total uint64
+		for j := range sizes[:len(sizes)-1] {
+			size, err := h.number()
+			if err != nil {
+				return err
+			}
+			if size > f.unpackSize()-total {
+				return errCorruptHeader
+			}
+			sizes[j] = size
+			total += size
+		}
+		sizes[len(sizes)-1] = f.unpackSize() - total
+		si.streams[i] = sizes
+	}
+	return nil
+}
+
+func (h headerReader) filesInfo() (int, []bool, error) {
+	n, err := h.count()
+	if err != nil {
+		return 0, nil, err
+	}
+	var empty []bool
+	for {
+		id, err := h.number()
+		if err != nil {
+			return 0, nil, err
+		}
+		if id == idEnd {
+			return n, empty, nil
+		}
+		size, err := h.number()
+		if err != nil {
+			return 0, nil, err
+		}
+		data, err := h.next(size)
+		if err != nil {
+			return 0, nil, err
+		}
+		if id == idEmptyStream {
+			if empty, err = (headerReader{bytes.NewReader(data)}).bits(n); err != nil {
+				return 0, nil, err
+			}
+		}
+	}
+}
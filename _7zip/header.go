@@ -0,0 +1,297 @@
+// Package _7zip
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package _7zip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io/fs"
+	"time"
+	"unicode/utf16"
+)
+
+// 7z header property IDs
+const (
+	idEnd              = 0x00
+	idHeader           = 0x01
+	idArchiveProps     = 0x02
+	idAdditionalInfo   = 0x03
+	idMainStreamsInfo  = 0x04
+	idFilesInfo        = 0x05
+	idPackInfo         = 0x06
+	idUnpackInfo       = 0x07
+	idSubStreamsInfo   = 0x08
+	idSize             = 0x09
+	idCRC              = 0x0a
+	idFolder           = 0x0b
+	idCodersUnpackSize = 0x0c
+	idNumUnpackStream  = 0x0d
+	idEmptyStream      = 0x0e
+	idEmptyFile        = 0x0f
+	idName             = 0x11
+	idMTime            = 0x14
+	idWinAttributes    = 0x15
+	idEncodedHeader    = 0x17
+)
+
+var signature = []byte{'7', 'z', 0xbc, 0xaf, 0x27, 0x1c}
+
+type coderInfo struct {
+	id, props []byte
+}
+
+// folderInfo is a folder of the archive, the first coder reads the packed stream
+// and the output of each coder is the input of the next one.
+type folderInfo struct {
+	coders   []coderInfo
+	sizes    []uint64 // the output size of each coder
+	packSize uint64
+	crc      uint32   // the CRC of the output, only written for the header folder
+	streams  []uint64 // the size of each file in the folder
+	digests  []uint32 // the CRC of each file in the folder
+}
+
+// headerWriter writes the 7z header structures.
+type headerWriter struct {
+	bytes.Buffer
+}
+
+// number writes the 7z variable length number.
+func (h *headerWriter) number(v uint64) {
+	n := 0
+	for n < 8 && v >= 1<<(7*(n+1)) {
+		n++
+	}
+	var b [9]byte
+	if n == 8 {
+		b[0] = 0xff
+	} else {
+		b[0] = byte(0xff<<(8-n)) | byte(v>>(8*n))
+	}
+	binary.LittleEndian.PutUint64(b[1:], v)
+	h.Write(b[:1+n])
+}
+
+func (h *headerWriter) uint32(v uint32) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], v)
+	h.Write(b[:])
+}
+
+func (h *headerWriter) uint64(v uint64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	h.Write(b[:])
+}
+
+func (h *headerWriter) bits(v []bool) {
+	b := make([]byte, (len(v)+7)/8)
+	for i, set := range v {
+		if set {
+			b[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+	h.Write(b)
+}
+
+// streamsInfo writes the folders packed from packPos, the CRC of the files are written
+// by the substreams, or the CRC of the folders if files is false.
+func (h *headerWriter) streamsInfo(packPos uint64, folders []*folderInfo, files bool) {
+	h.WriteByte(idPackInfo)
+	h.number(packPos)
+	h.number(uint64(len(folders)))
+	h.WriteByte(idSize)
+	for _, f := range folders {
+		h.number(f.packSize)
+	}
+	h.WriteByte(idEnd)
+
+	h.WriteByte(idUnpackInfo)
+	h.WriteByte(idFolder)
+	h.number(uint64(len(folders)))
+	h.WriteByte(0) // not external
+	for _, f := range folders {
+		h.folder(f)
+	}
+	h.WriteByte(idCodersUnpackSize)
+	for _, f := range folders {
+		for _, size := range f.sizes {
+			h.number(size)
+		}
+	}
+	if !files {
+		h.WriteByte(idCRC)
+		h.WriteByte(1) // all defined
+		for _, f := range folders {
+			h.uint32(f.crc)
+		}
+	}
+	h.WriteByte(idEnd)
+
+	if files {
+		h.subStreamsInfo(folders)
+	}
+	h.WriteByte(idEnd)
+}
+
+func (h *headerWriter) folder(f *folderInfo) {
+	h.number(uint64(len(f.coders)))
+	for _, c := range f.coders {
+		flag := byte(len(c.id))
+		if len(c.props) > 0 {
+			flag |= 0x20
+		}
+		h.WriteByte(flag)
+		h.Write(c.id)
+		if len(c.props) > 0 {
+			h.number(uint64(len(c.props)))
+			h.Write(c.props)
+		}
+	}
+	// bind the output of each coder to the input of the next one
+	for i := 1; i < len(f.coders); i++ {
+		h.number(uint64(i))
+		h.number(uint64(i - 1))
+	}
+}
+
+func (h *headerWriter) subStreamsInfo(folders []*folderInfo) {
+	h.WriteByte(idSubStreamsInfo)
+	single, multi := true, false
+	for _, f := range folders {
+		single = single && len(f.streams) == 1
+		multi = multi || len(f.streams) > 1
+	}
+	if !single {
+		h.WriteByte(idNumUnpackStream)
+		for _, f := range folders {
+			h.number(uint64(len(f.streams)))
+		}
+	}
+	if multi {
+		h.WriteByte(idSize)
+		for _, f := range folders {
+			for _, size := range f.streams[:len(f.streams)-1] {
+				h.number(size)
+			}
+		}
+	}
+	// sevenzip reads a CRC for every file, so all are defined
+	h.WriteByte(idCRC)
+	h.WriteByte(1)
+	for _, f := range folders {
+		for _, crc := range f.digests {
+			h.uint32(crc)
+		}
+	}
+	h.WriteByte(idEnd)
+}
+
+// filesInfo writes the entries, the entries without data must be the first ones
+// (required by sevenzip to read the empty files).
+func (h *headerWriter) filesInfo(items []*item) {
+	h.WriteByte(idFilesInfo)
+	h.number(uint64(len(items)))
+
+	var emptyStream, emptyFile []bool
+	hasEmpty, hasFile := false, false
+	for _, it := range items {
+		emptyStream = append(emptyStream, it.empty)
+		if it.empty {
+			hasEmpty = true
+			emptyFile = append(emptyFile, !it.isDir)
+			hasFile = hasFile || !it.isDir
+		}
+	}
+	if hasEmpty {
+		h.WriteByte(idEmptyStream)
+		h.number(uint64((len(emptyStream) + 7) / 8))
+		h.bits(emptyStream)
+	}
+	if hasFile {
+		h.WriteByte(idEmptyFile)
+		h.number(uint64((len(emptyFile) + 7) / 8))
+		h.bits(emptyFile)
+	}
+
+	var names headerWriter
+	names.WriteByte(0) // not external
+	for _, it := range items {
+		for _, c := range utf16.Encode([]rune(it.name)) {
+			names.WriteByte(byte(c))
+			names.WriteByte(byte(c >> 8))
+		}
+		names.Write([]byte{0, 0})
+	}
+	h.WriteByte(idName)
+	h.number(uint64(names.Len()))
+	h.Write(names.Bytes())
+
+	h.WriteByte(idMTime)
+	h.number(uint64(2 + 8*len(items)))
+	h.Write([]byte{1, 0}) // all defined, not external
+	for _, it := range items {
+		h.uint64(fileTime(it.modTime))
+	}
+
+	h.WriteByte(idWinAttributes)
+	h.number(uint64(2 + 4*len(items)))
+	h.Write([]byte{1, 0}) // all defined, not external
+	for _, it := range items {
+		h.uint32(it.attributes)
+	}
+	h.WriteByte(idEnd)
+}
+
+// startHeader returns the signature header pointing to the next header.
+func startHeader(offset uint64, next []byte) []byte {
+	b := make([]byte, 32)
+	copy(b, signature)
+	b[7] = 4 // version 0.4
+	binary.LittleEndian.PutUint64(b[12:], offset)
+	binary.LittleEndian.PutUint64(b[20:], uint64(len(next)))
+	binary.LittleEndian.PutUint32(b[28:], crc32.ChecksumIEEE(next))
+	binary.LittleEndian.PutUint32(b[8:], crc32.ChecksumIEEE(b[12:]))
+	return b
+}
+
+// fileTime returns the Windows FILETIME of t.
+func fileTime(t time.Time) uint64 {
+	if t.IsZero() {
+		return 0
+	}
+	return uint64(t.UnixNano()/100 + 116444736000000000)
+}
+
+// attributes returns the Windows attributes of mode, with the Unix mode in the high 16 bits.
+func attributes(mode fs.FileMode) uint32 {
+	var attr, unix uint32
+	switch {
+	case mode.IsDir():
+		attr, unix = 0x10, 0040000
+	case mode&fs.ModeSymlink != 0:
+		attr, unix = 0x20, 0120000
+	default:
+		attr, unix = 0x20, 0100000
+	}
+	if mode&0200 == 0 {
+		attr |= 0x01 // read-only
+	}
+	return attr | 0x8000 | (unix|uint32(mode.Perm()))<<16
+}
@@ -23,4 +23,5 @@ import (
 
 func init() {
 	compress.RegisterDecoder(&ReadCloser{})
+	compress.RegisterEncoder(&WriteCloser{})
 }
@@ -0,0 +1,199 @@
+// Package _7zip
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package _7zip
+
+import (
+	"bytes"
+	"container/list"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/pashifika/compress"
+)
+
+// DefaultCacheSize is the default memory limit of the cached entries of solid folders.
+const DefaultCacheSize = 32 << 20
+
+// cursor is the decoded stream of a solid folder at pos.
+type cursor struct {
+	folder int
+	r      io.Reader
+	pos    int64
+}
+
+func (c *cursor) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.pos += int64(n)
+	return n, err
+}
+
+// skip decode the folder up to offset, the entries which are passed are cached.
+func (c *cursor) skip(rc *ReadCloser, offset int64) error {
+	entries := rc.folders[c.folder]
+	i := sort.Search(len(entries), func(i int) bool { return entries[i].offset >= c.pos })
+	for ; i < len(entries) && entries[i].offset < offset; i++ {
+		e := entries[i]
+		if _, err := io.CopyN(ioutil.Discard, c, e.offset-c.pos); err != nil {
+			return err
+		}
+		if !rc.cache.fit(e.size) {
+			continue
+		}
+		data := make([]byte, e.size)
+		if _, err := io.ReadFull(c, data); err != nil {
+			return err
+		}
+		rc.cache.put(e, data)
+	}
+	_, err := io.CopyN(ioutil.Discard, c, offset-c.pos)
+	return err
+}
+
+// openEntry returns the reader of f, reusing the decoded stream of its solid folder
+// instead of decoding it from the start. The files which are not in a folder read
+// by solidReader are opened by sevenzip.
+func (rc *ReadCloser) openEntry(f *File) (io.ReadCloser, error) {
+	if f.folder < 0 {
+		return f.f.Open()
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if data, ok := rc.cache.get(f); ok {
+		return ioutil.NopCloser(bytes.NewReader(data)), nil
+	}
+	c := rc.cursor
+	rc.cursor = nil
+	if c != nil && (c.folder != f.folder || c.pos > f.offset) {
+		c = nil
+	}
+	if c == nil {
+		r, err := rc.solid.open(f.folder)
+		if err != nil {
+			return nil, err
+		}
+		c = &cursor{folder: f.folder, r: r}
+	}
+	if err := c.skip(rc, f.offset); err != nil {
+		return nil, err
+	}
+	return &entryReader{rc: rc, c: c, n: f.size}, nil
+}
+
+// entryReader reads an entry from the cursor, and gives back the cursor when it is closed.
+type entryReader struct {
+	rc *ReadCloser
+	c  *cursor
+	n  int64
+}
+
+func (r *entryReader) Read(p []byte) (int, error) {
+	if r.n <= 0 {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > r.n {
+		p = p[:r.n]
+	}
+	n, err := r.c.Read(p)
+	r.n -= int64(n)
+	if err == io.EOF && r.n > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+func (r *entryReader) Close() error {
+	if r.c == nil {
+		return nil
+	}
+	r.rc.mu.Lock()
+	defer r.rc.mu.Unlock()
+	c := r.c
+	r.c = nil
+	if r.rc.cursor == nil || r.rc.cursor.folder != c.folder || r.rc.cursor.pos < c.pos {
+		r.rc.cursor = c
+	}
+	return nil
+}
+
+// Stream calls fn for each entry in the archive order, decoding each solid folder once.
+func (rc *ReadCloser) Stream(fn compress.StreamFunc) error {
+	for _, file := range rc.index[:len(rc.index)-1] {
+		if file.isDir {
+			if err := fn(file.name, file, strings.NewReader("")); err != nil {
+				return err
+			}
+			continue
+		}
+		r, err := rc.openEntry(file)
+		if err != nil {
+			return err
+		}
+		err = fn(file.name, file, r)
+		_ = r.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// folderCache is the LRU cache of the entries decoded from the solid folders.
+type folderCache struct {
+	limit int64
+	size  int64
+	lru   *list.List // *cacheItem, the front is the most recently used
+	items map[*File]*list.Element
+}
+
+type cacheItem struct {
+	f    *File
+	data []byte
+}
+
+func newFolderCache(limit int64) *folderCache {
+	return &folderCache{limit: limit, lru: list.New(), items: map[*File]*list.Element{}}
+}
+
+func (c *folderCache) fit(size int64) bool { return size <= c.limit }
+
+func (c *folderCache) get(f *File) ([]byte, bool) {
+	e, ok := c.items[f]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(e)
+	return e.Value.(*cacheItem).data, true
+}
+
+func (c *folderCache) put(f *File, data []byte) {
+	if _, ok := c.items[f]; ok || !c.fit(int64(len(data))) {
+		return
+	}
+	for c.size+int64(len(data)) > c.limit {
+		e := c.lru.Back()
+		item := e.Value.(*cacheItem)
+		c.lru.Remove(e)
+		delete(c.items, item.f)
+		c.size -= int64(len(item.data))
+	}
+	c.items[f] = c.lru.PushFront(&cacheItem{f: f, data: data})
+	c.size += int64(len(data))
+}
@@ -0,0 +1,259 @@
+// Package _7zip
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package _7zip
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bodgit/sevenzip"
+
+	"github.com/pashifika/compress"
+)
+
+// memFile is an entry to write, its data are read from memory.
+type memFile struct {
+	name string
+	data []byte
+	r    *bytes.Reader
+	lazy bool // the size is reported as 0, as a pipe
+}
+
+func (f *memFile) Root() string                         { return f.name }
+func (f *memFile) Name() string                         { return filepath.Base(f.name) }
+func (f *memFile) Size() int64                          { return int64(len(f.data)) }
+func (f *memFile) Mode() fs.FileMode                    { return 0644 }
+func (f *memFile) ModTime() time.Time                   { return time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC) }
+func (f *memFile) IsDir() bool                          { return false }
+func (f *memFile) Sys() interface{}                     { return nil }
+func (f *memFile) Type() fs.FileMode                    { return 0 }
+func (f *memFile) Info() (fs.FileInfo, error)           { return f, nil }
+func (f *memFile) Stat() (fs.FileInfo, error)           { return f, nil }
+func (f *memFile) ReadDir(_ int) ([]fs.DirEntry, error) { return nil, fs.ErrInvalid }
+func (f *memFile) Write(_ []byte) (int, error)          { return 0, compress.ErrWriterNotSupport }
+func (f *memFile) Close() error                         { return nil }
+func (f *memFile) Read(p []byte) (int, error)           { return f.r.Read(p) }
+
+// testFiles returns the files of the archives, with compressible, random,
+// stored and empty data.
+func testFiles() map[string][]byte {
+	rnd := rand.New(rand.NewSource(1))
+	files := map[string][]byte{"empty.txt": nil}
+	for i := 0; i < 12; i++ {
+		data := make([]byte, rnd.Intn(64<<10)+1)
+		if i%2 == 0 {
+			rnd.Read(data)
+		} else {
+			data = bytes.Repeat([]byte(fmt.Sprintf("file %d ", i)), len(data)/7+1)[:len(data)]
+		}
+		files[fmt.Sprintf("dir/file%02d.txt", i)] = data
+	}
+	files["image.jpg"] = bytes.Repeat([]byte{0xff, 0xd8}, 3000)
+	return files
+}
+
+func createArchive(t *testing.T, files map[string][]byte, setup func(wc *WriteCloser)) string {
+	var entries []compress.ArchiverFile
+	for name, data := range files {
+		entries = append(entries, &memFile{name: name, data: data, r: bytes.NewReader(data)})
+	}
+	return createEntries(t, entries, setup)
+}
+
+func createEntries(t *testing.T, entries []compress.ArchiverFile, setup func(wc *WriteCloser)) string {
+	wc := &WriteCloser{}
+	wc.SetCompressedExt(map[string]struct{}{".jpg": {}})
+	setup(wc)
+	name := filepath.Join(t.TempDir(), "test.7z")
+	f, err := os.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := wc.Create(f, entries); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func openArchive(t *testing.T, path, password string) *ReadCloser {
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec := &ReadCloser{}
+	dec.SetRootInfo(info)
+	fsys, err := dec.OpenReaderWithPassword(path, password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fsys.(*ReadCloser)
+}
+
+// TestSolid checks the solid folders are decoded from the header read by
+// solidReader, not by sevenzip, for every coder written by WriteCloser.
+func TestSolid(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		setup    func(wc *WriteCloser)
+	}{
+		{"lzma2", "", func(wc *WriteCloser) {}},
+		{"plain header", "", func(wc *WriteCloser) { wc.SetHeaderCompression(false) }},
+		{"not solid", "", func(wc *WriteCloser) { wc.SetSolid(false) }},
+		{"aes", "secret", func(wc *WriteCloser) { wc.SetPassword("secret", false) }},
+		{"aes header", "secret", func(wc *WriteCloser) { wc.SetPassword("secret", true) }},
+	}
+	files := testFiles()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := createArchive(t, files, tt.setup)
+			rc := openArchive(t, path, tt.password)
+			defer rc.Close()
+
+			if rc.solid == nil {
+				t.Fatal("the header is not read by solidReader")
+			}
+			for _, f := range rc.index {
+				if f.f != nil && !f.isDir && f.size > 0 && f.folder < 0 {
+					t.Errorf("%s: decoded by sevenzip", f.name)
+				}
+			}
+
+			// random order, so the folders are decoded from the cursor, the cache and the start
+			rc.SetCacheSize(64 << 10)
+			names := make([]string, 0, len(files))
+			for name := range files {
+				names = append(names, name)
+			}
+			rand.New(rand.NewSource(2)).Shuffle(len(names), func(i, j int) { names[i], names[j] = names[j], names[i] })
+			for _, name := range append(names, names...) {
+				data, err := fs.ReadFile(rc, name)
+				if err != nil {
+					t.Fatalf("%s: %v", name, err)
+				}
+				if !bytes.Equal(data, files[name]) {
+					t.Fatalf("%s: wrong data", name)
+				}
+			}
+
+			seen := 0
+			err := rc.Stream(func(name string, info fs.FileInfo, r io.Reader) error {
+				data, err := io.ReadAll(r)
+				if err != nil {
+					return err
+				}
+				if want, ok := files[name]; ok {
+					seen++
+					if !bytes.Equal(data, want) {
+						return fmt.Errorf("%s: wrong data", name)
+					}
+				} else if !strings.HasPrefix(name, "dir") {
+					return fmt.Errorf("%s: not in the archive", name)
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if seen != len(files) {
+				t.Fatalf("streamed %d files, want %d", seen, len(files))
+			}
+		})
+	}
+}
+
+// TestAES checks each encrypted folder has its own IV, and the folders compressed
+// by LZMA behind AES are read by sevenzip.
+func TestAES(t *testing.T) {
+	files := testFiles()
+	path := createArchive(t, files, func(wc *WriteCloser) {
+		wc.SetSolid(false)
+		wc.SetPassword("secret", true)
+	})
+	rc := openArchive(t, path, "secret")
+	defer rc.Close()
+
+	ivs := map[string]bool{}
+	for _, f := range rc.solid.folders {
+		if len(f.coders) != 2 || !bytes.Equal(f.coders[0].id, methodAES) ||
+			!bytes.Equal(f.coders[1].id, methodLZMA) && !bytes.Equal(f.coders[1].id, methodCopy) {
+			t.Fatalf("coders %x, want AES and LZMA or Copy", f.coders)
+		}
+		_, _, iv, err := aesProps(f.coders[0].props)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ivs[string(iv)] {
+			t.Fatalf("IV %x is used twice", iv)
+		}
+		ivs[string(iv)] = true
+	}
+
+	// each folder is opened once, sevenzip can not open an encrypted folder twice
+	r, err := sevenzip.OpenReaderWithPassword(path, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		fr, err := f.Open()
+		if err != nil {
+			t.Fatalf("%s: %v", f.Name, err)
+		}
+		data, err := io.ReadAll(fr)
+		_ = fr.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", f.Name, err)
+		}
+		if !bytes.Equal(data, files[f.Name]) {
+			t.Fatalf("%s: wrong data", f.Name)
+		}
+	}
+}
+
+// TestLazySize checks the files which report the size 0 keep their data.
+func TestLazySize(t *testing.T) {
+	data := []byte("the size of a pipe is not known")
+	path := createEntries(t, []compress.ArchiverFile{
+		&memFile{name: "pipe", data: data, r: bytes.NewReader(data), lazy: true},
+		&memFile{name: "empty", r: bytes.NewReader(nil), lazy: true},
+	}, func(wc *WriteCloser) {})
+	rc := openArchive(t, path, "")
+	defer rc.Close()
+	for name, want := range map[string][]byte{"pipe": data, "empty": {}} {
+		got, err := fs.ReadFile(rc, name)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("%s: got %q, want %q", name, got, want)
+		}
+	}
+}
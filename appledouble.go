@@ -0,0 +1,125 @@
+// Package compress
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package compress
+
+import (
+	"encoding/binary"
+	"io"
+	"strings"
+	"time"
+)
+
+// the entry ids of the AppleDouble file
+const (
+	appleResourceFork = 2
+	appleFileDates    = 8
+	appleFinderInfo   = 9
+)
+
+const appleDoubleMagic = 0x00051607
+
+// AppleDouble is the metadata of an AppleDouble "._" file, stored by macOS in the archives.
+type AppleDouble struct {
+	Entries      map[uint32][]byte // the entries by id
+	FinderInfo   []byte            // the 32 bytes of the Finder information
+	ResourceFork []byte
+	Attributes   map[string][]byte // the extended attributes, as com.apple.quarantine
+
+	// the file dates, zero if they are unknown
+	Created  time.Time
+	Modified time.Time
+	Accessed time.Time
+}
+
+// ParseAppleDouble parses the AppleDouble file read from r.
+func ParseAppleDouble(r io.Reader) (*AppleDouble, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) < 26 || binary.BigEndian.Uint32(b) != appleDoubleMagic {
+		return nil, errAppleDouble
+	}
+	n := int(binary.BigEndian.Uint16(b[24:]))
+	if len(b) < 26+n*12 {
+		return nil, errAppleDouble
+	}
+	ad := &AppleDouble{Entries: map[uint32][]byte{}}
+	for i := 0; i < n; i++ {
+		e := b[26+i*12:]
+		id := binary.BigEndian.Uint32(e)
+		off, size := int64(binary.BigEndian.Uint32(e[4:])), int64(binary.BigEndian.Uint32(e[8:]))
+		if off+size > int64(len(b)) {
+			return nil, errAppleDouble
+		}
+		ad.Entries[id] = b[off : off+size]
+	}
+
+	ad.ResourceFork = ad.Entries[appleResourceFork]
+	if dates := ad.Entries[appleFileDates]; len(dates) >= 16 {
+		ad.Created = appleTime(dates)
+		ad.Modified = appleTime(dates[4:])
+		ad.Accessed = appleTime(dates[12:])
+	}
+	if info := ad.Entries[appleFinderInfo]; len(info) >= 32 {
+		ad.FinderInfo = info[:32]
+		// macOS stores the extended attributes after the Finder information
+		if len(info) > 34 {
+			ad.Attributes = appleAttributes(b, info[34:])
+		}
+	}
+	return ad, nil
+}
+
+// appleTime returns the date of b, in seconds since 2000.
+func appleTime(b []byte) time.Time {
+	sec := int32(binary.BigEndian.Uint32(b))
+	if uint32(sec) == 0x80000000 {
+		return time.Time{}
+	}
+	return time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(sec) * time.Second)
+}
+
+// appleAttributes parses the "ATTR" header, the offsets of the attributes are from
+// the start of the file b.
+func appleAttributes(b, h []byte) map[string][]byte {
+	if len(h) < 36 || string(h[:4]) != "ATTR" {
+		return nil
+	}
+	n := int(binary.BigEndian.Uint16(h[34:]))
+	attrs := make(map[string][]byte, n)
+	p := h[36:]
+	for i := 0; i < n; i++ {
+		if len(p) < 11 {
+			break
+		}
+		off, size := int64(binary.BigEndian.Uint32(p)), int64(binary.BigEndian.Uint32(p[4:]))
+		nameLen := int(p[10])
+		if len(p) < 11+nameLen || off+size > int64(len(b)) {
+			break
+		}
+		attrs[strings.TrimRight(string(p[11:11+nameLen]), "\x00")] = b[off : off+size]
+		// the entries are aligned to 4 bytes
+		next := (11 + nameLen + 3) &^ 3
+		if next > len(p) {
+			break
+		}
+		p = p[next:]
+	}
+	return attrs
+}
@@ -0,0 +1,370 @@
+// Package ar
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package ar
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/text/encoding"
+
+	"github.com/pashifika/compress"
+)
+
+const (
+	magic     = "!<arch>\n"
+	headerLen = 60
+)
+
+var (
+	errNotAr      = errors.New("ar: not an ar archive")
+	errHeader     = errors.New("ar: invalid header")
+	errDebian     = errors.New("ar: the Debian packages are opened by the deb package")
+	errNoRootInfo = errors.New("ar: the root info is not set")
+)
+
+type ReadCloser struct {
+	file    *os.File // nil if opened by NewReader
+	r       io.ReaderAt
+	size    int64
+	entries map[string]*compress.DirIndex
+	dirs    map[string]int
+	files   map[string]int
+	index   []*File
+	seq     []*File // the members in the archive order
+
+	root fs.FileInfo
+}
+
+func (rc *ReadCloser) Name() string { return "ar" }
+
+func (rc *ReadCloser) SetRootInfo(info os.FileInfo) { rc.root = info }
+
+func (rc *ReadCloser) SetCharset(_ []encoding.Encoding, _ bool) {}
+
+// OpenReader will open the ar file (GNU or BSD) specified by name and return a ReadCloser.
+// The Debian packages are not opened, they are left to the deb package.
+func (rc *ReadCloser) OpenReader(path string) (fs.FS, error) {
+	return rc.OpenReaderWithPassword(path, "")
+}
+
+// OpenReaderWithPassword is the same as OpenReader, ar has no password.
+func (rc *ReadCloser) OpenReaderWithPassword(path, _ string) (fs.FS, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	root := rc.root
+	if root == nil {
+		root = info
+	}
+	res, err := newReader(f, info.Size(), root)
+	if err == nil && len(res.seq) > 0 && res.seq[0].name == "debian-binary" {
+		err = errDebian
+	}
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	res.file = f
+	return res, nil
+}
+
+// NewReader returns the ar archive of size bytes read from r, which is not closed by Close.
+func (rc *ReadCloser) NewReader(r io.ReaderAt, size int64) (*ReadCloser, error) {
+	if rc.root == nil {
+		return nil, errNoRootInfo
+	}
+	return newReader(r, size, rc.root)
+}
+
+func newReader(r io.ReaderAt, size int64, root fs.FileInfo) (*ReadCloser, error) {
+	res := &ReadCloser{
+		r:    r,
+		size: size,
+		entries: map[string]*compress.DirIndex{
+			compress.DefaultArchiverRoot: compress.NewDirEntries(),
+		},
+		dirs:  map[string]int{},
+		files: map[string]int{},
+		index: []*File{},
+		root:  root,
+	}
+	if err := res.scan(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// scan reads all the member headers to build the index.
+func (rc *ReadCloser) scan() error {
+	buf := make([]byte, headerLen)
+	if _, err := rc.r.ReadAt(buf[:len(magic)], 0); err != nil || string(buf[:len(magic)]) != magic {
+		return errNotAr
+	}
+
+	var longNames []byte
+	for off := int64(len(magic)); off < rc.size; {
+		if _, err := rc.r.ReadAt(buf, off); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return err
+		}
+		hdr, err := parseHeader(buf)
+		if err != nil {
+			return err
+		}
+		data := off + headerLen
+		off = data + hdr.Size + hdr.Size%2
+		if off > rc.size+1 {
+			return io.ErrUnexpectedEOF
+		}
+
+		name := strings.TrimRight(string(buf[:16]), " ")
+		switch {
+		case name == "//":
+			// the GNU long names table
+			longNames = make([]byte, hdr.Size)
+			if _, err = rc.r.ReadAt(longNames, data); err != nil {
+				return err
+			}
+			continue
+		case strings.HasPrefix(name, "#1/"):
+			// the BSD long name is stored before the data
+			n, err := strconv.ParseInt(name[3:], 10, 64)
+			if err != nil || n < 0 || n > hdr.Size {
+				return errHeader
+			}
+			b := make([]byte, n)
+			if _, err = rc.r.ReadAt(b, data); err != nil {
+				return err
+			}
+			name = strings.TrimRight(string(b), "\x00")
+			data += n
+			hdr.Size -= n
+		case len(name) > 1 && name[0] == '/' && name != "/SYM64/":
+			// the GNU long name is an offset in the table
+			n, err := strconv.Atoi(name[1:])
+			if err != nil || n < 0 || n >= len(longNames) {
+				return errHeader
+			}
+			name = string(longNames[n:])
+			if end := strings.IndexByte(name, '\n'); end >= 0 {
+				name = name[:end]
+			}
+			name = strings.TrimSuffix(name, "/")
+		default:
+			name = strings.TrimSuffix(name, "/")
+		}
+		if name == "" || name == "/SYM64" || strings.HasPrefix(name, "__.SYMDEF") {
+			continue // the symbol tables
+		}
+		hdr.Name = name
+		rc.add(hdr, data)
+	}
+
+	// Set root info
+	rc.dirs[compress.DefaultArchiverRoot] = len(rc.index)
+	rc.index = append(rc.index, &File{
+		name:       compress.DefaultArchiverRoot,
+		mode:       rc.root.Mode() + os.ModeDir,
+		isDir:      true,
+		dirEntries: rc.GetDirEntries,
+	})
+	return nil
+}
+
+// parseHeader parses the fixed fields of the member header, the name is not parsed.
+func parseHeader(buf []byte) (*Header, error) {
+	if string(buf[58:60]) != "`\n" {
+		return nil, errHeader
+	}
+	field := func(b []byte, base int) (int64, error) {
+		s := string(bytes.TrimRight(b, " "))
+		if s == "" {
+			return 0, nil
+		}
+		return strconv.ParseInt(s, base, 64)
+	}
+	var (
+		values [5]int64
+		err    error
+	)
+	for i, f := range []struct{ start, end, base int }{
+		{16, 28, 10}, {28, 34, 10}, {34, 40, 10}, {40, 48, 8}, {48, 58, 10},
+	} {
+		if values[i], err = field(buf[f.start:f.end], f.base); err != nil {
+			return nil, errHeader
+		}
+	}
+	if values[4] < 0 {
+		return nil, errHeader
+	}
+	return &Header{
+		ModTime: time.Unix(values[0], 0),
+		Uid:     int(values[1]),
+		Gid:     int(values[2]),
+		Mode:    values[3],
+		Size:    values[4],
+	}, nil
+}
+
+// add adds the member of hdr to the index, the member of the same name is replaced.
+func (rc *ReadCloser) add(hdr *Header, offset int64) {
+	name := cleanName(hdr.Name)
+	if name == compress.DefaultArchiverRoot {
+		return
+	}
+	entry := &File{
+		header: hdr,
+		name:   name,
+		size:   hdr.Size,
+		mode:   fs.FileMode(hdr.Mode & 0777),
+		data:   io.NewSectionReader(rc.r, offset, hdr.Size),
+	}
+	rc.seq = append(rc.seq, entry)
+	if idx, ok := rc.files[name]; ok {
+		rc.index[idx] = entry
+		return
+	}
+	rc.files[name] = rc.addIndex(entry)
+}
+
+// addIndex adds entry to the index and to its parent directory, the parent
+// directories are created.
+func (rc *ReadCloser) addIndex(entry *File) int {
+	idx := len(rc.index)
+	rc.index = append(rc.index, entry)
+	dir := path.Dir(entry.name)
+	if _, ok := rc.entries[dir]; !ok {
+		rc.entries[dir] = compress.NewDirEntries()
+		if _, ok = rc.dirs[dir]; !ok {
+			rc.dirs[dir] = rc.addIndex(&File{
+				name:       dir,
+				isDir:      true,
+				mode:       fs.ModeDir | 0755,
+				dirEntries: rc.GetDirEntries,
+			})
+		}
+	}
+	rc.entries[dir].Add(idx)
+	return idx
+}
+
+// cleanName returns the fs.FS name of the member name, the leading "/"
+// and the ".." which leads outside the archive are removed.
+func cleanName(name string) string {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if name == "" {
+		return compress.DefaultArchiverRoot
+	}
+	return name
+}
+
+// Open opens the named file in the ar file, using the semantics of fs.FS.Open:
+// paths are always slash separated, with no leading / or ../ elements.
+func (rc *ReadCloser) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	idx, ok := rc.dirs[name]
+	if ok {
+		return rc.getFile(idx)
+	}
+	idx, ok = rc.files[name]
+	if ok {
+		return rc.getFile(idx)
+	}
+	return nil, &fs.PathError{Op: "info", Path: name, Err: fs.ErrNotExist}
+}
+
+func (rc *ReadCloser) GetDirEntries(path string, n int) ([]fs.DirEntry, error) {
+	dIdx, ok := rc.dirs[path]
+	if !ok {
+		return nil, fs.ErrNotExist
+	}
+	dir, err := rc.getFile(dIdx)
+	if err != nil {
+		return nil, err
+	}
+	list, err := rc.entries[path].Page(&dir.dirReadAt, n)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]fs.DirEntry, len(list))
+	for i, fIdx := range list {
+		// the entries are not opened to be listed
+		entries[i] = rc.index[fIdx]
+	}
+	return entries, nil
+}
+
+func (rc *ReadCloser) getFile(idx int) (*File, error) {
+	if idx >= len(rc.index) || idx < 0 {
+		return nil, fs.ErrInvalid
+	}
+	file := rc.index[idx]
+	if !file.isDir {
+		err := file.OpenFile()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return file, nil
+}
+
+// Stream calls fn for each member in the archive order.
+func (rc *ReadCloser) Stream(fn compress.StreamFunc) error {
+	for _, file := range rc.seq {
+		if err := fn(file.name, file, io.NewSectionReader(file.data, 0, file.size)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (rc *ReadCloser) Reset() {
+	*rc = ReadCloser{}
+}
+
+// Close closes the ar file, rendering it unusable for I/O.
+func (rc *ReadCloser) Close() error {
+	if rc == nil || rc.r == nil {
+		return nil
+	}
+	var err error
+	if rc.file != nil {
+		err = rc.file.Close()
+	}
+	rc.Reset()
+	return err
+}
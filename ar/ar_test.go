@@ -0,0 +1,49 @@
+// Package ar
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package ar
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/pashifika/compress/internal/archivetest"
+)
+
+const fixture = "testdata/test.a"
+
+func open(path string) (fs.FS, error) { return (&ReadCloser{}).OpenReader(path) }
+
+// TestReader reads the GNU archive of ar(1), with a long name table.
+func TestReader(t *testing.T) {
+	archivetest.Check(t, open, fixture, map[string]string{
+		"hello.txt":              "hello, world\n",
+		"a_long_member_name.txt": strings.Repeat("lorem ipsum dolor sit amet\n", 100),
+	})
+}
+
+func TestTruncated(t *testing.T) { archivetest.Truncated(t, open, fixture) }
+
+func TestCorrupt(t *testing.T) {
+	archivetest.Corrupt(t, open, fixture, 0, '?')                       // magic
+	archivetest.Corrupt(t, open, fixture, 8+58, 'x')                    // header end
+	archivetest.Corrupt(t, open, fixture, 92+48, []byte("99999999")...) // size past the end
+	archivetest.Corrupt(t, open, fixture, 92+48, []byte("-1        ")...)
+	archivetest.Corrupt(t, open, fixture, 166, []byte("/99")...) // long name past the table
+	archivetest.Mangle(t, open, fixture, 0, 166+60)
+}
@@ -0,0 +1,138 @@
+// Package ar
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package ar
+
+import (
+	"io"
+	"io/fs"
+	"path"
+	"time"
+
+	"github.com/pashifika/compress"
+)
+
+// Header is the Sys() of the ar members, it implements compress.Owner.
+type Header struct {
+	Name    string
+	ModTime time.Time
+	Uid     int
+	Gid     int
+	Mode    int64 // the Unix mode of the member
+	Size    int64
+}
+
+func (h *Header) Owner() (uid, gid int, ok bool) { return h.Uid, h.Gid, true }
+
+type File struct {
+	header *Header // nil for the directories which are not stored
+	name   string
+	isDir  bool
+	size   int64
+	mode   fs.FileMode
+
+	data *io.SectionReader // the member data
+	sr   *io.SectionReader // the opened reader
+
+	dirReadAt  int
+	dirEntries func(path string, n int) ([]fs.DirEntry, error)
+}
+
+func (f *File) Root() string {
+	if f.header == nil {
+		return f.name + "/"
+	}
+	return f.header.Name
+}
+
+func (f *File) IsDir() bool { return f.isDir }
+
+func (f *File) Size() int64 { return f.size }
+
+func (f *File) Write(_ []byte) (n int, err error) {
+	return 0, compress.ErrWriterNotSupport
+}
+
+func (f *File) OpenFile() error {
+	f.sr = io.NewSectionReader(f.data, 0, f.size)
+	return nil
+}
+
+// ------ to fs.FileInfo ------
+
+func (f *File) Mode() fs.FileMode { return f.mode }
+
+func (f *File) ModTime() time.Time {
+	if f.header == nil {
+		return time.Time{}
+	}
+	return f.header.ModTime
+}
+
+func (f *File) Sys() interface{} {
+	if f.header == nil {
+		return nil
+	}
+	return f.header
+}
+
+// ------ to fs.File ------
+
+func (f *File) Stat() (fs.FileInfo, error) { return f, nil }
+
+func (f *File) Read(b []byte) (int, error) {
+	if f.sr == nil {
+		return 0, fs.ErrClosed
+	}
+	return f.sr.Read(b)
+}
+
+// Seek implements io.Seeker, the members are stored as is.
+func (f *File) Seek(offset int64, whence int) (int64, error) {
+	if f.sr == nil {
+		return 0, fs.ErrClosed
+	}
+	return f.sr.Seek(offset, whence)
+}
+
+// ReadAt implements io.ReaderAt, it does not depend on the file being opened.
+func (f *File) ReadAt(b []byte, off int64) (int, error) {
+	if f.data == nil {
+		return 0, fs.ErrInvalid
+	}
+	return f.data.ReadAt(b, off)
+}
+
+func (f *File) Close() error {
+	f.sr = nil
+	return nil
+}
+
+// ------ to fs.DirEntry ------
+
+func (f *File) Name() string { return path.Base(f.name) }
+
+func (f *File) Type() fs.FileMode { return f.mode.Type() }
+
+func (f *File) Info() (fs.FileInfo, error) { return f, nil }
+
+func (f *File) ReadDir(n int) ([]fs.DirEntry, error) {
+	if f.dirEntries != nil {
+		return f.dirEntries(f.name, n)
+	}
+	return nil, fs.ErrNotExist
+}
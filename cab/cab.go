@@ -0,0 +1,481 @@
+// Package cab
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cab
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+
+	"github.com/pashifika/compress"
+)
+
+var errMissingCabinet = errors.New("cab: missing cabinet")
+
+// ReadCloser is the cabinet file, or the set of the cabinets which continue each other.
+type ReadCloser struct {
+	cabs    []*cabinet
+	folders []*folder
+	entries map[string]*compress.DirIndex
+	dirs    map[string]int
+	files   map[string]int
+	index   []*File
+
+	mu     sync.Mutex
+	cursor *folderReader
+
+	charset []encoding.Encoding
+	skipErr bool
+	root    fs.FileInfo
+}
+
+func (rc *ReadCloser) Name() string { return "cab" }
+
+func (rc *ReadCloser) SetRootInfo(info os.FileInfo) { rc.root = info }
+
+// SetCharset set the charsets to decode the file names which are not UTF-8.
+func (rc *ReadCloser) SetCharset(charset []encoding.Encoding, skipErr bool) {
+	rc.charset = charset
+	rc.skipErr = skipErr
+}
+
+// OpenReader will open the cabinet file specified by name and return a ReadCloser,
+// the previous and the next cabinets of the set are opened from the same directory.
+func (rc *ReadCloser) OpenReader(path string) (fs.FS, error) {
+	return rc.OpenReaderWithPassword(path, "")
+}
+
+// OpenReaderWithPassword is the same as OpenReader, cab has no password.
+func (rc *ReadCloser) OpenReaderWithPassword(path, _ string) (fs.FS, error) {
+	cab, err := openCabinet(path)
+	if err != nil {
+		return nil, err
+	}
+	res := &ReadCloser{
+		cabs: []*cabinet{cab},
+		entries: map[string]*compress.DirIndex{
+			compress.DefaultArchiverRoot: compress.NewDirEntries(),
+		},
+		dirs:    map[string]int{},
+		files:   map[string]int{},
+		index:   []*File{},
+		charset: rc.charset,
+		skipErr: rc.skipErr,
+		root:    rc.root,
+	}
+	if res.root == nil {
+		if res.root, err = os.Stat(path); err != nil {
+			_ = res.Close()
+			return nil, err
+		}
+	}
+	if err = res.openSet(); err == nil {
+		err = res.scan()
+	}
+	if err != nil {
+		_ = res.Close()
+		return nil, err
+	}
+	return res, nil
+}
+
+// openSet opens the previous and the next cabinets of the set.
+func (rc *ReadCloser) openSet() error {
+	seen := map[string]bool{rc.cabs[0].path: true}
+	open := func(from *cabinet, name string) (*cabinet, error) {
+		path := from.neighbour(name)
+		if seen[path] || len(rc.cabs) == maxCabinets {
+			return nil, fmt.Errorf("cab: cabinet loop [%d] %s", len(rc.cabs), path)
+		}
+		seen[path] = true
+		cab, err := openCabinet(path)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil, fmt.Errorf("%w [%d] %s\n  error: %v", errMissingCabinet, len(rc.cabs), name, err)
+			}
+			return nil, err
+		}
+		return cab, nil
+	}
+	for first := rc.cabs[0]; first.prev != ""; first = rc.cabs[0] {
+		cab, err := open(first, first.prev)
+		if err != nil {
+			return err
+		}
+		rc.cabs = append([]*cabinet{cab}, rc.cabs...)
+	}
+	for last := rc.cabs[len(rc.cabs)-1]; last.next != ""; last = rc.cabs[len(rc.cabs)-1] {
+		cab, err := open(last, last.next)
+		if err != nil {
+			return err
+		}
+		rc.cabs = append(rc.cabs, cab)
+	}
+	return nil
+}
+
+// scan joins the folders which continue in the next cabinet, and builds the index of the files.
+func (rc *ReadCloser) scan() error {
+	type key struct {
+		folder *folder
+		offset uint32
+		name   string
+	}
+	added := map[key]bool{}
+	var last *folder // the last folder of the previous cabinet
+	for _, cab := range rc.cabs {
+		folders := make([]*folder, len(cab.folders))
+		for i, f := range cab.folders {
+			seg := segment{cab: cab, offset: f.offset, blocks: f.blocks}
+			if i == 0 && last != nil && cab.continued() {
+				last.segments = append(last.segments, seg)
+				folders[i] = last
+				continue
+			}
+			folders[i] = &folder{method: f.method, segments: []segment{seg}}
+			rc.folders = append(rc.folders, folders[i])
+		}
+		if len(folders) > 0 {
+			last = folders[len(folders)-1]
+		}
+
+		for _, file := range cab.files {
+			var f *folder
+			switch {
+			case len(folders) == 0:
+				return fmt.Errorf("cab: file without folder [%d] %s", cab.index, file.name)
+			case file.folder == folderFromPrev || file.folder == folderPrevAndNext:
+				f = folders[0]
+			case file.folder == folderToNext:
+				f = folders[len(folders)-1]
+			default:
+				f = folders[file.folder]
+			}
+			name, err := rc.decodeName(file)
+			if err != nil {
+				return fmt.Errorf("decoding name [%d] %s\n  error: %w", cab.index, file.name, err)
+			}
+			k := key{folder: f, offset: file.offset, name: name}
+			if added[k] {
+				continue // the file is listed by each cabinet where it is stored
+			}
+			added[k] = true
+			rc.add(cab, f, file, name)
+		}
+	}
+
+	// the files must be in their folder, the size is checked before it is read
+	sizes := map[*folder]int64{}
+	for _, file := range rc.index {
+		if file.folder == nil {
+			continue
+		}
+		size, ok := sizes[file.folder]
+		if !ok {
+			var err error
+			if size, err = file.folder.size(); err != nil {
+				return err
+			}
+			sizes[file.folder] = size
+		}
+		if file.offset+file.size > size {
+			return fmt.Errorf("cab: file past the end of the folder %s", file.name)
+		}
+	}
+
+	// Set root info
+	rc.dirs[compress.DefaultArchiverRoot] = len(rc.index)
+	rc.index = append(rc.index, &File{
+		name:       compress.DefaultArchiverRoot,
+		mode:       rc.root.Mode() + os.ModeDir,
+		isDir:      true,
+		dirEntries: rc.GetDirEntries,
+	})
+	return nil
+}
+
+// decodeName returns the name of file, decoded by the charset if it is not UTF-8.
+func (rc *ReadCloser) decodeName(file cfFile) (string, error) {
+	if file.attribs&attribNameIsUTF8 != 0 || rc.charset == nil || utf8.Valid(file.name) && isASCII(file.name) {
+		return string(file.name), nil
+	}
+	var err error
+	for _, enc := range rc.charset {
+		var name string
+		if name, err = enc.NewDecoder().String(string(file.name)); err == nil {
+			return name, nil
+		}
+	}
+	if rc.skipErr {
+		return string(file.name), nil
+	}
+	return "", err
+}
+
+func isASCII(b []byte) bool {
+	for _, c := range b {
+		if c >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// add adds the file to the index, the file of the same name is replaced.
+func (rc *ReadCloser) add(cab *cabinet, f *folder, file cfFile, name string) {
+	name = strings.TrimPrefix(path.Clean("/"+strings.ReplaceAll(name, "\\", "/")), "/")
+	if name == "" {
+		return
+	}
+	mode := fs.FileMode(0644)
+	if file.attribs&attribReadOnly != 0 {
+		mode = 0444
+	}
+	if file.attribs&attribExecute != 0 {
+		mode |= 0111
+	}
+	entry := &File{
+		header: &Header{
+			Name:       name,
+			Size:       file.size,
+			ModTime:    msDosTimeToTime(file.date, file.time),
+			Attributes: file.attribs,
+			Method:     methodName(f.method),
+			Cabinet:    cab.path,
+		},
+		name:     name,
+		size:     int64(file.size),
+		mode:     mode,
+		folder:   f,
+		offset:   int64(file.offset),
+		fileOpen: rc.openEntry,
+	}
+	if idx, ok := rc.files[name]; ok {
+		rc.index[idx] = entry
+		return
+	}
+	rc.files[name] = rc.addIndex(entry)
+}
+
+// addIndex adds entry to the index and to its parent directory, the parent
+// directories are created.
+func (rc *ReadCloser) addIndex(entry *File) int {
+	idx := len(rc.index)
+	rc.index = append(rc.index, entry)
+	dir := path.Dir(entry.name)
+	if _, ok := rc.entries[dir]; !ok {
+		rc.entries[dir] = compress.NewDirEntries()
+		if _, ok = rc.dirs[dir]; !ok {
+			rc.dirs[dir] = rc.addIndex(&File{
+				name:       dir,
+				isDir:      true,
+				mode:       fs.ModeDir | 0755,
+				dirEntries: rc.GetDirEntries,
+			})
+		}
+	}
+	rc.entries[dir].Add(idx)
+	return idx
+}
+
+// msDosTimeToTime converts the MS-DOS date and time to time.Time, in UTC as zip.
+func msDosTimeToTime(dosDate, dosTime uint16) time.Time {
+	return time.Date(
+		int(dosDate>>9+1980),
+		time.Month(dosDate>>5&0xf),
+		int(dosDate&0x1f),
+		int(dosTime>>11),
+		int(dosTime>>5&0x3f),
+		int(dosTime&0x1f*2),
+		0,
+		time.UTC,
+	)
+}
+
+// openEntry returns the reader of f, the reader of the previous file is reused
+// if it is in the same folder before f, instead of decoding the folder from the start.
+func (rc *ReadCloser) openEntry(f *File) (io.ReadCloser, error) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	r := rc.cursor
+	rc.cursor = nil
+	if r == nil || r.f != f.folder || r.n > f.offset {
+		var err error
+		if r, err = newFolderReader(f.folder); err != nil {
+			return nil, err
+		}
+	}
+	if err := r.discard(f.offset - r.n); err != nil {
+		return nil, err
+	}
+	return &entryReader{rc: rc, r: r, remain: f.size}, nil
+}
+
+// entryReader reads a file from the folder reader, and gives back the reader when it is closed.
+type entryReader struct {
+	rc     *ReadCloser
+	r      *folderReader
+	remain int64
+}
+
+func (e *entryReader) Read(p []byte) (int, error) {
+	if e.r == nil {
+		return 0, io.ErrClosedPipe
+	}
+	if e.remain <= 0 {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > e.remain {
+		p = p[:e.remain]
+	}
+	n, err := e.r.Read(p)
+	e.remain -= int64(n)
+	if err == io.EOF && e.remain > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+func (e *entryReader) Close() error {
+	if e.r == nil {
+		return nil
+	}
+	e.rc.mu.Lock()
+	e.rc.cursor = e.r
+	e.rc.mu.Unlock()
+	e.r = nil
+	return nil
+}
+
+// Stream calls fn for each file in the folder order, each folder is decoded once.
+func (rc *ReadCloser) Stream(fn compress.StreamFunc) error {
+	files := map[*folder][]*File{}
+	for _, file := range rc.index {
+		if file.folder != nil {
+			files[file.folder] = append(files[file.folder], file)
+		}
+	}
+	for _, f := range rc.folders {
+		entries := files[f]
+		if len(entries) == 0 {
+			continue
+		}
+		sort.SliceStable(entries, func(i, j int) bool { return entries[i].offset < entries[j].offset })
+		r, err := newFolderReader(f)
+		if err != nil {
+			return err
+		}
+		for _, file := range entries {
+			if file.offset < r.n {
+				// the data is shared with the previous file
+				if r, err = newFolderReader(f); err != nil {
+					return err
+				}
+			}
+			if err = r.discard(file.offset - r.n); err != nil {
+				return err
+			}
+			if err = fn(file.name, file, io.LimitReader(r, file.size)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Open opens the named file in the cabinet, using the semantics of fs.FS.Open:
+// paths are always slash separated, with no leading / or ../ elements.
+func (rc *ReadCloser) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	idx, ok := rc.dirs[name]
+	if ok {
+		return rc.getFile(idx)
+	}
+	idx, ok = rc.files[name]
+	if ok {
+		return rc.getFile(idx)
+	}
+	return nil, &fs.PathError{Op: "info", Path: name, Err: fs.ErrNotExist}
+}
+
+func (rc *ReadCloser) GetDirEntries(path string, n int) ([]fs.DirEntry, error) {
+	dIdx, ok := rc.dirs[path]
+	if !ok {
+		return nil, fs.ErrNotExist
+	}
+	dir, err := rc.getFile(dIdx)
+	if err != nil {
+		return nil, err
+	}
+	list, err := rc.entries[path].Page(&dir.dirReadAt, n)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]fs.DirEntry, len(list))
+	for i, fIdx := range list {
+		// the entries are not opened to be listed
+		entries[i] = rc.index[fIdx]
+	}
+	return entries, nil
+}
+
+func (rc *ReadCloser) getFile(idx int) (*File, error) {
+	if idx >= len(rc.index) || idx < 0 {
+		return nil, fs.ErrInvalid
+	}
+	file := rc.index[idx]
+	if !file.isDir {
+		err := file.OpenFile()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return file, nil
+}
+
+func (rc *ReadCloser) Reset() {
+	*rc = ReadCloser{}
+}
+
+// Close closes the cabinet files, rendering them unusable for I/O.
+func (rc *ReadCloser) Close() error {
+	if rc == nil {
+		return nil
+	}
+	var err error
+	for _, cab := range rc.cabs {
+		if e := cab.file.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	rc.Reset()
+	return err
+}
@@ -0,0 +1,112 @@
+// Package cab
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cab
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/japanese"
+
+	"github.com/pashifika/compress/internal/archivetest"
+)
+
+// text returns the files f1.txt and f2.txt of the MSZIP folder.
+func text() (f1, f2 string) {
+	var b strings.Builder
+	for i := 0; i < 6000; i++ {
+		fmt.Fprintf(&b, "line %d of the folder\n", i)
+	}
+	return b.String()[:50000], b.String()[50000:]
+}
+
+func open(path string) (fs.FS, error) {
+	reader := &ReadCloser{}
+	reader.SetCharset([]encoding.Encoding{japanese.ShiftJIS}, false)
+	return reader.OpenReader(path)
+}
+
+// TestReader reads the cabinet of a MSZIP and a stored folder, and the set of two
+// cabinets where a MSZIP block is split.
+func TestReader(t *testing.T) {
+	bin, err := os.ReadFile("testdata/bin.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f1, f2 := text()
+	archivetest.Check(t, open, "testdata/one.cab", map[string]string{
+		"dir/f1.txt":     f1,
+		"dir/sub/f2.txt": f2,
+		"bin.dat":        string(bin),
+		"日本語.txt":        string(bin[:3]),
+		"ütf8.txt":       string(bin[5:15]),
+	})
+	archivetest.Check(t, open, "testdata/set1.cab", map[string]string{
+		"dir/f1.txt":     f1,
+		"dir/sub/f2.txt": f2,
+		"bin.dat":        string(bin),
+	})
+
+	fsys, err := open("testdata/one.cab")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fsys.(*ReadCloser).Close()
+	for name, mode := range map[string]fs.FileMode{"dir/f1.txt": 0644, "dir/sub/f2.txt": 0444} {
+		if info, err := fs.Stat(fsys, name); err != nil || info.Mode() != mode {
+			t.Errorf("%s: mode %v, %v", name, info.Mode(), err)
+		}
+	}
+}
+
+func TestUnsupported(t *testing.T) {
+	if _, err := archivetest.ReadAll(mustOpen(t, "testdata/lzx.cab")); err == nil {
+		t.Error("the LZX folder is read")
+	}
+}
+
+func mustOpen(t *testing.T, path string) fs.FS {
+	fsys, err := open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fsys
+}
+
+func TestTruncated(t *testing.T) { archivetest.Truncated(t, open, "testdata/one.cab") }
+
+func TestCorrupt(t *testing.T) {
+	const (
+		fixture = "testdata/one.cab"
+		files   = 36 + 2*8 // the CFFILE after the header and the 2 CFFOLDER
+		data    = files + 5*16 + 55
+	)
+	archivetest.Corrupt(t, open, fixture, 0, 'X')                     // magic
+	archivetest.Corrupt(t, open, fixture, 16, 0xff, 0xff)             // files offset
+	archivetest.Corrupt(t, open, fixture, 28, 0xff)                   // files count
+	archivetest.Corrupt(t, open, fixture, files+8, 9)                 // folder index
+	archivetest.Corrupt(t, open, fixture, files+4, 0xff, 0xff, 0xff)  // offset in the folder
+	archivetest.Corrupt(t, open, fixture, 36, 0xff, 0xff, 0xff, 0x7f) // folder offset
+	archivetest.Corrupt(t, open, fixture, data+4, 0xff, 0xff)         // compressed block size
+	archivetest.Corrupt(t, open, fixture, data+8, 'X')                // MSZIP signature
+	archivetest.Mangle(t, open, fixture, 0, data+16)
+}
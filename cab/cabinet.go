@@ -0,0 +1,220 @@
+// Package cab
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cab
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	headerLen = 36
+
+	flagPrevCabinet    = 0x0001
+	flagNextCabinet    = 0x0002
+	flagReservePresent = 0x0004
+
+	folderFromPrev    = 0xfffd
+	folderToNext      = 0xfffe
+	folderPrevAndNext = 0xffff
+	attribReadOnly    = 0x01
+	attribExecute     = 0x40
+	attribNameIsUTF8  = 0x80
+	maxCabinets       = 1024
+	maxStringLen      = 256
+)
+
+var (
+	errNotCab = errors.New("cab: not a cabinet file")
+	errHeader = errors.New("cab: invalid header")
+)
+
+// cabinet is a cabinet file of the set.
+type cabinet struct {
+	path  string
+	file  *os.File
+	setID uint16
+	index uint16
+
+	prev, next  string // the file names of the neighbour cabinets
+	dataReserve int    // the reserved bytes of the CFDATA
+	folders     []cfFolder
+	files       []cfFile
+}
+
+// cfFolder is the CFFOLDER of a cabinet.
+type cfFolder struct {
+	offset int64 // the first CFDATA
+	blocks int
+	method uint16
+}
+
+// cfFile is the CFFILE of a cabinet.
+type cfFile struct {
+	size    uint32
+	offset  uint32 // the uncompressed offset in the folder
+	folder  uint16
+	date    uint16
+	time    uint16
+	attribs uint16
+	name    []byte
+}
+
+// openCabinet opens and parses the cabinet file of path.
+func openCabinet(path string) (*cabinet, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	cab := &cabinet{path: path, file: f}
+	if err = cab.parse(); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return cab, nil
+}
+
+func (cab *cabinet) parse() error {
+	hdr := make([]byte, headerLen)
+	if _, err := cab.file.ReadAt(hdr, 0); err != nil || string(hdr[:4]) != "MSCF" {
+		return errNotCab
+	}
+	le := binary.LittleEndian
+	filesOffset := int64(le.Uint32(hdr[16:]))
+	numFolders := int(le.Uint16(hdr[26:]))
+	numFiles := int(le.Uint16(hdr[28:]))
+	flags := le.Uint16(hdr[30:])
+	cab.setID = le.Uint16(hdr[32:])
+	cab.index = le.Uint16(hdr[34:])
+
+	off := int64(headerLen)
+	folderReserve := 0
+	if flags&flagReservePresent != 0 {
+		b := make([]byte, 4)
+		if _, err := cab.file.ReadAt(b, off); err != nil {
+			return errHeader
+		}
+		folderReserve, cab.dataReserve = int(b[2]), int(b[3])
+		off += 4 + int64(le.Uint16(b))
+	}
+	var err error
+	if flags&flagPrevCabinet != 0 {
+		if cab.prev, off, err = cab.readString(off); err != nil {
+			return err
+		}
+		if _, off, err = cab.readString(off); err != nil { // the disk name
+			return err
+		}
+	}
+	if flags&flagNextCabinet != 0 {
+		if cab.next, off, err = cab.readString(off); err != nil {
+			return err
+		}
+		if _, off, err = cab.readString(off); err != nil {
+			return err
+		}
+	}
+
+	b := make([]byte, 8+folderReserve)
+	for i := 0; i < numFolders; i++ {
+		if _, err = cab.file.ReadAt(b, off); err != nil {
+			return errHeader
+		}
+		cab.folders = append(cab.folders, cfFolder{
+			offset: int64(le.Uint32(b)),
+			blocks: int(le.Uint16(b[4:])),
+			method: le.Uint16(b[6:]),
+		})
+		off += int64(len(b))
+	}
+
+	off = filesOffset
+	b = make([]byte, 16)
+	for i := 0; i < numFiles; i++ {
+		if _, err = cab.file.ReadAt(b, off); err != nil {
+			return errHeader
+		}
+		file := cfFile{
+			size:    le.Uint32(b),
+			offset:  le.Uint32(b[4:]),
+			folder:  le.Uint16(b[8:]),
+			date:    le.Uint16(b[10:]),
+			time:    le.Uint16(b[12:]),
+			attribs: le.Uint16(b[14:]),
+		}
+		var name string
+		if name, off, err = cab.readString(off + 16); err != nil {
+			return err
+		}
+		file.name = []byte(name)
+		if file.folder < folderFromPrev && int(file.folder) >= numFolders {
+			return errHeader
+		}
+		cab.files = append(cab.files, file)
+	}
+	return nil
+}
+
+// readString reads the NUL terminated string at off, it returns the offset after it.
+func (cab *cabinet) readString(off int64) (string, int64, error) {
+	b := make([]byte, maxStringLen)
+	n, err := cab.file.ReadAt(b, off)
+	if err != nil && err != io.EOF {
+		return "", 0, err
+	}
+	end := bytes.IndexByte(b[:n], 0)
+	if end < 0 {
+		return "", 0, errHeader
+	}
+	return string(b[:end]), off + int64(end) + 1, nil
+}
+
+// neighbour returns the path of the cabinet named name in the directory of cab,
+// the name is matched without case if it is not found.
+func (cab *cabinet) neighbour(name string) string {
+	dir := filepath.Dir(cab.path)
+	path := filepath.Join(dir, filepath.Base(strings.ReplaceAll(name, "\\", "/")))
+	if _, err := os.Stat(path); err == nil {
+		return path
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return path
+	}
+	for _, entry := range entries {
+		if strings.EqualFold(entry.Name(), filepath.Base(path)) {
+			return filepath.Join(dir, entry.Name())
+		}
+	}
+	return path
+}
+
+// continued reports whether the first folder of cab is continued from the previous cabinet.
+func (cab *cabinet) continued() bool {
+	for _, file := range cab.files {
+		if file.folder == folderFromPrev || file.folder == folderPrevAndNext {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,240 @@
+// Package cab
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cab
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	methodNone    = 0
+	methodMSZIP   = 1
+	methodQuantum = 2
+	methodLZX     = 3
+
+	maxBlockSize = 32768 + 6144
+	windowSize   = 32768
+)
+
+var (
+	errChecksum = errors.New("cab: checksum error")
+	errBlock    = errors.New("cab: invalid data block")
+)
+
+// folder is the compressed data of the files, which may continue in the next cabinets.
+type folder struct {
+	method   uint16
+	segments []segment
+}
+
+// segment is the part of a folder in a cabinet.
+type segment struct {
+	cab    *cabinet
+	offset int64
+	blocks int
+}
+
+// size returns the uncompressed size of the folder, from the headers of its CFDATA.
+func (f *folder) size() (int64, error) {
+	var n int64
+	for _, seg := range f.segments {
+		hdr := make([]byte, 8+seg.cab.dataReserve)
+		off := seg.offset
+		for i := 0; i < seg.blocks; i++ {
+			if _, err := seg.cab.file.ReadAt(hdr, off); err != nil {
+				return 0, errBlock
+			}
+			n += int64(binary.LittleEndian.Uint16(hdr[6:]))
+			off += int64(len(hdr)) + int64(binary.LittleEndian.Uint16(hdr[4:]))
+		}
+	}
+	return n, nil
+}
+
+// methodName returns the name of the compression method.
+func methodName(method uint16) string {
+	switch method & 0x0f {
+	case methodNone:
+		return "None"
+	case methodMSZIP:
+		return "MSZIP"
+	case methodQuantum:
+		return "Quantum"
+	case methodLZX:
+		return "LZX"
+	}
+	return fmt.Sprintf("unknown (%d)", method&0x0f)
+}
+
+// supported reports whether the method of the folder can be decoded.
+func (f *folder) supported() error {
+	switch f.method & 0x0f {
+	case methodNone, methodMSZIP:
+		return nil
+	}
+	return fmt.Errorf("cab: unsupported compression method %s", methodName(f.method))
+}
+
+// folderReader decodes the data blocks of a folder in sequence.
+type folderReader struct {
+	f      *folder
+	seg    int   // the segment of the next block
+	block  int   // the next block in the segment
+	off    int64 // the offset of the next block
+	buf    []byte
+	pos    int
+	window []byte // the history of MSZIP
+	n      int64  // the uncompressed bytes read
+}
+
+func newFolderReader(f *folder) (*folderReader, error) {
+	if err := f.supported(); err != nil {
+		return nil, err
+	}
+	r := &folderReader{f: f}
+	if len(f.segments) > 0 {
+		r.off = f.segments[0].offset
+	}
+	return r, nil
+}
+
+func (r *folderReader) Read(p []byte) (int, error) {
+	for r.pos == len(r.buf) {
+		if err := r.nextBlock(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.buf[r.pos:])
+	r.pos += n
+	r.n += int64(n)
+	return n, nil
+}
+
+// discard skips n uncompressed bytes.
+func (r *folderReader) discard(n int64) error {
+	_, err := io.CopyN(io.Discard, r, n)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// readData reads the next CFDATA, it returns its data and uncompressed size.
+func (r *folderReader) readData() ([]byte, int, error) {
+	for r.seg < len(r.f.segments) && r.block == r.f.segments[r.seg].blocks {
+		r.seg++
+		r.block = 0
+		if r.seg < len(r.f.segments) {
+			r.off = r.f.segments[r.seg].offset
+		}
+	}
+	if r.seg == len(r.f.segments) {
+		return nil, 0, io.EOF
+	}
+	cab := r.f.segments[r.seg].cab
+	hdr := make([]byte, 8+cab.dataReserve)
+	if _, err := cab.file.ReadAt(hdr, r.off); err != nil {
+		return nil, 0, errBlock
+	}
+	le := binary.LittleEndian
+	sum := le.Uint32(hdr)
+	size := int(le.Uint16(hdr[4:]))
+	uncompressed := int(le.Uint16(hdr[6:]))
+	if size > maxBlockSize {
+		return nil, 0, errBlock
+	}
+	data := make([]byte, size)
+	if _, err := cab.file.ReadAt(data, r.off+int64(len(hdr))); err != nil {
+		return nil, 0, errBlock
+	}
+	if sum != 0 && checksum(hdr[4:8], checksum(data, 0)) != sum {
+		return nil, 0, errChecksum
+	}
+	r.off += int64(len(hdr) + size)
+	r.block++
+	return data, uncompressed, nil
+}
+
+// nextBlock decodes the next block to buf.
+func (r *folderReader) nextBlock() error {
+	data, size, err := r.readData()
+	if err != nil {
+		return err
+	}
+	if size == 0 {
+		// the block is split, its rest is the first block of the next cabinet
+		rest, n, err := r.readData()
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return err
+		}
+		data, size = append(data, rest...), n
+	}
+	if size > windowSize {
+		return errBlock
+	}
+
+	switch r.f.method & 0x0f {
+	case methodNone:
+		if len(data) != size {
+			return errBlock
+		}
+		r.buf = data
+	case methodMSZIP:
+		if len(data) < 2 || data[0] != 'C' || data[1] != 'K' {
+			return errBlock
+		}
+		fr := flate.NewReaderDict(bytes.NewReader(data[2:]), r.window)
+		out := make([]byte, size)
+		if _, err = io.ReadFull(fr, out); err != nil {
+			return errBlock
+		}
+		// the history is kept across the blocks
+		r.window = append(r.window, out...)
+		if len(r.window) > windowSize {
+			r.window = r.window[len(r.window)-windowSize:]
+		}
+		r.buf = out
+	}
+	r.pos = 0
+	return nil
+}
+
+// checksum is the CFDATA checksum of b.
+func checksum(b []byte, sum uint32) uint32 {
+	for ; len(b) >= 4; b = b[4:] {
+		sum ^= binary.LittleEndian.Uint32(b)
+	}
+	var ul uint32
+	switch len(b) {
+	case 3:
+		ul = uint32(b[0])<<16 | uint32(b[1])<<8 | uint32(b[2])
+	case 2:
+		ul = uint32(b[0])<<8 | uint32(b[1])
+	case 1:
+		ul = uint32(b[0])
+	}
+	return sum ^ ul
+}
@@ -0,0 +1,111 @@
+// Package comic
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package comic
+
+import (
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pashifika/compress"
+)
+
+// ImageExt is the extensions of the page images, in lower case.
+var ImageExt = map[string]struct{}{
+	".jpg":  {},
+	".jpeg": {},
+	".png":  {},
+	".gif":  {},
+	".webp": {},
+	".bmp":  {},
+	".avif": {},
+	".jxl":  {},
+	".tif":  {},
+	".tiff": {},
+}
+
+// IsImage reports whether name has an extension of ImageExt.
+func IsImage(name string) bool {
+	_, ok := ImageExt[strings.ToLower(path.Ext(name))]
+	return ok
+}
+
+// Pages returns the names of the page images of fsys, sorted by PathLess.
+// The directories and the files which are not images are skipped, as well as the
+// hidden files and the OS junk entries of compress.IsJunk.
+func Pages(fsys fs.FS) ([]string, error) {
+	var pages []string
+	err := fs.WalkDir(fsys, compress.DefaultArchiverRoot, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if name == compress.DefaultArchiverRoot {
+			return nil
+		}
+		if isJunk(d.Name()) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() && IsImage(name) {
+			pages = append(pages, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(pages, func(i, j int) bool { return PathLess(pages[i], pages[j]) })
+	return pages, nil
+}
+
+// PathLess reports whether the path a sorts before b, the elements of the paths
+// are compared by compress.NaturalLess, so "ch2/page10.jpg" is after "ch2/page2.jpg"
+// and before "ch10/page1.jpg".
+func PathLess(a, b string) bool {
+	for {
+		x, restA, moreA := cut(a)
+		y, restB, moreB := cut(b)
+		if x != y {
+			// the files of a directory before its subdirectories
+			if moreA != moreB {
+				return moreB
+			}
+			return compress.NaturalLess(x, y)
+		}
+		if !moreA || !moreB {
+			return !moreA && moreB
+		}
+		a, b = restA, restB
+	}
+}
+
+// cut splits the first element of the path s, more reports whether it is a directory.
+func cut(s string) (elem, rest string, more bool) {
+	if i := strings.IndexByte(s, '/'); i >= 0 {
+		return s[:i], s[i+1:], true
+	}
+	return s, "", false
+}
+
+// isJunk reports whether name is hidden or is an OS junk entry.
+func isJunk(name string) bool {
+	return strings.HasPrefix(name, ".") || compress.IsJunk(name)
+}
@@ -0,0 +1,167 @@
+// Package comic
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package comic
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+
+	"golang.org/x/text/encoding/htmlindex"
+
+	"github.com/pashifika/compress"
+)
+
+// InfoName is the name of the metadata file, it is looked up without case.
+const InfoName = "ComicInfo.xml"
+
+// ComicInfo is the metadata of ComicInfo.xml, the schema of ComicRack.
+type ComicInfo struct {
+	Title               string
+	Series              string
+	Number              string
+	Count               int
+	Volume              int
+	AlternateSeries     string
+	AlternateNumber     string
+	AlternateCount      int
+	Summary             string
+	Notes               string
+	Year                int
+	Month               int
+	Day                 int
+	Writer              string
+	Penciller           string
+	Inker               string
+	Colorist            string
+	Letterer            string
+	CoverArtist         string
+	Editor              string
+	Translator          string
+	Publisher           string
+	Imprint             string
+	Genre               string
+	Tags                string
+	Web                 string
+	PageCount           int
+	LanguageISO         string
+	Format              string
+	BlackAndWhite       string // "Unknown", "No" or "Yes"
+	Manga               string // "Unknown", "No", "Yes" or "YesAndRightToLeft"
+	Characters          string
+	Teams               string
+	Locations           string
+	MainCharacterOrTeam string
+	ScanInformation     string
+	StoryArc            string
+	StoryArcNumber      string
+	SeriesGroup         string
+	AgeRating           string
+	CommunityRating     float64
+	Review              string
+	GTIN                string
+	Pages               []PageInfo `xml:"Pages>Page"`
+}
+
+// PageInfo is a page of ComicInfo.xml, Image is the index of the page in the page list.
+type PageInfo struct {
+	Image       int    `xml:",attr"`
+	Type        string `xml:",attr"` // as "FrontCover", "Story" or "Deleted"
+	DoublePage  bool   `xml:",attr"`
+	ImageSize   int64  `xml:",attr"`
+	Key         string `xml:",attr"`
+	Bookmark    string `xml:",attr"`
+	ImageWidth  int    `xml:",attr"`
+	ImageHeight int    `xml:",attr"`
+}
+
+// RightToLeft reports whether the pages are read from right to left, as the Japanese manga.
+func (c *ComicInfo) RightToLeft() bool { return c.Manga == "YesAndRightToLeft" }
+
+// Info returns the metadata of the ComicInfo.xml of fsys, the file at the root is
+// used first, or the one of the least depth. The error wraps fs.ErrNotExist if
+// fsys has no ComicInfo.xml.
+func Info(fsys fs.FS) (*ComicInfo, error) {
+	name, err := findInfo(fsys)
+	if err != nil {
+		return nil, err
+	}
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	//goland:noinspection ALL
+	defer f.Close()
+	info, err := ParseInfo(f)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s\n  error: %w", name, err)
+	}
+	return info, nil
+}
+
+// ParseInfo parses the ComicInfo.xml read from r, the encoding declared by the XML
+// header is decoded, as Shift_JIS.
+func ParseInfo(r io.Reader) (*ComicInfo, error) {
+	dec := xml.NewDecoder(r)
+	dec.CharsetReader = func(label string, input io.Reader) (io.Reader, error) {
+		enc, err := htmlindex.Get(label)
+		if err != nil {
+			return nil, err
+		}
+		return enc.NewDecoder().Reader(input), nil
+	}
+	info := &ComicInfo{}
+	if err := dec.Decode(info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// findInfo returns the name of the ComicInfo.xml of the least depth.
+func findInfo(fsys fs.FS) (string, error) {
+	found, depth := "", 0
+	err := fs.WalkDir(fsys, compress.DefaultArchiverRoot, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if name == compress.DefaultArchiverRoot {
+			return nil
+		}
+		n := strings.Count(name, "/")
+		if isJunk(d.Name()) || found != "" && n >= depth {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() && strings.EqualFold(path.Base(name), InfoName) {
+			found, depth = name, n
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if found == "" {
+		return "", &fs.PathError{Op: "open", Path: InfoName, Err: fs.ErrNotExist}
+	}
+	return found, nil
+}
@@ -0,0 +1,376 @@
+// Package cpio
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cpio
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"golang.org/x/text/encoding"
+
+	"github.com/pashifika/compress"
+	"github.com/pashifika/compress/internal/codec"
+)
+
+var (
+	errNotCpio    = errors.New("cpio: not a cpio archive")
+	errNoRootInfo = errors.New("cpio: the root info is not set")
+)
+
+type ReadCloser struct {
+	file    *os.File // nil if opened by NewReader
+	r       io.ReaderAt
+	size    int64
+	format  codec.Format
+	entries map[string]*compress.DirIndex
+	dirs    map[string]int
+	files   map[string]int
+	index   []*File
+	seq     []*File // the entries in the archive order
+
+	mu     sync.Mutex
+	cursor *cursor
+
+	root fs.FileInfo
+}
+
+func (rc *ReadCloser) Name() string { return "cpio" }
+
+func (rc *ReadCloser) SetRootInfo(info os.FileInfo) { rc.root = info }
+
+func (rc *ReadCloser) SetCharset(_ []encoding.Encoding, _ bool) {}
+
+// OpenReader will open the cpio file (newc or odc) specified by name and return a ReadCloser,
+// the cpio may be compressed by gzip, bzip2, xz or zstd.
+func (rc *ReadCloser) OpenReader(path string) (fs.FS, error) {
+	return rc.OpenReaderWithPassword(path, "")
+}
+
+// OpenReaderWithPassword is the same as OpenReader, cpio has no password.
+func (rc *ReadCloser) OpenReaderWithPassword(path, _ string) (fs.FS, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	root := rc.root
+	if root == nil {
+		root = info
+	}
+	res, err := newReader(f, info.Size(), root)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	res.file = f
+	return res, nil
+}
+
+// NewReader returns the cpio archive of size bytes read from r, which is not closed by Close.
+// The cpio may be compressed by gzip, bzip2, xz or zstd, such as the payload of a rpm.
+func (rc *ReadCloser) NewReader(r io.ReaderAt, size int64) (*ReadCloser, error) {
+	if rc.root == nil {
+		return nil, errNoRootInfo
+	}
+	return newReader(r, size, rc.root)
+}
+
+func newReader(r io.ReaderAt, size int64, root fs.FileInfo) (*ReadCloser, error) {
+	res := &ReadCloser{
+		r:    r,
+		size: size,
+		entries: map[string]*compress.DirIndex{
+			compress.DefaultArchiverRoot: compress.NewDirEntries(),
+		},
+		dirs:  map[string]int{},
+		files: map[string]int{},
+		index: []*File{},
+		root:  root,
+	}
+	if err := res.scan(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// scan reads all the cpio headers to build the index.
+func (rc *ReadCloser) scan() error {
+	br := bufio.NewReader(io.NewSectionReader(rc.r, 0, rc.size))
+	rc.format = codec.Detect(br)
+	cr := &reader{r: io.NewSectionReader(rc.r, 0, rc.size)}
+	if rc.format != codec.None {
+		zr, err := codec.NewReader(rc.format, br)
+		if err != nil {
+			return err
+		}
+		//goland:noinspection ALL
+		defer zr.Close()
+		cr = &reader{r: zr}
+	}
+
+	links := map[string][]*File{}
+	for seq := 0; ; seq++ {
+		hdr, err := cr.Next()
+		if err == io.EOF {
+			if cr.n == 0 {
+				return errNotCpio // empty file
+			}
+			break
+		}
+		if err != nil {
+			if seq == 0 {
+				return errNotCpio
+			}
+			return err
+		}
+		entry := rc.add(seq, hdr)
+		rc.seq = append(rc.seq, entry)
+		if entry == nil {
+			continue
+		}
+		if rc.format == codec.None {
+			entry.offset = hdr.offset
+		}
+		if entry.mode.IsRegular() && hdr.Nlink > 1 {
+			key := fmt.Sprintf("%d:%d", hdr.Dev, hdr.Ino)
+			links[key] = append(links[key], entry)
+		}
+	}
+
+	// the data of the hard links is stored once, by the last link in newc
+	for _, group := range links {
+		var target *File
+		for _, entry := range group {
+			if entry.size > 0 {
+				target = entry
+			}
+		}
+		if target == nil {
+			continue
+		}
+		for _, entry := range group {
+			if entry != target && entry.size == 0 {
+				entry.hard = target
+				entry.size = target.size
+			}
+		}
+	}
+
+	// Set root info
+	rc.dirs[compress.DefaultArchiverRoot] = len(rc.index)
+	rc.index = append(rc.index, &File{
+		name:       compress.DefaultArchiverRoot,
+		mode:       rc.root.Mode() + os.ModeDir,
+		isDir:      true,
+		dirEntries: rc.GetDirEntries,
+	})
+	return nil
+}
+
+// add adds the entry of hdr to the index, the entry of the same name is replaced.
+// It returns nil for the root.
+func (rc *ReadCloser) add(seq int, hdr *Header) *File {
+	name := cleanName(hdr.Name)
+	if name == compress.DefaultArchiverRoot {
+		return nil
+	}
+	entry := &File{
+		header:   hdr,
+		name:     name,
+		mode:     hdr.FileMode(),
+		seq:      seq,
+		offset:   -1,
+		fileOpen: rc.openEntry,
+	}
+	switch {
+	case entry.mode.IsDir():
+		entry.isDir = true
+		entry.dirEntries = rc.GetDirEntries
+		if idx, ok := rc.dirs[name]; ok {
+			rc.index[idx] = entry
+			return entry
+		}
+		rc.dirs[name] = rc.addIndex(entry)
+		return entry
+	case entry.mode&fs.ModeSymlink != 0:
+		entry.link = hdr.Linkname
+		entry.size = int64(len(hdr.Linkname))
+	default:
+		entry.size = hdr.Size
+	}
+	if idx, ok := rc.files[name]; ok {
+		rc.index[idx] = entry
+		return entry
+	}
+	rc.files[name] = rc.addIndex(entry)
+	return entry
+}
+
+// addIndex adds entry to the index and to its parent directory, the parent
+// directories which are not stored are created.
+func (rc *ReadCloser) addIndex(entry *File) int {
+	idx := len(rc.index)
+	rc.index = append(rc.index, entry)
+	dir := path.Dir(entry.name)
+	if _, ok := rc.entries[dir]; !ok {
+		rc.entries[dir] = compress.NewDirEntries()
+		if _, ok = rc.dirs[dir]; !ok {
+			rc.dirs[dir] = rc.addIndex(&File{
+				name:       dir,
+				isDir:      true,
+				mode:       fs.ModeDir | 0755,
+				offset:     -1,
+				dirEntries: rc.GetDirEntries,
+			})
+		}
+	}
+	rc.entries[dir].Add(idx)
+	return idx
+}
+
+// cleanName returns the fs.FS name of the cpio entry name, the leading "/",
+// "./" and the ".." which leads outside the archive are removed.
+func cleanName(name string) string {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if name == "" {
+		return compress.DefaultArchiverRoot
+	}
+	return name
+}
+
+// Open opens the named file in the cpio file, using the semantics of fs.FS.Open:
+// paths are always slash separated, with no leading / or ../ elements.
+func (rc *ReadCloser) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	idx, ok := rc.dirs[name]
+	if ok {
+		return rc.getFile(idx)
+	}
+	idx, ok = rc.files[name]
+	if ok {
+		return rc.getFile(idx)
+	}
+	return nil, &fs.PathError{Op: "info", Path: name, Err: fs.ErrNotExist}
+}
+
+// Lstat returns the fs.FileInfo of the named file, the symbolic link is not followed.
+func (rc *ReadCloser) Lstat(name string) (fs.FileInfo, error) {
+	file, err := rc.lookup("lstat", name)
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// ReadLink returns the destination of the named symbolic link.
+func (rc *ReadCloser) ReadLink(name string) (string, error) {
+	file, err := rc.lookup("readlink", name)
+	if err != nil {
+		return "", err
+	}
+	if file.mode&os.ModeSymlink == 0 {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+	return file.link, nil
+}
+
+// lookup returns the named file without opening it.
+func (rc *ReadCloser) lookup(op, name string) (*File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	if idx, ok := rc.dirs[name]; ok {
+		return rc.index[idx], nil
+	}
+	if idx, ok := rc.files[name]; ok {
+		return rc.index[idx], nil
+	}
+	return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+}
+
+func (rc *ReadCloser) GetDirEntries(path string, n int) ([]fs.DirEntry, error) {
+	dIdx, ok := rc.dirs[path]
+	if !ok {
+		return nil, fs.ErrNotExist
+	}
+	dir, err := rc.getFile(dIdx)
+	if err != nil {
+		return nil, err
+	}
+	list, err := rc.entries[path].Page(&dir.dirReadAt, n)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]fs.DirEntry, len(list))
+	for i, fIdx := range list {
+		// the entries are not opened to be listed
+		entries[i] = rc.index[fIdx]
+	}
+	return entries, nil
+}
+
+func (rc *ReadCloser) getFile(idx int) (*File, error) {
+	if idx >= len(rc.index) || idx < 0 {
+		return nil, fs.ErrInvalid
+	}
+	file := rc.index[idx]
+	if !file.isDir {
+		err := file.OpenFile()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return file, nil
+}
+
+func (rc *ReadCloser) Reset() {
+	*rc = ReadCloser{}
+}
+
+// Close closes the cpio file, rendering it unusable for I/O.
+func (rc *ReadCloser) Close() error {
+	if rc == nil || rc.r == nil {
+		return nil
+	}
+	rc.mu.Lock()
+	if rc.cursor != nil {
+		_ = rc.cursor.close()
+		rc.cursor = nil
+	}
+	rc.mu.Unlock()
+	var err error
+	if rc.file != nil {
+		err = rc.file.Close()
+	}
+	rc.Reset()
+	return err
+}
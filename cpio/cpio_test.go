@@ -0,0 +1,64 @@
+// Package cpio
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cpio
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/pashifika/compress/internal/archivetest"
+)
+
+var want = map[string]string{
+	"hello.txt":     "hello, world\n",
+	"dir/lorem.txt": strings.Repeat("lorem ipsum dolor sit amet\n", 100),
+}
+
+func open(path string) (fs.FS, error) { return (&ReadCloser{}).OpenReader(path) }
+
+// TestReader reads the newc and odc archives of a directory, files and a symbolic link.
+func TestReader(t *testing.T) {
+	for _, fixture := range []string{"testdata/test.cpio", "testdata/odc.cpio"} {
+		archivetest.Check(t, open, fixture, want)
+		fsys, err := open(fixture)
+		if err != nil {
+			t.Fatal(err)
+		}
+		target, err := fsys.(*ReadCloser).ReadLink("link")
+		if err != nil || target != "hello.txt" {
+			t.Errorf("%s: link target %q, %v", fixture, target, err)
+		}
+		_ = fsys.(*ReadCloser).Close()
+	}
+}
+
+func TestTruncated(t *testing.T) {
+	archivetest.Truncated(t, open, "testdata/test.cpio")
+	archivetest.Truncated(t, open, "testdata/odc.cpio")
+}
+
+func TestCorrupt(t *testing.T) {
+	const fixture = "testdata/test.cpio"
+	archivetest.Corrupt(t, open, fixture, 0, '9')                        // magic
+	archivetest.Corrupt(t, open, fixture, 6, 'x')                        // not hex
+	archivetest.Corrupt(t, open, fixture, 6+11*8, []byte("FFFFFFFF")...) // name size
+	archivetest.Corrupt(t, open, fixture, 6+11*8, []byte("00000000")...)
+	archivetest.Mangle(t, open, fixture, 0, 112)
+	archivetest.Mangle(t, open, "testdata/odc.cpio", 0, 80)
+}
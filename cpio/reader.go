@@ -0,0 +1,262 @@
+// Package cpio
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cpio
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"strconv"
+	"time"
+)
+
+const (
+	magicNewc    = "070701"
+	magicNewcCRC = "070702"
+	magicOdc     = "070707"
+	trailer      = "TRAILER!!!"
+
+	newcHeaderLen = 110
+	odcHeaderLen  = 76
+	maxNameSize   = 1 << 16
+)
+
+var errHeader = errors.New("cpio: invalid header")
+
+// Header is the Sys() of the cpio entries, it implements compress.Owner.
+type Header struct {
+	Name     string
+	Mode     int64 // the Unix mode of the entry
+	Uid      int
+	Gid      int
+	Nlink    int
+	Ino      int64
+	Dev      int64 // the device of the entry, the major and minor of newc are joined
+	Rdev     int64
+	ModTime  time.Time
+	Size     int64
+	Linkname string // the symbolic link target
+	Check    uint32 // the checksum of the data of "070702"
+
+	offset int64 // the offset of the data in the stream
+}
+
+func (h *Header) Owner() (uid, gid int, ok bool) { return h.Uid, h.Gid, true }
+
+// FileMode returns the fs.FileMode of the Unix mode.
+func (h *Header) FileMode() fs.FileMode {
+	mode := fs.FileMode(h.Mode & 0777)
+	switch h.Mode & 0170000 {
+	case 0040000:
+		mode |= fs.ModeDir
+	case 0120000:
+		mode |= fs.ModeSymlink
+	case 0140000:
+		mode |= fs.ModeSocket
+	case 0060000:
+		mode |= fs.ModeDevice
+	case 0020000:
+		mode |= fs.ModeDevice | fs.ModeCharDevice
+	case 0010000:
+		mode |= fs.ModeNamedPipe
+	}
+	if h.Mode&04000 != 0 {
+		mode |= fs.ModeSetuid
+	}
+	if h.Mode&02000 != 0 {
+		mode |= fs.ModeSetgid
+	}
+	if h.Mode&01000 != 0 {
+		mode |= fs.ModeSticky
+	}
+	return mode
+}
+
+// reader reads the headers and the data of a cpio stream.
+type reader struct {
+	r      io.Reader
+	n      int64 // the bytes read from r
+	remain int64 // the unread data of the current entry
+	pad    int64 // the padding after the data
+}
+
+// Next advances to the next entry, it returns io.EOF at the trailer.
+func (r *reader) Next() (*Header, error) {
+	if err := r.skip(r.remain + r.pad); err != nil {
+		return nil, err
+	}
+	r.remain, r.pad = 0, 0
+
+	buf := make([]byte, newcHeaderLen)
+	if err := r.readFull(buf[:6]); err != nil {
+		return nil, err
+	}
+	var (
+		hdr      *Header
+		nameSize int64
+		err      error
+	)
+	switch string(buf[:6]) {
+	case magicNewc, magicNewcCRC:
+		if err = r.readFull(buf[6:newcHeaderLen]); err != nil {
+			return nil, err
+		}
+		hdr, nameSize, err = parseNewc(buf)
+	case magicOdc:
+		if err = r.readFull(buf[6:odcHeaderLen]); err != nil {
+			return nil, err
+		}
+		hdr, nameSize, err = parseOdc(buf[:odcHeaderLen])
+	default:
+		return nil, errHeader
+	}
+	if err != nil {
+		return nil, err
+	}
+	if nameSize < 1 || nameSize > maxNameSize || hdr.Size < 0 {
+		return nil, errHeader
+	}
+	name := make([]byte, nameSize)
+	if err = r.readFull(name); err != nil {
+		return nil, err
+	}
+	hdr.Name = string(name[:nameSize-1])
+	if hdr.Name == trailer {
+		return nil, io.EOF
+	}
+	if buf[5] != '7' {
+		// newc pads the header and the data to 4 bytes
+		if err = r.skip(pad4(newcHeaderLen + nameSize)); err != nil {
+			return nil, err
+		}
+		r.pad = pad4(hdr.Size)
+	}
+	hdr.offset = r.n
+	r.remain = hdr.Size
+	if hdr.Mode&0170000 == 0120000 {
+		target, err := ioutil.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		hdr.Linkname = string(target)
+	}
+	return hdr, nil
+}
+
+// Read reads the data of the current entry.
+func (r *reader) Read(p []byte) (int, error) {
+	if r.remain <= 0 {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > r.remain {
+		p = p[:r.remain]
+	}
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	r.remain -= int64(n)
+	if err == io.EOF && r.remain > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+func (r *reader) readFull(p []byte) error {
+	n, err := io.ReadFull(r.r, p)
+	r.n += int64(n)
+	if err == io.EOF && r.n > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// skip discards n bytes, r is seeked if it is an io.Seeker.
+func (r *reader) skip(n int64) error {
+	if n <= 0 {
+		return nil
+	}
+	if s, ok := r.r.(io.Seeker); ok {
+		if _, err := s.Seek(n, io.SeekCurrent); err != nil {
+			return err
+		}
+		r.n += n
+		return nil
+	}
+	m, err := io.CopyN(ioutil.Discard, r.r, n)
+	r.n += m
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func pad4(n int64) int64 { return (4 - n%4) % 4 }
+
+// parseNewc parses the "070701" and "070702" header, the fields are 8 hex digits.
+func parseNewc(buf []byte) (*Header, int64, error) {
+	var (
+		fields [13]int64
+		err    error
+	)
+	for i := range fields {
+		if fields[i], err = strconv.ParseInt(string(buf[6+i*8:14+i*8]), 16, 64); err != nil {
+			return nil, 0, errHeader
+		}
+	}
+	hdr := &Header{
+		Ino:     fields[0],
+		Mode:    fields[1],
+		Uid:     int(fields[2]),
+		Gid:     int(fields[3]),
+		Nlink:   int(fields[4]),
+		ModTime: time.Unix(fields[5], 0),
+		Size:    fields[6],
+		Dev:     fields[7]<<32 | fields[8],
+		Rdev:    fields[9]<<32 | fields[10],
+		Check:   uint32(fields[12]),
+	}
+	return hdr, fields[11], nil
+}
+
+// parseOdc parses the "070707" header, the fields are octal.
+func parseOdc(buf []byte) (*Header, int64, error) {
+	widths := [...]int{6, 6, 6, 6, 6, 6, 6, 11, 6, 11}
+	var (
+		fields [len(widths)]int64
+		err    error
+	)
+	off := 6
+	for i, w := range widths {
+		if fields[i], err = strconv.ParseInt(string(buf[off:off+w]), 8, 64); err != nil {
+			return nil, 0, errHeader
+		}
+		off += w
+	}
+	hdr := &Header{
+		Dev:     fields[0],
+		Ino:     fields[1],
+		Mode:    fields[2],
+		Uid:     int(fields[3]),
+		Gid:     int(fields[4]),
+		Nlink:   int(fields[5]),
+		Rdev:    fields[6],
+		ModTime: time.Unix(fields[7], 0),
+		Size:    fields[9],
+	}
+	return hdr, fields[8], nil
+}
@@ -0,0 +1,250 @@
+// Package deb
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package deb
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+
+	"golang.org/x/text/encoding"
+
+	"github.com/pashifika/compress"
+	"github.com/pashifika/compress/ar"
+	"github.com/pashifika/compress/tar"
+)
+
+var errNotDeb = errors.New("deb: not a Debian package")
+
+// ReadCloser is the Debian package, which is an ar archive of the members
+// "debian-binary", "control.tar.*" and "data.tar.*".
+type ReadCloser struct {
+	file   *os.File
+	ar     *ar.ReadCloser
+	mount  bool
+	mounts map[string]*tar.ReadCloser // the tarballs by their directory
+	dir    *File
+	dirs   []*File // the directories of the tarballs
+
+	root fs.FileInfo
+}
+
+func (rc *ReadCloser) Name() string { return "deb" }
+
+func (rc *ReadCloser) SetRootInfo(info os.FileInfo) { rc.root = info }
+
+func (rc *ReadCloser) SetCharset(_ []encoding.Encoding, _ bool) {}
+
+// SetMount set to mount the control and data tarballs as the directories "control"
+// and "data" next to them, the package members are only listed by default.
+func (rc *ReadCloser) SetMount(mount bool) { rc.mount = mount }
+
+func (rc *ReadCloser) OpenReader(path string) (fs.FS, error) {
+	return rc.OpenReaderWithPassword(path, "")
+}
+
+// OpenReaderWithPassword is the same as OpenReader, deb has no password.
+func (rc *ReadCloser) OpenReaderWithPassword(path, _ string) (fs.FS, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	res, err := rc.open(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return res, nil
+}
+
+func (rc *ReadCloser) open(f *os.File) (*ReadCloser, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	res := &ReadCloser{file: f, mount: rc.mount, mounts: map[string]*tar.ReadCloser{}, root: rc.root}
+	if res.root == nil {
+		res.root = info
+	}
+	reader := &ar.ReadCloser{}
+	reader.SetRootInfo(res.root)
+	if res.ar, err = reader.NewReader(f, info.Size()); err != nil {
+		return nil, err
+	}
+	entries, err := res.ar.GetDirEntries(compress.DefaultArchiverRoot, -1)
+	if err != nil || len(entries) == 0 || entries[0].Name() != "debian-binary" {
+		return nil, errNotDeb
+	}
+	res.dir = &File{
+		name:       compress.DefaultArchiverRoot,
+		mode:       res.root.Mode() + os.ModeDir,
+		modTime:    res.root.ModTime(),
+		dirEntries: res.GetDirEntries,
+	}
+	if !res.mount {
+		return res, nil
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		dir := mountName(name)
+		if dir == "" {
+			continue
+		}
+		file, err := res.ar.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		member := file.(*ar.File)
+		reader := &tar.ReadCloser{}
+		reader.SetRootInfo(member)
+		t, err := reader.NewReader(member, member.Size())
+		if err != nil {
+			return nil, &fs.PathError{Op: "mount", Path: name, Err: err}
+		}
+		res.mounts[dir] = t
+		res.dirs = append(res.dirs, &File{
+			name:       dir,
+			mode:       member.Mode() | fs.ModeDir | 0111,
+			modTime:    member.ModTime(),
+			dirEntries: res.GetDirEntries,
+		})
+	}
+	return res, nil
+}
+
+// mountName returns the directory of the control and data tarballs, or "".
+func mountName(name string) string {
+	for _, dir := range []string{"control", "data"} {
+		if name == dir+".tar" || strings.HasPrefix(name, dir+".tar.") {
+			return dir
+		}
+	}
+	return ""
+}
+
+// split returns the tarball of name and the name in it, t is nil if name is not mounted.
+func (rc *ReadCloser) split(name string) (t *tar.ReadCloser, dir, sub string) {
+	dir, sub = name, compress.DefaultArchiverRoot
+	if i := strings.IndexByte(name, '/'); i >= 0 {
+		dir, sub = name[:i], name[i+1:]
+	}
+	return rc.mounts[dir], dir, sub
+}
+
+// Open opens the named file in the package, using the semantics of fs.FS.Open:
+// paths are always slash separated, with no leading / or ../ elements.
+func (rc *ReadCloser) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if name == compress.DefaultArchiverRoot {
+		return rc.dir, nil
+	}
+	t, dir, sub := rc.split(name)
+	if t == nil {
+		return rc.ar.Open(name)
+	}
+	if sub == compress.DefaultArchiverRoot {
+		for _, d := range rc.dirs {
+			if d.name == dir {
+				return d, nil
+			}
+		}
+	}
+	f, err := t.Open(sub)
+	return f, pathError(err, name)
+}
+
+// Lstat returns the fs.FileInfo of the named file, the symbolic link is not followed.
+func (rc *ReadCloser) Lstat(name string) (fs.FileInfo, error) {
+	t, _, sub := rc.split(name)
+	if t == nil || sub == compress.DefaultArchiverRoot {
+		return fs.Stat(rc, name)
+	}
+	info, err := t.Lstat(sub)
+	return info, pathError(err, name)
+}
+
+// ReadLink returns the destination of the named symbolic link in the mounted tarballs.
+func (rc *ReadCloser) ReadLink(name string) (string, error) {
+	t, _, sub := rc.split(name)
+	if t == nil || sub == compress.DefaultArchiverRoot {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+	target, err := t.ReadLink(sub)
+	return target, pathError(err, name)
+}
+
+// pathError set the path of err to the name in the package.
+func pathError(err error, name string) error {
+	if pe, ok := err.(*fs.PathError); ok {
+		return &fs.PathError{Op: pe.Op, Path: name, Err: pe.Err}
+	}
+	return err
+}
+
+func (rc *ReadCloser) GetDirEntries(path string, n int) ([]fs.DirEntry, error) {
+	if path != compress.DefaultArchiverRoot {
+		t, _, sub := rc.split(path)
+		if t == nil {
+			return rc.ar.GetDirEntries(path, n)
+		}
+		return t.GetDirEntries(sub, n)
+	}
+
+	entries, err := rc.ar.GetDirEntries(path, -1)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range rc.dirs {
+		entries = append(entries, d)
+	}
+	if n <= 0 {
+		return entries, nil
+	}
+	if rc.dir.dirReadAt >= len(entries) {
+		return nil, io.EOF
+	}
+	entries = entries[rc.dir.dirReadAt:]
+	if len(entries) > n {
+		entries = entries[:n]
+	}
+	rc.dir.dirReadAt += len(entries)
+	return entries, nil
+}
+
+func (rc *ReadCloser) Reset() {
+	*rc = ReadCloser{mount: rc.mount}
+}
+
+// Close closes the package, rendering it unusable for I/O.
+func (rc *ReadCloser) Close() error {
+	if rc == nil || rc.file == nil {
+		return nil
+	}
+	for _, t := range rc.mounts {
+		_ = t.Close()
+	}
+	_ = rc.ar.Close()
+	err := rc.file.Close()
+	rc.Reset()
+	return err
+}
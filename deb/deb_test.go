@@ -0,0 +1,89 @@
+// Package deb
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package deb
+
+import (
+	"io"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/pashifika/compress/internal/archivetest"
+)
+
+const fixture = "testdata/test.deb"
+
+// dataFS is the data directory of the mounted package.
+type dataFS struct {
+	fs.FS
+	io.Closer
+}
+
+func open(path string) (fs.FS, error) {
+	reader := &ReadCloser{}
+	reader.SetMount(true)
+	fsys, err := reader.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	sub, err := fs.Sub(fsys, "data")
+	if err != nil {
+		_ = fsys.(*ReadCloser).Close()
+		return nil, err
+	}
+	return dataFS{FS: sub, Closer: fsys.(*ReadCloser)}, nil
+}
+
+// TestReader reads the package of dpkg-deb, with the gzip compressed tarballs.
+func TestReader(t *testing.T) {
+	archivetest.Check(t, open, fixture, map[string]string{
+		"usr/share/test/hello.txt": "hello, world\n",
+		"usr/share/test/lorem.txt": strings.Repeat("lorem ipsum dolor sit amet\n", 100),
+	})
+
+	reader := &ReadCloser{}
+	reader.SetMount(true)
+	fsys, err := reader.OpenReader(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fsys.(*ReadCloser).Close()
+	var names []string
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if got, want := strings.Join(names, " "), "control control.tar.gz data data.tar.gz debian-binary"; got != want {
+		t.Errorf("members %q, want %q", got, want)
+	}
+	control, err := fs.ReadFile(fsys, "control/control")
+	if err != nil || !strings.HasPrefix(string(control), "Package: test\n") {
+		t.Errorf("control %q, %v", control, err)
+	}
+}
+
+func TestTruncated(t *testing.T) { archivetest.Truncated(t, open, fixture) }
+
+func TestCorrupt(t *testing.T) {
+	archivetest.Corrupt(t, open, fixture, 0, '?')                      // ar magic
+	archivetest.Corrupt(t, open, fixture, 8, []byte("debian-text")...) // first member
+	archivetest.Mangle(t, open, fixture, 0, 8+60+4+60)
+}
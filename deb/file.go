@@ -0,0 +1,78 @@
+// Package deb
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package deb
+
+import (
+	"errors"
+	"io/fs"
+	"time"
+
+	"github.com/pashifika/compress"
+)
+
+var errIsDir = errors.New("is a directory")
+
+// File is the directory where a tarball is mounted, or the root of the package.
+type File struct {
+	name    string
+	mode    fs.FileMode
+	modTime time.Time
+
+	dirReadAt  int
+	dirEntries func(path string, n int) ([]fs.DirEntry, error)
+}
+
+func (f *File) Root() string { return f.name + "/" }
+
+func (f *File) IsDir() bool { return true }
+
+func (f *File) Size() int64 { return 0 }
+
+func (f *File) Write(_ []byte) (n int, err error) {
+	return 0, compress.ErrWriterNotSupport
+}
+
+// ------ to fs.FileInfo ------
+
+func (f *File) Mode() fs.FileMode { return f.mode }
+
+func (f *File) ModTime() time.Time { return f.modTime }
+
+func (f *File) Sys() interface{} { return nil }
+
+// ------ to fs.File ------
+
+func (f *File) Stat() (fs.FileInfo, error) { return f, nil }
+
+func (f *File) Read(_ []byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: f.name, Err: errIsDir}
+}
+
+func (f *File) Close() error { return nil }
+
+// ------ to fs.DirEntry ------
+
+func (f *File) Name() string { return f.name }
+
+func (f *File) Type() fs.FileMode { return f.mode.Type() }
+
+func (f *File) Info() (fs.FileInfo, error) { return f, nil }
+
+func (f *File) ReadDir(n int) ([]fs.DirEntry, error) {
+	return f.dirEntries(f.name, n)
+}
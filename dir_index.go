@@ -17,6 +17,8 @@
  */
 package compress
 
+import "io"
+
 // smallBufferSize is an initial allocation minimal capacity.
 const smallBufferSize = 64
 const maxInt = int(^uint(0) >> 1)
@@ -36,6 +38,35 @@ func (d *DirIndex) Add(idx int) {
 
 func (d *DirIndex) Entries() []int { return d.slice }
 
+// Page returns the indexes listed by ReadDir(n) of a directory, at is the read
+// position kept by the directory:
+//
+// * n <= 0 returns all the indexes and resets at.
+// * n > 0 returns the next n indexes from at, and io.EOF at the end which resets at.
+//
+// The DirIndex of an empty directory may be nil.
+func (d *DirIndex) Page(at *int, n int) ([]int, error) {
+	var list []int
+	if d != nil {
+		list = d.Entries()
+	}
+	if n <= 0 {
+		*at = 0
+		return list, nil
+	}
+	start := *at
+	if start >= len(list) {
+		*at = 0
+		return nil, io.EOF
+	}
+	end := start + n
+	if end > len(list) {
+		end = len(list)
+	}
+	*at = end
+	return list[start:end], nil
+}
+
 // Len returns the number of slice of the unread portion of the DirIndex;
 func (d *DirIndex) Len() int { return len(d.slice) - int(d.off) }
 
@@ -0,0 +1,158 @@
+// Package compress
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package compress
+
+import (
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// PrefixReporter is implemented by the archive fs.FS opened with FileSystem.Flatten,
+// StrippedPrefix returns the name of the top-level directory presented as the root,
+// or "" if the archive was not flattened.
+type PrefixReporter interface {
+	StrippedPrefix() string
+}
+
+// StrippedPrefix returns the top-level directory of fsys which is presented as its root,
+// or "" if fsys was not flattened.
+func StrippedPrefix(fsys fs.FS) string {
+	if p, ok := fsys.(PrefixReporter); ok {
+		return p.StrippedPrefix()
+	}
+	return ""
+}
+
+// flatten returns the directory of fsys as its root, if the root of fsys has only
+// this directory.
+func flatten(fsys fs.FS) fs.FS {
+	entries, err := fs.ReadDir(fsys, DefaultArchiverRoot)
+	if err != nil || len(entries) != 1 || !entries[0].IsDir() {
+		return &flatFS{FS: fsys}
+	}
+	return &flatFS{FS: fsys, prefix: entries[0].Name()}
+}
+
+// flatFS is the directory prefix of the archive as the root, the same as fs.Sub.
+type flatFS struct {
+	fs.FS
+	prefix string // "" if the archive is not flattened
+}
+
+func (f *flatFS) StrippedPrefix() string { return f.prefix }
+
+// full returns the name of the archive of name.
+func (f *flatFS) full(op, name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	if f.prefix == "" {
+		return name, nil
+	}
+	return path.Join(f.prefix, name), nil
+}
+
+// shorten returns the name of f of the archive name, ok is false if it is outside f.
+func (f *flatFS) shorten(name string) (string, bool) {
+	switch {
+	case f.prefix == "":
+		return name, true
+	case name == f.prefix:
+		return DefaultArchiverRoot, true
+	case strings.HasPrefix(name, f.prefix+"/"):
+		return name[len(f.prefix)+1:], true
+	}
+	return "", false
+}
+
+// fixErr reports the fs.PathError by the name of f.
+func (f *flatFS) fixErr(err error) error {
+	if e, ok := err.(*fs.PathError); ok {
+		if short, ok := f.shorten(e.Path); ok {
+			e.Path = short
+		}
+	}
+	return err
+}
+
+func (f *flatFS) Open(name string) (fs.File, error) {
+	full, err := f.full("open", name)
+	if err != nil {
+		return nil, err
+	}
+	file, err := f.FS.Open(full)
+	return file, f.fixErr(err)
+}
+
+func (f *flatFS) Stat(name string) (fs.FileInfo, error) {
+	full, err := f.full("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := fs.Stat(f.FS, full)
+	return info, f.fixErr(err)
+}
+
+func (f *flatFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	full, err := f.full("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := fs.ReadDir(f.FS, full)
+	return entries, f.fixErr(err)
+}
+
+func (f *flatFS) ReadLink(name string) (string, error) {
+	full, err := f.full("readlink", name)
+	if err != nil {
+		return "", err
+	}
+	target, err := ReadLink(f.FS, full)
+	return target, f.fixErr(err)
+}
+
+func (f *flatFS) Lstat(name string) (fs.FileInfo, error) {
+	full, err := f.full("lstat", name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := Lstat(f.FS, full)
+	return info, f.fixErr(err)
+}
+
+func (f *flatFS) ArchiveComment() string { return ArchiveComment(f.FS) }
+
+func (f *flatFS) Collisions() [][]string { return Collisions(f.FS) }
+
+// Stream calls fn for the entries inside the prefix, in the archive order if the
+// archive implements Streamer.
+func (f *flatFS) Stream(fn StreamFunc) error {
+	s, ok := f.FS.(Streamer)
+	if !ok {
+		return walkStream(f, fn)
+	}
+	return s.Stream(func(name string, info fs.FileInfo, r io.Reader) error {
+		short, ok := f.shorten(name)
+		if !ok || short == DefaultArchiverRoot {
+			return nil
+		}
+		return fn(short, info, r)
+	})
+}
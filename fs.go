@@ -18,9 +18,11 @@
 package compress
 
 import (
+	"errors"
 	"io"
 	"io/fs"
 	"os"
+	"time"
 
 	"golang.org/x/text/encoding"
 )
@@ -28,6 +30,27 @@ import (
 type FileSystem struct {
 	Charset     []encoding.Encoding
 	SkipCharErr bool
+	// FollowSymlinks resolve the symbolic links inside the archive when opening a name,
+	// only work with the archive fs.FS implementing ReadLinkFS.
+	FollowSymlinks bool
+	// DirOrder is the order of the directory entries read by the opened archive, its
+	// ReadDir files, fs.ReadDir and fs.WalkDir. DirLess replaces it if it is not nil.
+	DirOrder DirOrder
+	DirLess  func(a, b fs.DirEntry) bool
+	// Lookup matches the opened names without case or Unicode normalization,
+	// only work with the Decoder implementing LookupDecoder.
+	Lookup LookupOption
+	// Junk hides the OS junk entries of the opened archive, which are reported by
+	// JunkFilter if it is not nil, or by IsJunk. The entries inside them are hidden too.
+	Junk       JunkMode
+	JunkFilter func(name string) bool
+	// Flatten presents the contents of the top-level directory as the root, if it is the
+	// only entry of the root (after hiding the junk), StrippedPrefix reports its name.
+	Flatten bool
+	// Location is the time zone of the archive times stored without one, such as the
+	// MS-DOS times of zip, UTC is used if it is nil. It only works with the Decoder
+	// implementing LocationDecoder.
+	Location *time.Location
 
 	close func() error
 }
@@ -35,7 +58,8 @@ type FileSystem struct {
 // Open cannot work with OpenWithPwd at the same time
 func (fs *FileSystem) Open(path string) (fs.FS, error) { return fs.OpenWithPwd(path, "") }
 
-// OpenWithPwd cannot work with Open at the same time
+// OpenWithPwd cannot work with Open at the same time,
+// it returns the error matching ErrMissingVolume instead of trying the next Decoder.
 func (fs *FileSystem) OpenWithPwd(path, pwd string) (fs.FS, error) {
 	info, err := os.Stat(path)
 	if err != nil {
@@ -46,16 +70,49 @@ func (fs *FileSystem) OpenWithPwd(path, pwd string) (fs.FS, error) {
 	}
 
 	// Decoder supported archiver file
-	for _, decoder := range decoders {
+	for _, decoder := range decodersOf(path) {
 		decoder.SetRootInfo(info)
 		if fs.Charset != nil {
 			decoder.SetCharset(fs.Charset, fs.SkipCharErr)
 		}
+		if l, ok := decoder.(LookupDecoder); ok {
+			l.SetLookup(fs.Lookup)
+		}
+		if l, ok := decoder.(LocationDecoder); ok {
+			l.SetLocation(fs.Location)
+		}
 		rc, err := decoder.OpenReaderWithPassword(path, pwd)
 		if err != nil {
+			if errors.Is(err, ErrMissingVolume) {
+				return nil, err
+			}
 			continue
 		}
+		// the opened archive owns the file, not the registered Decoder
 		fs.close = decoder.Close
+		if c, ok := rc.(io.Closer); ok {
+			fs.close = c.Close
+		}
+		if fs.DirOrder != DirOrderDefault || fs.DirLess != nil {
+			less := fs.DirLess
+			if less == nil {
+				less = dirLess(fs.DirOrder)
+			}
+			rc = &orderFS{FS: rc, less: less}
+		}
+		if fs.Junk != JunkShow {
+			junk := fs.JunkFilter
+			if junk == nil {
+				junk = IsJunk
+			}
+			rc = &junkFS{FS: rc, junk: junk, merge: fs.Junk == JunkMerge}
+		}
+		if fs.Flatten {
+			rc = flatten(rc)
+		}
+		if l, ok := rc.(ReadLinkFS); ok && fs.FollowSymlinks {
+			return &linkFS{l}, nil
+		}
 		return rc, nil
 	}
 	return nil, ErrUnknownArchiver
@@ -63,7 +120,7 @@ func (fs *FileSystem) OpenWithPwd(path, pwd string) (fs.FS, error) {
 
 // CreateArchiverFile save archiver entries to disk
 func (fs *FileSystem) CreateArchiverFile(encode string, w io.Writer, entries []ArchiverFile) error {
-	encoder, ok := encoders[encode]
+	encoder, ok := encoderOf(encode)
 	if !ok {
 		return ErrUnknownEncoder
 	}
@@ -4,7 +4,10 @@ go 1.17
 
 require (
 	github.com/bodgit/sevenzip v1.1.1
+	github.com/dsnet/compress v0.0.1
+	github.com/klauspost/compress v1.15.15
 	github.com/nwaples/rardecode/v2 v2.0.0-beta.2
+	github.com/ulikunitz/xz v0.5.10
 	golang.org/x/text v0.3.7
 )
 
@@ -14,6 +17,5 @@ require (
 	github.com/connesc/cipherio v0.2.1 // indirect
 	github.com/hashicorp/errwrap v1.0.0 // indirect
 	github.com/hashicorp/go-multierror v1.1.1 // indirect
-	github.com/ulikunitz/xz v0.5.10 // indirect
 	go4.org v0.0.0-20200411211856-f5505b9728dd // indirect
 )
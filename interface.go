@@ -22,6 +22,7 @@ import (
 	"io"
 	"io/fs"
 	"os"
+	"time"
 
 	"golang.org/x/text/encoding"
 )
@@ -84,12 +85,70 @@ type Encoder interface {
 	Reset()
 }
 
+// ArchiveCommenter is implemented by the archive fs.FS which support the archive comment.
+type ArchiveCommenter interface {
+	ArchiveComment() string
+}
+
+// EntryCommenter is implemented by the fs.FileInfo of the archive entries which support
+// entry comment, and by the ArchiverFile to set the entry comment when creating an archive.
+type EntryCommenter interface {
+	EntryComment() string
+}
+
+// Owner is implemented by the Sys() of the archive entries which store the Unix owner,
+// it is also used to set the owner when creating an archive.
+type Owner interface {
+	Owner() (uid, gid int, ok bool)
+}
+
+// Times is implemented by the Sys() of the archive entries which store the access and
+// creation times, they are zero if the entry does not have them.
+type Times interface {
+	Times() (accessed, created time.Time)
+}
+
+// LocationDecoder is implemented by the Decoder which read the times stored without
+// time zone, they are read in loc (UTC if it is nil).
+type LocationDecoder interface {
+	SetLocation(loc *time.Location)
+}
+
+// SymlinkFile is implemented by the ArchiverFile of symbolic links, the target is
+// stored as the link body when creating an archive. An ArchiverFile with
+// fs.ModeSymlink not implementing it must read the target as its content.
+type SymlinkFile interface {
+	LinkTarget() (string, error)
+}
+
+// ArchiveComment returns the archive comment of fsys opened by FileSystem,
+// or "" if the archive has none.
+func ArchiveComment(fsys fs.FS) string {
+	if c, ok := fsys.(ArchiveCommenter); ok {
+		return c.ArchiveComment()
+	}
+	return ""
+}
+
+// EntryComment returns the comment of the archive entry info, or "" if it has none.
+func EntryComment(info fs.FileInfo) string {
+	if c, ok := info.(EntryCommenter); ok {
+		return c.EntryComment()
+	}
+	return ""
+}
+
 // errors
 
 var (
 	ErrUnknownEncoder   = errors.New("unknown encoder")
 	ErrUnknownArchiver  = errors.New("unknown archiver file")
 	ErrWriterNotSupport = errors.New("writer is not supported")
+	ErrSymlinkLoop      = errors.New("too many levels of symbolic links")
+	ErrSymlinkEscape    = errors.New("symbolic link leads outside the archive")
+	// ErrMissingVolume is matched by the errors of the Decoder which recognize the
+	// archive but not find one of its volumes, FileSystem returns them as is.
+	ErrMissingVolume = errors.New("missing volume of the archive")
 
 	// ErrDirIndexTooLarge is passed to panic if memory cannot be allocated to store data in a buffer.
 	ErrDirIndexTooLarge = errors.New("DirIndex.slice: too large")
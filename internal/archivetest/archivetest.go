@@ -0,0 +1,191 @@
+// Package archivetest
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package archivetest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"testing"
+)
+
+// OpenFunc opens the archive at path.
+type OpenFunc func(path string) (fs.FS, error)
+
+// ReadAll returns the contents of the regular files of fsys by name, fsys is closed
+// if it is an io.Closer. The data is read to the end instead of by the size of the
+// header, which is not trusted by the corrupt archives.
+func ReadAll(fsys fs.FS) (map[string][]byte, error) {
+	if c, ok := fsys.(io.Closer); ok {
+		//goland:noinspection ALL
+		defer c.Close()
+	}
+	files := map[string][]byte{}
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		f, err := fsys.Open(name)
+		if err != nil {
+			return err
+		}
+		//goland:noinspection ALL
+		defer f.Close()
+		data, err := io.ReadAll(f)
+		if err != nil {
+			return err
+		}
+		files[name] = data
+		return nil
+	})
+	return files, err
+}
+
+// panicError is the panic of the decoder.
+type panicError struct {
+	value interface{}
+	stack []byte
+}
+
+func (e *panicError) Error() string { return fmt.Sprintf("panic: %v\n%s", e.value, e.stack) }
+
+func isPanic(err error) bool {
+	var pe *panicError
+	return errors.As(err, &pe)
+}
+
+// read opens the archive at path and reads its files, a panic is returned as an error.
+func read(open OpenFunc, path string) (files map[string][]byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &panicError{value: r, stack: debug.Stack()}
+		}
+	}()
+	fsys, err := open(path)
+	if err != nil {
+		return nil, err
+	}
+	return ReadAll(fsys)
+}
+
+// Check opens the fixture and compares its files with want.
+func Check(t *testing.T, open OpenFunc, fixture string, want map[string]string) {
+	t.Helper()
+	files, err := read(open, fixture)
+	if err != nil {
+		t.Fatalf("%s: %v", fixture, err)
+	}
+	for name, data := range want {
+		got, ok := files[name]
+		if !ok {
+			t.Errorf("%s: %s is missing", fixture, name)
+		} else if string(got) != data {
+			t.Errorf("%s: %s has wrong data", fixture, name)
+		}
+	}
+	for name := range files {
+		if _, ok := want[name]; !ok {
+			t.Errorf("%s: %s is not expected", fixture, name)
+		}
+	}
+}
+
+// Truncated opens the prefixes of the fixture, each one must fail or have only
+// files of the fixture with their whole data.
+func Truncated(t *testing.T, open OpenFunc, fixture string) {
+	t.Helper()
+	data, err := os.ReadFile(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := read(open, fixture)
+	if err != nil {
+		t.Fatalf("%s: %v", fixture, err)
+	}
+	name := filepath.Join(t.TempDir(), "archive")
+	step := len(data)/512 + 1
+	for n := 0; n < len(data); n += step {
+		write(t, name, data[:n])
+		files, err := read(open, name)
+		if err == nil && n == 0 {
+			t.Errorf("%s: the empty file is opened", fixture)
+		}
+		if err != nil {
+			if isPanic(err) {
+				t.Fatalf("%s truncated at %d: %v", fixture, n, err)
+			}
+			continue
+		}
+		for name, got := range files {
+			if w, ok := want[name]; !ok || string(got) != string(w) {
+				t.Fatalf("%s truncated at %d: %s is read without error and wrong data", fixture, n, name)
+			}
+		}
+	}
+}
+
+// Corrupt opens the fixture with the bytes at off replaced by b, it must fail.
+func Corrupt(t *testing.T, open OpenFunc, fixture string, off int, b ...byte) {
+	t.Helper()
+	data, err := os.ReadFile(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	copy(data[off:], b)
+	name := filepath.Join(t.TempDir(), "archive")
+	write(t, name, data)
+	if _, err = read(open, name); err == nil {
+		t.Errorf("%s corrupted at %d: no error", fixture, off)
+	} else if isPanic(err) {
+		t.Errorf("%s corrupted at %d: %v", fixture, off, err)
+	}
+}
+
+// Mangle opens the fixture with each byte of [from, to) replaced by some values,
+// the archive may be read or fail but it must not panic.
+func Mangle(t *testing.T, open OpenFunc, fixture string, from, to int) {
+	t.Helper()
+	data, err := os.ReadFile(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if to > len(data) {
+		to = len(data)
+	}
+	name := filepath.Join(t.TempDir(), "archive")
+	for off := from; off < to; off++ {
+		for _, v := range []byte{0x00, 0xff, 0x7f, 0x80, data[off] ^ 0x01} {
+			mangled := append([]byte(nil), data...)
+			mangled[off] = v
+			write(t, name, mangled)
+			if _, err := read(open, name); isPanic(err) {
+				t.Fatalf("%s with 0x%02x at %d: %v", fixture, v, off, err)
+			}
+		}
+	}
+}
+
+func write(t *testing.T, name string, data []byte) {
+	if err := os.WriteFile(name, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,127 @@
+// Package codec
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package codec
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/ioutil"
+
+	dsbzip2 "github.com/dsnet/compress/bzip2"
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// Format is the compression of a single stream.
+type Format int
+
+const (
+	None Format = iota
+	Gzip
+	Bzip2
+	Xz
+	Zstd
+)
+
+var ErrUnknownFormat = errors.New("codec: unknown compression format")
+
+var magics = []struct {
+	format Format
+	magic  []byte
+}{
+	{Gzip, []byte{0x1f, 0x8b}},
+	{Bzip2, []byte("BZh")},
+	{Xz, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}},
+	{Zstd, []byte{0x28, 0xb5, 0x2f, 0xfd}},
+}
+
+// Ext returns the file extension of the format, "" for None.
+func (f Format) Ext() string {
+	switch f {
+	case Gzip:
+		return ".gz"
+	case Bzip2:
+		return ".bz2"
+	case Xz:
+		return ".xz"
+	case Zstd:
+		return ".zst"
+	}
+	return ""
+}
+
+// Detect returns the format of the stream by its magic number, br is not advanced.
+func Detect(br *bufio.Reader) Format {
+	head, _ := br.Peek(6)
+	for _, m := range magics {
+		if bytes.HasPrefix(head, m.magic) {
+			return m.format
+		}
+	}
+	return None
+}
+
+// NewReader returns the decompressor of r, None returns r itself.
+func NewReader(f Format, r io.Reader) (io.ReadCloser, error) {
+	switch f {
+	case None:
+		return ioutil.NopCloser(r), nil
+	case Gzip:
+		return gzip.NewReader(r)
+	case Bzip2:
+		return ioutil.NopCloser(bzip2.NewReader(r)), nil
+	case Xz:
+		xr, err := xz.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return ioutil.NopCloser(xr), nil
+	case Zstd:
+		zr, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return zr.IOReadCloser(), nil
+	}
+	return nil, ErrUnknownFormat
+}
+
+// NewWriter returns the compressor to w, Close flush it but does not close w.
+func NewWriter(f Format, w io.Writer) (io.WriteCloser, error) {
+	switch f {
+	case None:
+		return nopWriteCloser{w}, nil
+	case Gzip:
+		return gzip.NewWriter(w), nil
+	case Bzip2:
+		return dsbzip2.NewWriter(w, nil)
+	case Xz:
+		return xz.NewWriter(w)
+	case Zstd:
+		return zstd.NewWriter(w)
+	}
+	return nil, ErrUnknownFormat
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
@@ -48,6 +48,14 @@ func decodeTxt(b []byte) (str string, err error) {
 	return
 }
 
+// decodeComment decodes the comment by the charset, it is kept as is if it cannot be decoded.
+func decodeComment(s string) string {
+	if str, err := decodeTxt([]byte(s)); err == nil {
+		return str
+	}
+	return s
+}
+
 func bytesToString(b []byte) string {
 	if len(b) == 0 {
 		return ""
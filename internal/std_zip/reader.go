@@ -6,26 +6,42 @@ package std_zip
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"hash"
 	"hash/crc32"
 	"io"
 	"io/fs"
 	"os"
 	"path"
+	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/pashifika/compress"
+	"github.com/pashifika/compress/internal/zran"
 )
 
 var (
 	ErrFormat    = errors.New("zip: not a valid zip file")
 	ErrAlgorithm = errors.New("zip: unsupported compression algorithm")
 	ErrChecksum  = errors.New("zip: checksum error")
+	ErrVolume    = error(&volumeError{})
 )
 
+// volumeError is the missing volume of a split archive.
+type volumeError struct{}
+
+func (e *volumeError) Error() string { return "zip: missing volume of split archive" }
+
+// Is reports the error matches compress.ErrMissingVolume.
+func (e *volumeError) Is(target error) bool { return target == compress.ErrMissingVolume }
+
 // A Reader serves content from a ZIP archive.
 type Reader struct {
 	r             io.ReaderAt
@@ -33,15 +49,33 @@ type Reader struct {
 	Comment       string
 	decompressors map[uint16]Decompressor
 
+	// diskStart is the start offset of each volume in r
+	// when reading a split archive, it is nil otherwise.
+	diskStart []int64
+
+	// baseOffset is the size of the data prepended to the archive
+	// (e.g. a self-extracting stub), all offsets are relative to it.
+	baseOffset int64
+
+	// dirOffset is the offset of the central directory in r.
+	dirOffset int64
+
 	// fileList is a list of files sorted by ename,
 	// for use by the Open method.
 	fileListOnce sync.Once
 	fileList     []fileListEntry
+
+	// match is the lookup options, names is the index of the names matched by them.
+	match compress.LookupOption
+	names *compress.NameIndex
+
+	// loc is the location of the MS-DOS times, UTC if it is nil.
+	loc *time.Location
 }
 
 // A ReadCloser is a Reader that must be closed when no longer needed.
 type ReadCloser struct {
-	f *os.File
+	f []fs.File
 	Reader
 }
 
@@ -53,28 +87,91 @@ type File struct {
 	zip          *Reader
 	zipr         io.ReaderAt
 	headerOffset int64
-	zip64        bool  // zip64 extended information extra field presence
-	descErr      error // error reading the data descriptor during init
+	disk         uint32 // disk number where the file starts
+	zip64        bool   // zip64 extended information extra field presence
+	descErr      error  // error reading the data descriptor during init
+	localTimes   bool   // the access or creation time is only in the local header
+	timesOnce    sync.Once
+
+	// index is the access points of the Deflated file, built by the first seek.
+	indexOnce sync.Once
+	index     *zran.Index
+	indexErr  error
 }
 
 // OpenReader will open the Zip file specified by name and return a ReadCloser.
+// If name is a volume of a split archive (name.z01, name.z02, ..., name.zip),
+// all the volumes are opened.
 func OpenReader(name string) (*ReadCloser, error) {
-	f, err := os.Open(name)
-	if err != nil {
-		return nil, err
+	return OpenReaderIn(name, nil)
+}
+
+// OpenReaderIn is the same as OpenReader, the MS-DOS times of the entries without
+// extended timestamps are read in loc, UTC is used if loc is nil.
+func OpenReaderIn(name string, loc *time.Location) (*ReadCloser, error) {
+	return OpenReaderFS(nil, name, loc)
+}
+
+// OpenReaderFS is the same as OpenReaderIn, the archive and the volumes of a split
+// archive are opened from fsys, nil is the OS file system. The files which do not
+// implement io.ReaderAt are read into memory.
+func OpenReaderFS(fsys fs.FS, name string, loc *time.Location) (*ReadCloser, error) {
+	volumes := splitVolumes(fsys, name)
+	if volumes == nil {
+		volumes = []string{name}
+	}
+	r := new(ReadCloser)
+	r.loc = loc
+	readers := make([]io.ReaderAt, 0, len(volumes))
+	sizes := make([]int64, 0, len(volumes))
+	for _, volume := range volumes {
+		f, err := openFile(fsys, volume)
+		if err != nil {
+			r.Close()
+			return nil, err
+		}
+		r.f = append(r.f, f)
+		fi, err := f.Stat()
+		if err != nil {
+			r.Close()
+			return nil, err
+		}
+		ra, ok := f.(io.ReaderAt)
+		if !ok {
+			data, err := io.ReadAll(f)
+			if err != nil {
+				r.Close()
+				return nil, err
+			}
+			ra = bytes.NewReader(data)
+		}
+		readers = append(readers, ra)
+		sizes = append(sizes, fi.Size())
+	}
+	var err error
+	if len(r.f) == 1 {
+		err = r.init(readers[0], sizes[0])
+	} else {
+		err = r.initMulti(readers, sizes)
 	}
-	fi, err := f.Stat()
 	if err != nil {
-		f.Close()
+		r.Close()
 		return nil, err
 	}
-	r := new(ReadCloser)
-	if err := r.init(f, fi.Size()); err != nil {
-		f.Close()
+	return r, nil
+}
+
+// NewMultiReader returns a new Reader reading from the volumes of a split
+// archive, in disk order, each assumed to have the given size in bytes.
+func NewMultiReader(volumes []io.ReaderAt, sizes []int64) (*Reader, error) {
+	if len(volumes) != len(sizes) {
+		return nil, errors.New("zip: volumes and sizes mismatch")
+	}
+	zr := new(Reader)
+	if err := zr.initMulti(volumes, sizes); err != nil {
 		return nil, err
 	}
-	r.f = f
-	return r, nil
+	return zr, nil
 }
 
 // NewReader returns a new Reader reading from r, which is assumed to
@@ -90,11 +187,39 @@ func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
 	return zr, nil
 }
 
+func (z *Reader) initMulti(volumes []io.ReaderAt, sizes []int64) error {
+	mr := &multiReaderAt{r: volumes, start: make([]int64, len(volumes)+1)}
+	for i, size := range sizes {
+		if size < 0 {
+			return errors.New("zip: size cannot be negative")
+		}
+		mr.start[i+1] = mr.start[i] + size
+	}
+	z.diskStart = mr.start[:len(volumes)]
+	return z.init(mr, mr.start[len(volumes)])
+}
+
 func (z *Reader) init(r io.ReaderAt, size int64) error {
-	end, err := readDirectoryEnd(r, size)
+	end, baseOffset, err := readDirectoryEnd(r, size, z.diskStart)
 	if err != nil {
 		return err
 	}
+	z.baseOffset = baseOffset
+	end.directoryOffset += uint64(baseOffset)
+	z.dirOffset = int64(end.directoryOffset)
+	if z.diskStart != nil {
+		if int(end.diskNbr) != len(z.diskStart)-1 {
+			return ErrVolume
+		}
+		o, err := z.diskOffset(end.dirDiskNbr, int64(end.directoryOffset))
+		if err != nil {
+			return err
+		}
+		end.directoryOffset = uint64(o)
+	} else if end.diskNbr > 0 {
+		// the last volume of a split archive opened alone
+		return ErrVolume
+	}
 	z.r = r
 	// Since the number of directory records is not validated, it is not
 	// safe to preallocate z.File without first checking that the specified
@@ -105,7 +230,7 @@ func (z *Reader) init(r io.ReaderAt, size int64) error {
 	if end.directorySize < uint64(size) && (uint64(size)-end.directorySize)/30 >= end.directoryRecords {
 		z.File = make([]*File, 0, end.directoryRecords)
 	}
-	z.Comment = end.comment
+	z.Comment = decodeComment(end.comment)
 	rs := io.NewSectionReader(r, 0, size)
 	if _, err = rs.Seek(int64(end.directoryOffset), io.SeekStart); err != nil {
 		return err
@@ -125,7 +250,14 @@ func (z *Reader) init(r io.ReaderAt, size int64) error {
 		if err != nil {
 			return err
 		}
+		if f.headerOffset, err = z.diskOffset(f.disk, f.headerOffset); err != nil {
+			return err
+		}
+		f.headerOffset += baseOffset
 		f.readDataDescriptor()
+		if f.localTimes {
+			f.readTimes = func() { f.timesOnce.Do(f.readLocalTimes) }
+		}
 		z.File = append(z.File, f)
 	}
 	if uint16(len(z.File)) != uint16(end.directoryRecords) { // only compare 16 bits here
@@ -136,6 +268,35 @@ func (z *Reader) init(r io.ReaderAt, size int64) error {
 	return nil
 }
 
+// ArchiveComment returns the archive comment (see compress.ArchiveCommenter).
+func (z *Reader) ArchiveComment() string { return z.Comment }
+
+// BaseOffset returns the size of the data found before the archive,
+// such as the stub of a self-extracting archive.
+func (z *Reader) BaseOffset() int64 { return z.baseOffset }
+
+// ReaderAt returns the reader of the whole archive.
+func (z *Reader) ReaderAt() io.ReaderAt { return z.r }
+
+// IsSplit reports whether the archive is split in several volumes.
+func (z *Reader) IsSplit() bool { return z.diskStart != nil }
+
+// DirectoryOffset returns the offset of the central directory in the archive,
+// it is where new files are written when appending to the archive.
+func (z *Reader) DirectoryOffset() int64 { return z.dirOffset }
+
+// diskOffset converts the offset relative to disk to the offset in z.r.
+// The disk is ignored when z is not a split archive.
+func (z *Reader) diskOffset(disk uint32, offset int64) (int64, error) {
+	if z.diskStart == nil {
+		return offset, nil
+	}
+	if int(disk) >= len(z.diskStart) {
+		return 0, ErrVolume
+	}
+	return z.diskStart[disk] + offset, nil
+}
+
 // RegisterDecompressor registers or overrides a custom decompressor for a
 // specific method ID. If a decompressor for a given method is not found,
 // Reader will default to looking up the decompressor at the package level.
@@ -156,9 +317,19 @@ func (z *Reader) decompressor(method uint16) Decompressor {
 
 // Close closes the Zip file, rendering it unusable for I/O.
 func (rc *ReadCloser) Close() error {
-	return rc.f.Close()
+	var err error
+	for _, f := range rc.f {
+		if e := f.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
 }
 
+// HeaderOffset returns the offset of the file's local header,
+// relative to the beginning of the zip file.
+func (f *File) HeaderOffset() int64 { return f.headerOffset }
+
 // DataOffset returns the offset of the file's possibly-compressed
 // data, relative to the beginning of the zip file.
 //
@@ -174,6 +345,8 @@ func (f *File) DataOffset() (offset int64, err error) {
 
 // Open returns a ReadCloser that provides access to the File's contents.
 // Multiple files may be read concurrently.
+//
+// The ReadCloser of a Stored or Deflated file also implements io.Seeker and io.ReaderAt.
 func (f *File) Open() (io.ReadCloser, error) {
 	bodyOffset, err := f.findBodyOffset()
 	if err != nil {
@@ -186,12 +359,15 @@ func (f *File) Open() (io.ReadCloser, error) {
 		return nil, ErrAlgorithm
 	}
 	var rc io.ReadCloser = dcomp(r)
-	rc = &checksumReader{
+	cr := &checksumReader{
 		rc:   rc,
 		hash: crc32.NewIEEE(),
 		f:    f,
 	}
-	return rc, nil
+	if f.Method == Store || f.Method == Deflate {
+		return &seekReader{checksumReader: cr, body: r}, nil
+	}
+	return cr, nil
 }
 
 // OpenRaw returns a Reader that provides access to the File's contents without
@@ -310,6 +486,63 @@ func (f *File) findBodyOffset() (int64, error) {
 	return int64(fileHeaderLen + filenameLen + extraLen), nil
 }
 
+// extTimes returns the times of the extended timestamp extra field b, in the order
+// of its flags, ok is false if some of them are missing.
+func extTimes(b readBuf) (modified, accessed, created time.Time, ok bool) {
+	if len(b) < 1 {
+		return
+	}
+	flags := b.uint8()
+	for i, t := range []*time.Time{&modified, &accessed, &created} {
+		if flags&(1<<i) == 0 {
+			continue
+		}
+		if len(b) < 4 {
+			return modified, accessed, created, false
+		}
+		*t = time.Unix(int64(b.uint32()), 0) // since Unix epoch
+	}
+	return modified, accessed, created, true
+}
+
+// readLocalTimes reads the access and creation times of the extended timestamp extra
+// field of the local header, they are optional so the errors are ignored.
+func (f *File) readLocalTimes() {
+	var buf [fileHeaderLen]byte
+	if _, err := f.zipr.ReadAt(buf[:], f.headerOffset); err != nil {
+		return
+	}
+	b := readBuf(buf[:])
+	if sig := b.uint32(); sig != fileHeaderSignature {
+		return
+	}
+	b = b[22:] // skip over most of the header
+	filenameLen := int(b.uint16())
+	extra := make(readBuf, int(b.uint16()))
+	if _, err := f.zipr.ReadAt(extra, f.headerOffset+fileHeaderLen+int64(filenameLen)); err != nil {
+		return
+	}
+	for len(extra) >= 4 {
+		fieldTag := extra.uint16()
+		fieldSize := int(extra.uint16())
+		if len(extra) < fieldSize {
+			return
+		}
+		fieldBuf := extra.sub(fieldSize)
+		if fieldTag != extTimeExtraID {
+			continue
+		}
+		_, accessed, created, _ := extTimes(fieldBuf)
+		if !accessed.IsZero() && f.Accessed.IsZero() {
+			f.Accessed = accessed.UTC()
+		}
+		if !created.IsZero() && f.Created.IsZero() {
+			f.Created = created.UTC()
+		}
+		return
+	}
+}
+
 // readDirectoryHeader attempts to read a directory header from r.
 // It returns io.ErrUnexpectedEOF if it cannot read a complete header,
 // and ErrFormat if it doesn't find a valid header signature.
@@ -336,7 +569,8 @@ func readDirectoryHeader(f *File, r io.Reader) error {
 	filenameLen := int(b.uint16())
 	extraLen := int(b.uint16())
 	commentLen := int(b.uint16())
-	b = b[4:] // skipped start disk number and internal attributes (2x uint16)
+	f.disk = uint32(b.uint16())
+	b = b[2:] // skipped internal attributes (uint16)
 	f.ExternalAttrs = b.uint32()
 	f.headerOffset = int64(b.uint32())
 	d := make([]byte, filenameLen+extraLen+commentLen)
@@ -352,7 +586,7 @@ func readDirectoryHeader(f *File, r io.Reader) error {
 		f.Name = string(d[:filenameLen])
 	}
 	f.Extra = d[filenameLen : filenameLen+extraLen]
-	f.Comment = string(d[filenameLen+extraLen:])
+	f.Comment = decodeComment(string(d[filenameLen+extraLen:]))
 
 	// Determine the character encoding.
 	utf8Valid1, utf8Require1 := detectUTF8(f.Name)
@@ -375,11 +609,15 @@ func readDirectoryHeader(f *File, r io.Reader) error {
 	needUSize := f.UncompressedSize == ^uint32(0)
 	needCSize := f.CompressedSize == ^uint32(0)
 	needHeaderOffset := f.headerOffset == int64(^uint32(0))
+	needDisk := f.disk == uint16max
 
 	// Best effort to find what we need.
 	// Other zip authors might not even follow the basic format,
 	// and we'll just ignore the Extra content in that case.
-	var modified time.Time
+	var (
+		modified, accessed, created time.Time
+		precise                     bool // the times are read from the NTFS extra field
+	)
 parseExtras:
 	for extra := readBuf(f.Extra); len(extra) >= 4; { // need at least tag and size
 		fieldTag := extra.uint16()
@@ -418,6 +656,13 @@ parseExtras:
 				}
 				f.headerOffset = int64(fieldBuf.uint64())
 			}
+			if needDisk {
+				needDisk = false
+				if len(fieldBuf) < 4 {
+					return ErrFormat
+				}
+				f.disk = fieldBuf.uint32()
+			}
 		case ntfsExtraID:
 			if len(fieldBuf) < 4 {
 				continue parseExtras
@@ -434,31 +679,61 @@ parseExtras:
 					continue // Ignore irrelevant attributes
 				}
 
-				const ticksPerSecond = 1e7    // Windows timestamp resolution
-				ts := int64(attrBuf.uint64()) // ModTime since Windows epoch
-				secs := int64(ts / ticksPerSecond)
-				nsecs := (1e9 / ticksPerSecond) * int64(ts%ticksPerSecond)
-				epoch := time.Date(1601, time.January, 1, 0, 0, 0, 0, time.UTC)
-				modified = time.Unix(epoch.Unix()+secs, nsecs)
+				modified = fileTimeToTime(attrBuf.uint64())
+				accessed = fileTimeToTime(attrBuf.uint64())
+				created = fileTimeToTime(attrBuf.uint64())
+				precise = true
 			}
 		case unixExtraID, infoZipUnixExtraID:
 			if len(fieldBuf) < 8 {
 				continue parseExtras
 			}
-			fieldBuf.uint32()              // AcTime (ignored)
-			ts := int64(fieldBuf.uint32()) // ModTime since Unix epoch
-			modified = time.Unix(ts, 0)
+			atime := int64(fieldBuf.uint32()) // AcTime since Unix epoch
+			mtime := int64(fieldBuf.uint32()) // ModTime since Unix epoch
+			if !precise {
+				modified, accessed = time.Unix(mtime, 0), time.Unix(atime, 0)
+			}
+			if fieldTag == infoZipUnixExtraID && len(fieldBuf) >= 4 && !f.HasOwner {
+				f.Uid = int(fieldBuf.uint16())
+				f.Gid = int(fieldBuf.uint16())
+				f.HasOwner = true
+			}
+		case newUnixExtraID:
+			if len(fieldBuf) < 2 || fieldBuf.uint8() != 1 { // version
+				continue parseExtras
+			}
+			uid, ok := fieldBuf.uintN()
+			if !ok {
+				continue parseExtras
+			}
+			gid, ok := fieldBuf.uintN()
+			if !ok {
+				continue parseExtras
+			}
+			f.Uid, f.Gid, f.HasOwner = int(uid), int(gid), true
 		case extTimeExtraID:
-			if len(fieldBuf) < 5 || fieldBuf.uint8()&1 == 0 {
+			if len(fieldBuf) < 1 || precise {
 				continue parseExtras
 			}
-			ts := int64(fieldBuf.uint32()) // ModTime since Unix epoch
-			modified = time.Unix(ts, 0)
+			var ok bool
+			modified, accessed, created, ok = extTimes(fieldBuf)
+			// the flags report the times of the local header, the central
+			// header may only have ModTime (Info-ZIP)
+			f.localTimes = !ok
 		}
 	}
 
-	msdosModified := msDosTimeToTime(f.ModifiedDate, f.ModifiedTime)
+	msdosModified := msDosTimeToTime(f.ModifiedDate, f.ModifiedTime, time.UTC)
 	f.Modified = msdosModified
+	if f.zip != nil && f.zip.loc != nil {
+		f.Modified = msDosTimeToTime(f.ModifiedDate, f.ModifiedTime, f.zip.loc)
+	}
+	if !accessed.IsZero() {
+		f.Accessed = accessed.UTC()
+	}
+	if !created.IsZero() {
+		f.Created = created.UTC()
+	}
 	if !modified.IsZero() {
 		f.Modified = modified.UTC()
 
@@ -539,7 +814,10 @@ func readDataDescriptor(r io.Reader, zip64 bool) (*dataDescriptor, error) {
 	return out, nil
 }
 
-func readDirectoryEnd(r io.ReaderAt, size int64) (dir *directoryEnd, err error) {
+// readDirectoryEnd reads the directory end of the archive, diskStart is
+// the start offset of each volume of a split archive (or nil).
+// It also returns the size of the data prepended to the archive.
+func readDirectoryEnd(r io.ReaderAt, size int64, diskStart []int64) (dir *directoryEnd, baseOffset int64, err error) {
 	// look for directoryEndSignature in the last 1k, then in the last 65k
 	var buf []byte
 	var directoryEndOffset int64
@@ -549,7 +827,7 @@ func readDirectoryEnd(r io.ReaderAt, size int64) (dir *directoryEnd, err error)
 		}
 		buf = make([]byte, int(bLen))
 		if _, err := r.ReadAt(buf, size-bLen); err != nil && err != io.EOF {
-			return nil, err
+			return nil, 0, err
 		}
 		if p := findSignatureInBlock(buf); p >= 0 {
 			buf = buf[p:]
@@ -557,7 +835,7 @@ func readDirectoryEnd(r io.ReaderAt, size int64) (dir *directoryEnd, err error)
 			break
 		}
 		if i == 1 || bLen == size {
-			return nil, ErrFormat
+			return nil, 0, ErrFormat
 		}
 	}
 
@@ -574,31 +852,53 @@ func readDirectoryEnd(r io.ReaderAt, size int64) (dir *directoryEnd, err error)
 	}
 	l := int(d.commentLen)
 	if l > len(b) {
-		return nil, errors.New("zip: invalid comment length")
+		return nil, 0, errors.New("zip: invalid comment length")
 	}
 	d.comment = string(b[:l])
 
 	// These values mean that the file can be a zip64 file
 	if d.directoryRecords == 0xffff || d.directorySize == 0xffff || d.directoryOffset == 0xffffffff {
-		p, err := findDirectory64End(r, directoryEndOffset)
+		p, err := findDirectory64End(r, directoryEndOffset, diskStart)
 		if err == nil && p >= 0 {
+			directoryEndOffset = p
 			err = readDirectory64End(r, p, d)
 		}
 		if err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 	}
+	// Split archives are checked later, as their offsets are relative to each disk.
+	if diskStart != nil {
+		return d, 0, nil
+	}
+
+	// Data may be prepended to the archive (e.g. a self-extracting stub),
+	// the central directory ends right before the directory end record.
+	baseOffset = directoryEndOffset - int64(d.directorySize) - int64(d.directoryOffset)
+
 	// Make sure directoryOffset points to somewhere in our file.
-	if o := int64(d.directoryOffset); o < 0 || o >= size {
-		return nil, ErrFormat
+	if o := baseOffset + int64(d.directoryOffset); o < 0 || o >= size {
+		return nil, 0, ErrFormat
+	}
+
+	// If the directory end data tells us to use a non-zero baseOffset,
+	// but we would find a valid directory entry if we assume that the
+	// baseOffset is 0, then just use a baseOffset of 0.
+	// Some writers (e.g. "zip -A" or SetOffset) already adjust the offsets.
+	if baseOffset > 0 {
+		off := int64(d.directoryOffset)
+		rs := io.NewSectionReader(r, off, size-off)
+		if readDirectoryHeader(&File{}, rs) == nil {
+			baseOffset = 0
+		}
 	}
-	return d, nil
+	return d, baseOffset, nil
 }
 
 // findDirectory64End tries to read the zip64 locator just before the
 // directory end and returns the offset of the zip64 directory end if
 // found.
-func findDirectory64End(r io.ReaderAt, directoryEndOffset int64) (int64, error) {
+func findDirectory64End(r io.ReaderAt, directoryEndOffset int64, diskStart []int64) (int64, error) {
 	locOffset := directoryEndOffset - directory64LocLen
 	if locOffset < 0 {
 		return -1, nil // no need to look for a header outside the file
@@ -611,14 +911,19 @@ func findDirectory64End(r io.ReaderAt, directoryEndOffset int64) (int64, error)
 	if sig := b.uint32(); sig != directory64LocSignature {
 		return -1, nil
 	}
-	if b.uint32() != 0 { // number of the disk with the start of the zip64 end of central directory
-		return -1, nil // the file is not a valid zip64-file
+	disk := b.uint32()  // number of the disk with the start of the zip64 end of central directory
+	p := b.uint64()     // relative offset of the zip64 end of central directory record
+	disks := b.uint32() // total number of disks
+	if diskStart == nil {
+		if disk != 0 || disks != 1 {
+			return -1, nil // the file is not a valid zip64-file
+		}
+		return int64(p), nil
 	}
-	p := b.uint64()      // relative offset of the zip64 end of central directory record
-	if b.uint32() != 1 { // total number of disks
-		return -1, nil // the file is not a valid zip64-file
+	if int(disk) >= len(diskStart) || int(disks) != len(diskStart) {
+		return -1, ErrVolume
 	}
-	return int64(p), nil
+	return diskStart[disk] + int64(p), nil
 }
 
 // readDirectory64End reads the zip64 directory end and updates the
@@ -659,6 +964,87 @@ func findSignatureInBlock(b []byte) int {
 	return -1
 }
 
+// multiReaderAt is the io.ReaderAt of the concatenated volumes of a split archive.
+type multiReaderAt struct {
+	r     []io.ReaderAt
+	start []int64 // start offset of each volume, and the total size
+}
+
+func (m *multiReaderAt) ReadAt(p []byte, off int64) (n int, err error) {
+	if off < 0 {
+		return 0, errors.New("zip: negative offset")
+	}
+	i := sort.Search(len(m.r), func(i int) bool { return m.start[i+1] > off })
+	for len(p) > 0 {
+		if i >= len(m.r) {
+			return n, io.EOF
+		}
+		size := m.start[i+1] - m.start[i]
+		rel := off - m.start[i]
+		b := p
+		if int64(len(b)) > size-rel {
+			b = b[:size-rel]
+		}
+		nn, err := m.r[i].ReadAt(b, rel)
+		n += nn
+		off += int64(nn)
+		p = p[nn:]
+		if nn == len(b) {
+			i++
+			continue
+		}
+		if err == nil || err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return n, err
+	}
+	return n, nil
+}
+
+// openFile opens name from fsys, or from the OS file system if fsys is nil.
+func openFile(fsys fs.FS, name string) (fs.File, error) {
+	if fsys == nil {
+		return os.Open(name)
+	}
+	return fsys.Open(name)
+}
+
+// splitVolumes returns the volumes of the split archive which name belongs to,
+// in disk order (name.z01, name.z02, ..., name.zip), they are found in fsys or in
+// the OS file system if fsys is nil.
+// It returns nil if name is not a volume of a split archive.
+func splitVolumes(fsys fs.FS, name string) []string {
+	stat := os.Stat
+	if fsys != nil {
+		stat = func(name string) (fs.FileInfo, error) { return fs.Stat(fsys, name) }
+	}
+	ext := filepath.Ext(name)
+	if len(ext) < 3 || (ext[1] != 'z' && ext[1] != 'Z') {
+		return nil
+	}
+	if !strings.EqualFold(ext, ".zip") {
+		if _, err := strconv.Atoi(ext[2:]); err != nil {
+			return nil
+		}
+	}
+	base := name[:len(name)-len(ext)]
+	var volumes []string
+	for i := 1; ; i++ {
+		volume := fmt.Sprintf("%s.%c%02d", base, ext[1], i)
+		if _, err := stat(volume); err != nil {
+			break
+		}
+		volumes = append(volumes, volume)
+	}
+	if volumes == nil {
+		return nil
+	}
+	if ext[1] == 'Z' {
+		return append(volumes, base+".ZIP")
+	}
+	return append(volumes, base+".zip")
+}
+
 type readBuf []byte
 
 func (b *readBuf) uint8() uint8 {
@@ -685,6 +1071,22 @@ func (b *readBuf) uint64() uint64 {
 	return v
 }
 
+// uintN reads a size prefixed little-endian integer of up to 8 bytes.
+func (b *readBuf) uintN() (uint64, bool) {
+	if len(*b) < 1 {
+		return 0, false
+	}
+	n := int(b.uint8())
+	if n > 8 || len(*b) < n {
+		return 0, false
+	}
+	var v uint64
+	for i, c := range b.sub(n) {
+		v |= uint64(c) << (8 * i)
+	}
+	return v, true
+}
+
 func (b *readBuf) sub(n int) readBuf {
 	b2 := (*b)[:n]
 	*b = (*b)[n:]
@@ -697,6 +1099,7 @@ type fileListEntry struct {
 	name  string
 	file  *File
 	isDir bool
+	seq   int // the position in the archive, the first file of the directory without metadata
 }
 
 type fileInfoDirEntry interface {
@@ -717,7 +1120,12 @@ func (f *fileListEntry) Size() int64       { return 0 }
 func (f *fileListEntry) Mode() fs.FileMode { return fs.ModeDir | 0555 }
 func (f *fileListEntry) Type() fs.FileMode { return fs.ModeDir }
 func (f *fileListEntry) IsDir() bool       { return true }
-func (f *fileListEntry) Sys() interface{}  { return nil }
+func (f *fileListEntry) Sys() interface{} {
+	if f.file == nil {
+		return nil
+	}
+	return &f.file.FileHeader
+}
 
 func (f *fileListEntry) ModTime() time.Time {
 	if f.file == nil {
@@ -728,6 +1136,13 @@ func (f *fileListEntry) ModTime() time.Time {
 
 func (f *fileListEntry) Info() (fs.FileInfo, error) { return f, nil }
 
+func (f *fileListEntry) EntryComment() string {
+	if f.file == nil {
+		return ""
+	}
+	return f.file.Comment
+}
+
 // toValidName coerces name to be a valid name for fs.FS.Open.
 func toValidName(name string) string {
 	name = strings.ReplaceAll(name, `\`, `/`)
@@ -743,42 +1158,68 @@ func toValidName(name string) string {
 
 func (r *Reader) initFileList() {
 	r.fileListOnce.Do(func() {
-		dirs := make(map[string]bool)
-		knownDirs := make(map[string]bool)
-		for _, file := range r.File {
+		dirs := make(map[string]int)
+		knownDirs := make(map[string]int)
+		for i, file := range r.File {
 			isDir := len(file.Name) > 0 && file.Name[len(file.Name)-1] == '/'
 			name := toValidName(file.Name)
 			if name == "" {
 				continue
 			}
 			for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
-				dirs[dir] = true
+				if _, ok := dirs[dir]; !ok {
+					dirs[dir] = i
+				}
 			}
 			entry := fileListEntry{
 				name:  name,
 				file:  file,
 				isDir: isDir,
+				seq:   i,
 			}
 			r.fileList = append(r.fileList, entry)
 			if isDir {
-				knownDirs[name] = true
+				knownDirs[name] = len(r.fileList) - 1
 			}
 		}
-		for dir := range dirs {
-			if !knownDirs[dir] {
+		for dir, seq := range dirs {
+			if idx, ok := knownDirs[dir]; !ok {
 				entry := fileListEntry{
 					name:  dir,
 					file:  nil,
 					isDir: true,
+					seq:   seq,
 				}
 				r.fileList = append(r.fileList, entry)
+			} else if seq < r.fileList[idx].seq {
+				r.fileList[idx].seq = seq
 			}
 		}
 
 		sort.Slice(r.fileList, func(i, j int) bool { return fileEntryLess(r.fileList[i].name, r.fileList[j].name) })
+
+		if r.match != 0 {
+			r.names = compress.NewNameIndex(r.match)
+			for _, e := range r.fileList {
+				r.names.Add(e.name)
+			}
+		}
 	})
 }
 
+// SetLookup set the matching of the names which are not found as is,
+// it must be called before the first Open.
+func (r *Reader) SetLookup(opt compress.LookupOption) { r.match = opt }
+
+// Collisions returns the names of the archive which match the same name by the lookup options.
+func (r *Reader) Collisions() [][]string {
+	r.initFileList()
+	if r.names == nil {
+		return nil
+	}
+	return r.names.Collisions()
+}
+
 func fileEntryLess(x, y string) bool {
 	xdir, xelem, _ := split(x)
 	ydir, yelem, _ := split(y)
@@ -795,9 +1236,9 @@ func (r *Reader) Open(name string) (fs.File, error) {
 	if !fs.ValidPath(name) {
 		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
 	}
-	e := r.openLookup(name)
-	if e == nil {
-		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	e, name, err := r.find("open", name)
+	if err != nil {
+		return nil, err
 	}
 	if e.isDir {
 		return &openDir{e, r.openReadDir(name), 0}, nil
@@ -809,6 +1250,72 @@ func (r *Reader) Open(name string) (fs.File, error) {
 	return rc.(fs.File), nil
 }
 
+// Lstat returns the fs.FileInfo of the named file in the ZIP archive,
+// the symbolic link is not followed.
+func (r *Reader) Lstat(name string) (fs.FileInfo, error) {
+	r.initFileList()
+
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "lstat", Path: name, Err: fs.ErrInvalid}
+	}
+	e, _, err := r.find("lstat", name)
+	if err != nil {
+		return nil, err
+	}
+	return e.stat(), nil
+}
+
+// ReadLink returns the destination of the named symbolic link in the ZIP archive,
+// which is stored as the file content.
+func (r *Reader) ReadLink(name string) (string, error) {
+	r.initFileList()
+
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+	e, _, err := r.find("readlink", name)
+	if err != nil {
+		return "", err
+	}
+	if e.isDir || e.file.Mode()&fs.ModeSymlink == 0 {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+	rc, err := e.file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	target, err := io.ReadAll(rc)
+	if err != nil {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: err}
+	}
+	return string(target), nil
+}
+
+// ReadDirArchive reads the named directory in the ZIP archive, the entries
+// are in the order of the archive instead of sorted by name.
+func (r *Reader) ReadDirArchive(name string) ([]fs.DirEntry, error) {
+	r.initFileList()
+
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	e, name, err := r.find("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	if !e.isDir {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+	}
+	files := append([]fileListEntry(nil), r.openReadDir(name)...)
+	sort.SliceStable(files, func(i, j int) bool { return files[i].seq < files[j].seq })
+	list := make([]fs.DirEntry, len(files))
+	for i := range files {
+		list[i] = files[i].stat()
+	}
+	return list, nil
+}
+
 func split(name string) (dir, elem string, isDir bool) {
 	if len(name) > 0 && name[len(name)-1] == '/' {
 		isDir = true
@@ -826,6 +1333,22 @@ func split(name string) (dir, elem string, isDir bool) {
 
 var dotFile = &fileListEntry{name: "./", isDir: true}
 
+// find returns the entry of name and its name in the archive, name is matched
+// by the lookup options if it is not found as is.
+func (r *Reader) find(op, name string) (*fileListEntry, string, error) {
+	if e := r.openLookup(name); e != nil {
+		return e, name, nil
+	}
+	if r.names == nil {
+		return nil, "", &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	found, err := r.names.Find(name)
+	if err != nil {
+		return nil, "", &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	return r.openLookup(found), found, nil
+}
+
 func (r *Reader) openLookup(name string) *fileListEntry {
 	if name == "." {
 		return dotFile
@@ -0,0 +1,115 @@
+// Package std_zip
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package std_zip
+
+import (
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/pashifika/compress/internal/zran"
+)
+
+var errNegativeOffset = errors.New("zip: negative offset")
+
+// randomReader is the random access to the uncompressed data of a file.
+type randomReader interface {
+	io.ReadSeeker
+	io.ReaderAt
+}
+
+// seekReader is the io.Seeker and io.ReaderAt of the Stored and Deflated files.
+// The sequential read from the start is checked by CRC-32 as checksumReader,
+// the other reads are not.
+type seekReader struct {
+	*checksumReader
+	body *io.SectionReader
+	off  int64
+
+	mu   sync.Mutex
+	rand randomReader
+}
+
+func (r *seekReader) Read(b []byte) (n int, err error) {
+	if r.rand == nil && r.off == int64(r.nread) {
+		n, err = r.checksumReader.Read(b)
+		r.off += int64(n)
+		return
+	}
+	ra, err := r.random()
+	if err != nil {
+		return 0, err
+	}
+	if _, err = ra.Seek(r.off, io.SeekStart); err != nil {
+		return 0, err
+	}
+	n, err = ra.Read(b)
+	r.off += int64(n)
+	return
+}
+
+func (r *seekReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += r.off
+	case io.SeekEnd:
+		offset += int64(r.f.UncompressedSize64)
+	}
+	if offset < 0 {
+		return 0, errNegativeOffset
+	}
+	r.off = offset
+	return offset, nil
+}
+
+// ReadAt is safe to call concurrently, but not with Read.
+func (r *seekReader) ReadAt(b []byte, off int64) (int, error) {
+	ra, err := r.random()
+	if err != nil {
+		return 0, err
+	}
+	return ra.ReadAt(b, off)
+}
+
+// random returns the random access reader, the Deflated file is indexed
+// at the first call to not decompress from the start for each seek.
+func (r *seekReader) random() (randomReader, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.rand != nil {
+		return r.rand, nil
+	}
+	if r.f.Method == Store {
+		r.rand = r.body
+		return r.rand, nil
+	}
+	idx, err := r.f.deflateIndex(r.body)
+	if err != nil {
+		return nil, err
+	}
+	r.rand = zran.NewReader(r.body, r.body.Size(), idx)
+	return r.rand, nil
+}
+
+// deflateIndex returns the access points of the Deflated file, which is built once.
+func (f *File) deflateIndex(body *io.SectionReader) (*zran.Index, error) {
+	f.indexOnce.Do(func() {
+		f.index, f.indexErr = zran.BuildIndex(io.NewSectionReader(body, 0, body.Size()), zran.DefaultSpan)
+	})
+	return f.index, f.indexErr
+}
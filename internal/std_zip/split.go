@@ -0,0 +1,133 @@
+// Package std_zip
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package std_zip
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"sort"
+)
+
+// minSplitSize is the minimal volume size, large enough for any header.
+const minSplitSize = 64 * 1024
+
+// splitWriter writes the volumes of a split archive.
+type splitWriter struct {
+	w       io.Writer
+	size    int64 // max size of a volume
+	written int64 // bytes written to the current volume
+	pos     int64 // bytes written to all volumes
+	start   []int64
+	next    func(disk int) (io.Writer, error)
+}
+
+func (s *splitWriter) Write(p []byte) (n int, err error) {
+	for len(p) > 0 {
+		if s.written >= s.size {
+			if err = s.roll(); err != nil {
+				return n, err
+			}
+		}
+		b := p
+		if room := s.size - s.written; int64(len(b)) > room {
+			b = b[:room]
+		}
+		nn, err := s.w.Write(b)
+		n += nn
+		s.written += int64(nn)
+		s.pos += int64(nn)
+		if err != nil {
+			return n, err
+		}
+		p = p[nn:]
+	}
+	return n, nil
+}
+
+// roll opens the next volume.
+func (s *splitWriter) roll() error {
+	w, err := s.next(len(s.start))
+	if err != nil {
+		return err
+	}
+	s.w = w
+	s.written = 0
+	s.start = append(s.start, s.pos)
+	return nil
+}
+
+// disk returns the number of the current volume.
+func (s *splitWriter) disk() uint32 { return uint32(len(s.start) - 1) }
+
+// SetSplit makes w write a split archive: the writer given to NewWriter
+// receives the first volume, and next is called to get the writer of each
+// following volume (disk is 1 for the second volume). A volume is at most
+// size bytes, and headers never straddle two volumes.
+//
+// Split archives are usually named name.z01, name.z02, ..., and the last
+// volume name.zip.
+// It must be called before any data is written.
+func (w *Writer) SetSplit(size int64, next func(disk int) (io.Writer, error)) error {
+	if w.cw.count != 0 {
+		panic("zip: SetSplit called after data was written")
+	}
+	if size < minSplitSize {
+		return errors.New("zip: split size too small")
+	}
+	w.split = &splitWriter{
+		w:     w.dst,
+		size:  size,
+		start: []int64{0},
+		next:  next,
+	}
+	w.cw.w.(*bufio.Writer).Reset(w.split)
+
+	// spanning signature, the disk offsets are counted from it.
+	var buf [4]byte
+	b := writeBuf(buf[:])
+	b.uint32(dataDescriptorSignature)
+	_, err := w.cw.Write(buf[:])
+	return err
+}
+
+// reserve makes sure the next n bytes are written on the same volume.
+func (w *Writer) reserve(n int) error {
+	if w.split == nil {
+		return nil
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	s := w.split
+	if s.written > 0 && s.written+int64(n) > s.size {
+		return s.roll()
+	}
+	return nil
+}
+
+// locate converts the offset in the archive to the disk number
+// and the offset relative to that disk.
+func (w *Writer) locate(offset uint64) (uint32, uint64) {
+	if w.split == nil {
+		return 0, offset
+	}
+	start := w.split.start
+	i := sort.Search(len(start), func(i int) bool { return start[i] > int64(offset) }) - 1
+	return uint32(i), offset - uint64(start[i])
+}
@@ -7,7 +7,9 @@ Package zip provides support for reading and writing ZIP archives.
 
 See: https://www.pkware.com/appnote
 
-This package does not support disk spanning.
+Split archives (name.z01, name.z02, ..., name.zip) are supported by
+OpenReader, OpenReaderFS, NewMultiReader and Writer.SetSplit; spanning over removable
+media is not.
 
 A note about ZIP64:
 
@@ -75,6 +77,7 @@ const (
 	unixExtraID        = 0x000d // UNIX
 	extTimeExtraID     = 0x5455 // Extended timestamp
 	infoZipUnixExtraID = 0x5855 // Info-ZIP Unix extension
+	newUnixExtraID     = 0x7875 // Info-ZIP New Unix extension (uid/gid)
 )
 
 // FileHeader describes a file within a zip file.
@@ -119,7 +122,8 @@ type FileHeader struct {
 	//
 	// When reading, an extended timestamp is preferred over the legacy MS-DOS
 	// date field, and the offset between the times is used as the timezone.
-	// If only the MS-DOS date is present, the timezone is assumed to be UTC.
+	// If only the MS-DOS date is present, the timezone is assumed to be UTC,
+	// or the location given to OpenReaderIn.
 	//
 	// When writing, an extended timestamp (which is timezone-agnostic) is
 	// always emitted. The legacy MS-DOS date field is encoded according to the
@@ -128,6 +132,15 @@ type FileHeader struct {
 	ModifiedTime uint16 // Deprecated: Legacy MS-DOS date; use Modified instead.
 	ModifiedDate uint16 // Deprecated: Legacy MS-DOS time; use Modified instead.
 
+	// Accessed and Created are the access and creation times of the file in UTC,
+	// read from the NTFS or the extended timestamp extra fields. They are zero if
+	// the archive does not store them, and are not written.
+	//
+	// Info-ZIP only writes ModTime in the central directory, the times of the
+	// local header are read by the first call of Times, not when opening.
+	Accessed time.Time
+	Created  time.Time
+
 	CRC32              uint32
 	CompressedSize     uint32 // Deprecated: Use CompressedSize64 instead.
 	UncompressedSize   uint32 // Deprecated: Use UncompressedSize64 instead.
@@ -135,6 +148,30 @@ type FileHeader struct {
 	UncompressedSize64 uint64
 	Extra              []byte
 	ExternalAttrs      uint32 // Meaning depends on CreatorVersion
+
+	// Uid and Gid are the Unix owner of the file, they are only meaningful
+	// if HasOwner is set.
+	//
+	// When reading, they are parsed from the Info-ZIP Unix extra fields.
+	// When writing, the Info-ZIP New Unix extra field is emitted if HasOwner is set.
+	Uid      int
+	Gid      int
+	HasOwner bool
+
+	readTimes func() // reads the times which are only in the local header
+}
+
+// Owner returns the Unix owner of the file (see compress.Owner).
+func (h *FileHeader) Owner() (uid, gid int, ok bool) {
+	return h.Uid, h.Gid, h.HasOwner
+}
+
+// Times returns the access and creation times of the file (see compress.Times).
+func (h *FileHeader) Times() (accessed, created time.Time) {
+	if h.readTimes != nil {
+		h.readTimes()
+	}
+	return h.Accessed, h.Created
 }
 
 // FileInfo returns an fs.FileInfo for the FileHeader.
@@ -167,6 +204,8 @@ func (fi headerFileInfo) Sys() interface{}  { return fi.fh }
 
 func (fi headerFileInfo) Info() (fs.FileInfo, error) { return fi, nil }
 
+func (fi headerFileInfo) EntryComment() string { return fi.fh.Comment }
+
 // FileInfoHeader creates a partially-populated FileHeader from an
 // fs.FileInfo.
 // Because fs.FileInfo's Name method returns only the base name of
@@ -174,6 +213,7 @@ func (fi headerFileInfo) Info() (fs.FileInfo, error) { return fi, nil }
 // of the returned header to provide the full path name of the file.
 // If compression is desired, callers should set the FileHeader.Method
 // field; it is unset by default.
+// The owner is not set, callers which keep it should set it from FileInfoOwner.
 func FileInfoHeader(fi fs.FileInfo) (*FileHeader, error) {
 	size := fi.Size()
 	fh := &FileHeader{
@@ -190,6 +230,15 @@ func FileInfoHeader(fi fs.FileInfo) (*FileHeader, error) {
 	return fh, nil
 }
 
+// FileInfoOwner returns the Unix owner of fi, from the Owner method of fi.Sys()
+// (see compress.Owner) or from the syscall.Stat_t of the os.FileInfo.
+func FileInfoOwner(fi fs.FileInfo) (uid, gid int, ok bool) {
+	if o, ok := fi.Sys().(interface{ Owner() (int, int, bool) }); ok {
+		return o.Owner()
+	}
+	return sysOwner(fi.Sys())
+}
+
 type directoryEnd struct {
 	diskNbr            uint32 // unused
 	dirDiskNbr         uint32 // unused
@@ -216,10 +265,10 @@ func timeZone(offset time.Duration) *time.Location {
 	return time.FixedZone("", int(offset/time.Second))
 }
 
-// msDosTimeToTime converts an MS-DOS date and time into a time.Time.
+// msDosTimeToTime converts an MS-DOS date and time in loc into a time.Time.
 // The resolution is 2s.
 // See: https://msdn.microsoft.com/en-us/library/ms724247(v=VS.85).aspx
-func msDosTimeToTime(dosDate, dosTime uint16) time.Time {
+func msDosTimeToTime(dosDate, dosTime uint16, loc *time.Location) time.Time {
 	return time.Date(
 		// date bits 0-4: day of month; 5-8: month; 9-15: years since 1980
 		int(dosDate>>9+1980),
@@ -232,10 +281,17 @@ func msDosTimeToTime(dosDate, dosTime uint16) time.Time {
 		int(dosTime&0x1f*2),
 		0, // nanoseconds
 
-		time.UTC,
+		loc,
 	)
 }
 
+// fileTimeToTime converts a Windows FILETIME (100ns since 1601) into a time.Time.
+func fileTimeToTime(ft uint64) time.Time {
+	const ticksPerSecond = 1e7 // Windows timestamp resolution
+	epoch := time.Date(1601, time.January, 1, 0, 0, 0, 0, time.UTC)
+	return time.Unix(epoch.Unix()+int64(ft/ticksPerSecond), int64(ft%ticksPerSecond)*(1e9/ticksPerSecond))
+}
+
 // timeToMsDosTime converts a time.Time to an MS-DOS date and time.
 // The resolution is 2s.
 // See: https://msdn.microsoft.com/en-us/library/ms724274(v=VS.85).aspx
@@ -250,7 +306,7 @@ func timeToMsDosTime(t time.Time) (fDate uint16, fTime uint16) {
 //
 // Deprecated: Use Modified instead.
 func (h *FileHeader) ModTime() time.Time {
-	return msDosTimeToTime(h.ModifiedDate, h.ModifiedTime)
+	return msDosTimeToTime(h.ModifiedDate, h.ModifiedTime, time.UTC)
 }
 
 // SetModTime sets the Modified, ModifiedTime, and ModifiedDate fields
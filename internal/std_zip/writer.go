@@ -22,7 +22,9 @@ var (
 
 // Writer implements a zip file writer.
 type Writer struct {
+	dst         io.Writer
 	cw          *countWriter
+	split       *splitWriter
 	dir         []*header
 	last        *fileWriter
 	closed      bool
@@ -42,7 +44,7 @@ type header struct {
 
 // NewWriter returns a new Writer writing a zip file to w.
 func NewWriter(w io.Writer) *Writer {
-	return &Writer{cw: &countWriter{w: bufio.NewWriter(w)}}
+	return &Writer{dst: w, cw: &countWriter{w: bufio.NewWriter(w)}}
 }
 
 // SetOffset sets the offset of the beginning of the zip data within the
@@ -87,8 +89,23 @@ func (w *Writer) Close() error {
 	w.closed = true
 
 	// write central directory
-	start := w.cw.count
-	for _, h := range w.dir {
+	var start int64
+	var recordDisk, records uint32
+	for i, h := range w.dir {
+		if err := w.reserve(directoryHeaderLen + len(h.Name) + len(h.Extra) + 28 + len(h.Comment)); err != nil {
+			return err
+		}
+		if i == 0 {
+			start = w.cw.count
+			recordDisk, _ = w.locate(uint64(start))
+		}
+		disk, offset := w.locate(h.offset)
+		if w.split != nil && w.split.disk() != recordDisk {
+			recordDisk = w.split.disk()
+			records = 0
+		}
+		records++
+
 		var buf [directoryHeaderLen]byte
 		b := writeBuf(buf[:])
 		b.uint32(uint32(directoryHeaderSignature))
@@ -99,7 +116,7 @@ func (w *Writer) Close() error {
 		b.uint16(h.ModifiedTime)
 		b.uint16(h.ModifiedDate)
 		b.uint32(h.CRC32)
-		if h.isZip64() || h.offset >= uint32max {
+		if h.isZip64() || offset >= uint32max {
 			// the file needs a zip64 header. store maxint in both
 			// 32 bit size fields (and offset later) to signal that the
 			// zip64 extra header should be used.
@@ -113,7 +130,7 @@ func (w *Writer) Close() error {
 			eb.uint16(24) // size = 3x uint64
 			eb.uint64(h.UncompressedSize64)
 			eb.uint64(h.CompressedSize64)
-			eb.uint64(h.offset)
+			eb.uint64(offset)
 			h.Extra = append(h.Extra, buf[:]...)
 		} else {
 			b.uint32(h.CompressedSize)
@@ -123,12 +140,13 @@ func (w *Writer) Close() error {
 		b.uint16(uint16(len(h.Name)))
 		b.uint16(uint16(len(h.Extra)))
 		b.uint16(uint16(len(h.Comment)))
-		b = b[4:] // skip disk number start and internal file attr (2x uint16)
+		b.uint16(uint16(disk)) // disk number start
+		b = b[2:]              // skip internal file attr (uint16)
 		b.uint32(h.ExternalAttrs)
-		if h.offset > uint32max {
+		if offset > uint32max {
 			b.uint32(uint32max)
 		} else {
-			b.uint32(uint32(h.offset))
+			b.uint32(uint32(offset))
 		}
 		if _, err := w.cw.Write(buf[:]); err != nil {
 			return err
@@ -143,17 +161,32 @@ func (w *Writer) Close() error {
 			return err
 		}
 	}
+	if len(w.dir) == 0 {
+		start = w.cw.count
+	}
 	end := w.cw.count
 
-	records := uint64(len(w.dir))
+	// the end records are written together on the last disk.
+	if err := w.reserve(directory64EndLen + directory64LocLen + directoryEndLen + len(w.comment)); err != nil {
+		return err
+	}
+	startDisk, startOffset := w.locate(uint64(start))
+	lastDisk, endOffset := w.locate(uint64(w.cw.count))
+	disks := lastDisk + 1
+	total := uint64(len(w.dir))
+	if w.split == nil {
+		records = uint32(total)
+	} else if lastDisk != recordDisk {
+		records = 0
+	}
 	size := uint64(end - start)
-	offset := uint64(start)
+	offset := startOffset
 
 	if f := w.testHookCloseSizeOffset; f != nil {
 		f(size, offset)
 	}
 
-	if records >= uint16max || size >= uint32max || offset >= uint32max {
+	if total >= uint16max || size >= uint32max || offset >= uint32max {
 		var buf [directory64EndLen + directory64LocLen]byte
 		b := writeBuf(buf[:])
 
@@ -162,18 +195,18 @@ func (w *Writer) Close() error {
 		b.uint64(directory64EndLen - 12) // length minus signature (uint32) and length fields (uint64)
 		b.uint16(zipVersion45)           // version made by
 		b.uint16(zipVersion45)           // version needed to extract
-		b.uint32(0)                      // number of this disk
-		b.uint32(0)                      // number of the disk with the start of the central directory
-		b.uint64(records)                // total number of entries in the central directory on this disk
-		b.uint64(records)                // total number of entries in the central directory
+		b.uint32(lastDisk)               // number of this disk
+		b.uint32(startDisk)              // number of the disk with the start of the central directory
+		b.uint64(uint64(records))        // total number of entries in the central directory on this disk
+		b.uint64(total)                  // total number of entries in the central directory
 		b.uint64(size)                   // size of the central directory
 		b.uint64(offset)                 // offset of start of central directory with respect to the starting disk number
 
 		// zip64 end of central directory locator
 		b.uint32(directory64LocSignature)
-		b.uint32(0)           // number of the disk with the start of the zip64 end of central directory
-		b.uint64(uint64(end)) // relative offset of the zip64 end of central directory record
-		b.uint32(1)           // total number of disks
+		b.uint32(lastDisk)  // number of the disk with the start of the zip64 end of central directory
+		b.uint64(endOffset) // relative offset of the zip64 end of central directory record
+		b.uint32(disks)     // total number of disks
 
 		if _, err := w.cw.Write(buf[:]); err != nil {
 			return err
@@ -182,6 +215,7 @@ func (w *Writer) Close() error {
 		// store max values in the regular end record to signal
 		// that the zip64 values should be used instead
 		records = uint16max
+		total = uint16max
 		size = uint32max
 		offset = uint32max
 	}
@@ -190,12 +224,13 @@ func (w *Writer) Close() error {
 	var buf [directoryEndLen]byte
 	b := writeBuf(buf[:])
 	b.uint32(uint32(directoryEndSignature))
-	b = b[4:]                        // skip over disk number and first disk number (2x uint16)
-	b.uint16(uint16(records))        // number of entries this disk
-	b.uint16(uint16(records))        // number of entries total
-	b.uint32(uint32(size))           // size of directory
-	b.uint32(uint32(offset))         // start of directory
-	b.uint16(uint16(len(w.comment))) // byte size of EOCD comment
+	b.uint16(uint16(min64(uint64(lastDisk), uint16max)))  // number of this disk
+	b.uint16(uint16(min64(uint64(startDisk), uint16max))) // number of the disk with the start of the central directory
+	b.uint16(uint16(records))                             // number of entries this disk
+	b.uint16(uint16(total))                               // number of entries total
+	b.uint32(uint32(size))                                // size of directory
+	b.uint32(uint32(offset))                              // start of directory
+	b.uint16(uint16(len(w.comment)))                      // byte size of EOCD comment
 	if _, err := w.cw.Write(buf[:]); err != nil {
 		return err
 	}
@@ -258,7 +293,8 @@ func (w *Writer) prepare(fh *FileHeader) error {
 		// See https://golang.org/issue/11144 confusion.
 		return errors.New("archive/zip: invalid duplicate FileHeader")
 	}
-	return nil
+	// the extended timestamp may be added to Extra after prepare.
+	return w.reserve(fileHeaderLen + len(fh.Name) + len(fh.Extra) + 9)
 }
 
 // CreateHeader adds a file to the zip archive using the provided FileHeader
@@ -273,6 +309,65 @@ func (w *Writer) CreateHeader(fh *FileHeader) (io.Writer, error) {
 		return nil, err
 	}
 
+	initHeader(fh)
+
+	var (
+		ow io.Writer
+		fw *fileWriter
+	)
+	h := &header{
+		FileHeader: fh,
+		offset:     uint64(w.cw.count),
+	}
+
+	if strings.HasSuffix(fh.Name, "/") {
+		// Set the compression method to Store to ensure data length is truly zero,
+		// which the writeHeader method always encodes for the size fields.
+		// This is necessary as most compression formats have non-zero lengths
+		// even when compressing an empty string.
+		fh.Method = Store
+		fh.Flags &^= 0x8 // we will not write a data descriptor
+
+		// Explicitly clear sizes as they have no meaning for directories.
+		fh.CompressedSize = 0
+		fh.CompressedSize64 = 0
+		fh.UncompressedSize = 0
+		fh.UncompressedSize64 = 0
+
+		ow = dirWriter{}
+	} else {
+		fh.Flags |= 0x8 // we will write a data descriptor
+
+		fw = &fileWriter{
+			zipw:      w.cw,
+			compCount: &countWriter{w: w.cw},
+			crc32:     crc32.NewIEEE(),
+		}
+		comp := w.compressor(fh.Method)
+		if comp == nil {
+			return nil, ErrAlgorithm
+		}
+		var err error
+		fw.comp, err = comp(fw.compCount)
+		if err != nil {
+			return nil, err
+		}
+		fw.rawCount = &countWriter{w: fw.comp}
+		fw.header = h
+		ow = fw
+	}
+	w.dir = append(w.dir, h)
+	if err := writeHeader(w.cw, h); err != nil {
+		return nil, err
+	}
+	// If we're creating a directory, fw is nil.
+	w.last = fw
+	return ow, nil
+}
+
+// initHeader sets the flags, versions and extended timestamp of fh
+// the same way for CreateHeader and CreateCompressed.
+func initHeader(fh *FileHeader) {
 	// The ZIP format has a sad state of affairs regarding character encoding.
 	// Officially, the name and comment fields are supposed to be encoded
 	// in CP-437 (which is mostly compatible with ASCII), unless the UTF-8
@@ -329,58 +424,20 @@ func (w *Writer) CreateHeader(fh *FileHeader) (io.Writer, error) {
 		fh.Extra = append(fh.Extra, mbuf[:]...)
 	}
 
-	var (
-		ow io.Writer
-		fw *fileWriter
-	)
-	h := &header{
-		FileHeader: fh,
-		offset:     uint64(w.cw.count),
-	}
-
-	if strings.HasSuffix(fh.Name, "/") {
-		// Set the compression method to Store to ensure data length is truly zero,
-		// which the writeHeader method always encodes for the size fields.
-		// This is necessary as most compression formats have non-zero lengths
-		// even when compressing an empty string.
-		fh.Method = Store
-		fh.Flags &^= 0x8 // we will not write a data descriptor
-
-		// Explicitly clear sizes as they have no meaning for directories.
-		fh.CompressedSize = 0
-		fh.CompressedSize64 = 0
-		fh.UncompressedSize = 0
-		fh.UncompressedSize64 = 0
-
-		ow = dirWriter{}
-	} else {
-		fh.Flags |= 0x8 // we will write a data descriptor
-
-		fw = &fileWriter{
-			zipw:      w.cw,
-			compCount: &countWriter{w: w.cw},
-			crc32:     crc32.NewIEEE(),
-		}
-		comp := w.compressor(fh.Method)
-		if comp == nil {
-			return nil, ErrAlgorithm
-		}
-		var err error
-		fw.comp, err = comp(fw.compCount)
-		if err != nil {
-			return nil, err
-		}
-		fw.rawCount = &countWriter{w: fw.comp}
-		fw.header = h
-		ow = fw
+	// Use "Info-ZIP New Unix" format for the owner, it is identical
+	// for both local and central header.
+	if fh.HasOwner && !hasExtra(fh.Extra, newUnixExtraID) {
+		var obuf [15]byte // 2*SizeOf(uint16) + 3*SizeOf(uint8) + 2*SizeOf(uint32)
+		eb := writeBuf(obuf[:])
+		eb.uint16(newUnixExtraID)
+		eb.uint16(11) // Size: 3*SizeOf(uint8) + 2*SizeOf(uint32)
+		eb.uint8(1)   // Version
+		eb.uint8(4)   // UID size
+		eb.uint32(uint32(fh.Uid))
+		eb.uint8(4) // GID size
+		eb.uint32(uint32(fh.Gid))
+		fh.Extra = append(fh.Extra, obuf[:]...)
 	}
-	w.dir = append(w.dir, h)
-	if err := writeHeader(w.cw, h); err != nil {
-		return nil, err
-	}
-	// If we're creating a directory, fw is nil.
-	w.last = fw
-	return ow, nil
 }
 
 func writeHeader(w io.Writer, h *header) error {
@@ -470,14 +527,71 @@ func (w *Writer) CreateRaw(fh *FileHeader) (io.Writer, error) {
 	return fw, nil
 }
 
+// CreateCompressed adds a file whose contents were already compressed with
+// fh.Method, and returns a Writer to which the compressed bytes should be
+// written. The CRC32, CompressedSize64 and UncompressedSize64 fields of fh
+// must describe the contents. The file's contents must be written to the
+// io.Writer before the next call to Create, CreateHeader, CreateRaw,
+// CreateCompressed, or Close.
+//
+// In contrast to CreateRaw, the headers are written exactly as CreateHeader
+// would write them, so a file compressed elsewhere (e.g. by another goroutine)
+// produces the same bytes as one compressed by CreateHeader.
+func (w *Writer) CreateCompressed(fh *FileHeader) (io.Writer, error) {
+	if strings.HasSuffix(fh.Name, "/") {
+		return w.CreateHeader(fh)
+	}
+	if err := w.prepare(fh); err != nil {
+		return nil, err
+	}
+
+	initHeader(fh)
+	fh.Flags |= 0x8 // we will write a data descriptor
+
+	h := &header{
+		FileHeader: fh,
+		offset:     uint64(w.cw.count),
+		raw:        true,
+	}
+	w.dir = append(w.dir, h)
+	if err := writeHeader(w.cw, h); err != nil {
+		return nil, err
+	}
+
+	// same as fileWriter.close, after the local header was written.
+	if fh.isZip64() {
+		fh.CompressedSize = uint32max
+		fh.UncompressedSize = uint32max
+		fh.ReaderVersion = zipVersion45 // requires 4.5 - File uses ZIP64 format extensions
+	} else {
+		fh.CompressedSize = uint32(fh.CompressedSize64)
+		fh.UncompressedSize = uint32(fh.UncompressedSize64)
+	}
+
+	fw := &fileWriter{
+		header: h,
+		zipw:   w.cw,
+	}
+	w.last = fw
+	return fw, nil
+}
+
 // Copy copies the file f (obtained from a Reader) into w. It copies the raw
 // form directly bypassing decompression, compression, and validation.
 func (w *Writer) Copy(f *File) error {
+	return w.CopyAs(f, f.Name)
+}
+
+// CopyAs is like Copy, but the file is stored as name.
+func (w *Writer) CopyAs(f *File, name string) error {
 	r, err := f.OpenRaw()
 	if err != nil {
 		return err
 	}
-	fw, err := w.CreateRaw(&f.FileHeader)
+	fh := f.FileHeader
+	fh.Name = name
+	fh.Extra = stripZip64Extra(fh.Extra)
+	fw, err := w.CreateRaw(&fh)
 	if err != nil {
 		return err
 	}
@@ -485,6 +599,67 @@ func (w *Writer) Copy(f *File) error {
 	return err
 }
 
+// Keep adds the file f to the central directory without writing its data.
+// It is used to append files to the archive f was read from, when w writes
+// to the same file at the offset of its central directory (see SetOffset).
+func (w *Writer) Keep(f *File) error {
+	if w.last != nil && !w.last.closed {
+		if err := w.last.close(); err != nil {
+			return err
+		}
+	}
+	w.last = nil
+	fh := f.FileHeader
+	fh.Extra = stripZip64Extra(fh.Extra)
+	w.dir = append(w.dir, &header{
+		FileHeader: &fh,
+		offset:     uint64(f.headerOffset),
+		raw:        true,
+	})
+	return nil
+}
+
+// hasExtra reports whether extra contains the field tag.
+func hasExtra(extra []byte, tag uint16) bool {
+	for b := readBuf(extra); len(b) >= 4; {
+		fieldTag := b.uint16()
+		size := int(b.uint16())
+		if len(b) < size {
+			return false
+		}
+		if fieldTag == tag {
+			return true
+		}
+		b = b[size:]
+	}
+	return false
+}
+
+// stripZip64Extra returns extra without the zip64 field,
+// which Close appends again when it is needed.
+func stripZip64Extra(extra []byte) []byte {
+	var out []byte
+	for b := readBuf(extra); len(b) >= 4; {
+		tag := b.uint16()
+		size := int(b.uint16())
+		if len(b) < size {
+			break
+		}
+		field := extra[len(extra)-len(b)-4 : len(extra)-len(b)+size]
+		b = b[size:]
+		if tag != zip64ExtraID {
+			out = append(out, field...)
+		}
+	}
+	return out
+}
+
+// Compressor returns the compressor used by w for method, or nil if the
+// method is unknown. It is safe to call the result from multiple goroutines.
+func (w *Writer) Compressor(method uint16) Compressor {
+	return w.compressor(method)
+}
+
 // RegisterCompressor registers or overrides a custom compressor for a specific
 // method ID. If a compressor for a given method is not found, Writer will
 // default to looking up the compressor at the package level.
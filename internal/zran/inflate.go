@@ -0,0 +1,492 @@
+// Package zran
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package zran
+
+import (
+	"bufio"
+	"errors"
+	"io"
+)
+
+const (
+	maxBits    = 15
+	maxCodes   = 288
+	windowSize = 1 << 15
+	fastBits   = 9
+)
+
+var errCorrupt = errors.New("zran: corrupt deflate stream")
+
+var (
+	lenBase   = [29]uint16{3, 4, 5, 6, 7, 8, 9, 10, 11, 13, 15, 17, 19, 23, 27, 31, 35, 43, 51, 59, 67, 83, 99, 115, 131, 163, 195, 227, 258}
+	lenExtra  = [29]uint8{0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 1, 1, 2, 2, 2, 2, 3, 3, 3, 3, 4, 4, 4, 4, 5, 5, 5, 5, 0}
+	distBase  = [30]uint16{1, 2, 3, 4, 5, 7, 9, 13, 17, 25, 33, 49, 65, 97, 129, 193, 257, 385, 513, 769, 1025, 1537, 2049, 3073, 4097, 6145, 8193, 12289, 16385, 24577}
+	distExtra = [30]uint8{0, 0, 0, 0, 1, 1, 2, 2, 3, 3, 4, 4, 5, 5, 6, 6, 7, 7, 8, 8, 9, 9, 10, 10, 11, 11, 12, 12, 13, 13}
+	clOrder   = [19]uint8{16, 17, 18, 0, 8, 7, 9, 6, 10, 5, 11, 4, 12, 3, 13, 2, 14, 1, 15}
+
+	fixedLit, fixedDist = fixedHuffman()
+)
+
+// huffman is a canonical huffman code, decoded by a lookup table for
+// the codes up to fastBits, and bit by bit for the longer codes.
+type huffman struct {
+	count  [maxBits + 1]uint16
+	symbol [maxCodes]uint16
+	fast   [1 << fastBits]uint16 // symbol<<4 | length, 0 if the code is longer
+}
+
+func (h *huffman) init(lengths []uint8) error {
+	h.count = [maxBits + 1]uint16{}
+	for _, l := range lengths {
+		h.count[l]++
+	}
+	left := 1
+	for l := 1; l <= maxBits; l++ {
+		left <<= 1
+		if left -= int(h.count[l]); left < 0 {
+			return errCorrupt // over-subscribed
+		}
+	}
+
+	var offs [maxBits + 1]uint16
+	for l := 1; l < maxBits; l++ {
+		offs[l+1] = offs[l] + h.count[l]
+	}
+	for sym, l := range lengths {
+		if l != 0 {
+			h.symbol[offs[l]] = uint16(sym)
+			offs[l]++
+		}
+	}
+
+	var next [maxBits + 1]int
+	code := 0
+	for l := 1; l <= maxBits; l++ {
+		if l > 1 {
+			code = (code + int(h.count[l-1])) << 1
+		}
+		next[l] = code
+	}
+	h.fast = [1 << fastBits]uint16{}
+	for sym, l := range lengths {
+		if l == 0 {
+			continue
+		}
+		c := next[l]
+		next[l]++
+		if l > fastBits {
+			continue
+		}
+		rev := 0
+		for i := 0; i < int(l); i++ {
+			rev |= (c >> i & 1) << (int(l) - 1 - i)
+		}
+		for i := rev; i < 1<<fastBits; i += 1 << l {
+			h.fast[i] = uint16(sym)<<4 | uint16(l)
+		}
+	}
+	return nil
+}
+
+func fixedHuffman() (*huffman, *huffman) {
+	var lengths [maxCodes]uint8
+	for i := range lengths {
+		switch {
+		case i < 144:
+			lengths[i] = 8
+		case i < 256:
+			lengths[i] = 9
+		case i < 280:
+			lengths[i] = 7
+		default:
+			lengths[i] = 8
+		}
+	}
+	lit := &huffman{}
+	_ = lit.init(lengths[:])
+	for i := 0; i < 30; i++ {
+		lengths[i] = 5
+	}
+	dist := &huffman{}
+	_ = dist.init(lengths[:30])
+	return lit, dist
+}
+
+const (
+	stateHeader = iota
+	stateStored
+	stateCodes
+	stateDone
+)
+
+// inflater decompress a deflate stream, it can be started at any block boundary
+// with the window before it.
+type inflater struct {
+	r     io.ByteReader
+	in    int64 // bytes read from r
+	bits  uint64
+	nbits uint
+	eof   bool
+
+	hist []byte // the window and the unread output
+	rd   int    // read offset in hist
+	out  int64  // total output
+
+	state     int
+	final     bool
+	stored    int // bytes left in the stored block
+	copyLen   int
+	copyDist  int
+	lit, dist *huffman
+	dyn       [2]huffman
+}
+
+func newInflater(r io.Reader) *inflater {
+	br, ok := r.(io.ByteReader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	return &inflater{r: br, hist: make([]byte, 0, 4*windowSize)}
+}
+
+// pos returns the bit offset of the next bit in the stream.
+func (d *inflater) pos() int64 { return d.in*8 - int64(d.nbits) }
+
+// window returns a copy of the last 32 KiB of the output.
+func (d *inflater) window() []byte {
+	w := d.hist
+	if len(w) > windowSize {
+		w = w[len(w)-windowSize:]
+	}
+	return append([]byte(nil), w...)
+}
+
+// fill buffer at least n bits unless the stream ends.
+func (d *inflater) fill(n uint) error {
+	for d.nbits < n && !d.eof {
+		c, err := d.r.ReadByte()
+		if err != nil {
+			if err == io.EOF {
+				d.eof = true
+				return nil
+			}
+			return err
+		}
+		d.in++
+		d.bits |= uint64(c) << d.nbits
+		d.nbits += 8
+	}
+	return nil
+}
+
+func (d *inflater) read(n uint) (int, error) {
+	if err := d.fill(n); err != nil {
+		return 0, err
+	}
+	if d.nbits < n {
+		return 0, io.ErrUnexpectedEOF
+	}
+	v := int(d.bits & (1<<n - 1))
+	d.bits >>= n
+	d.nbits -= n
+	return v, nil
+}
+
+func (d *inflater) decode(h *huffman) (int, error) {
+	if err := d.fill(maxBits); err != nil {
+		return 0, err
+	}
+	if e := h.fast[d.bits&(1<<fastBits-1)]; e != 0 && uint(e&15) <= d.nbits {
+		d.bits >>= e & 15
+		d.nbits -= uint(e & 15)
+		return int(e >> 4), nil
+	}
+	code, first, index := 0, 0, 0
+	for l := uint(1); l <= maxBits; l++ {
+		if l > d.nbits {
+			return 0, io.ErrUnexpectedEOF
+		}
+		code |= int(d.bits>>(l-1)) & 1
+		count := int(h.count[l])
+		if code-first < count {
+			d.bits >>= l
+			d.nbits -= l
+			return int(h.symbol[index+code-first]), nil
+		}
+		index += count
+		first = (first + count) << 1
+		code <<= 1
+	}
+	return 0, errCorrupt
+}
+
+// grow makes room for n bytes of output, dropping the read output before the window.
+func (d *inflater) grow(n int) {
+	if len(d.hist)+n <= cap(d.hist) {
+		return
+	}
+	drop := len(d.hist) - windowSize
+	if d.rd < drop {
+		drop = d.rd
+	}
+	if drop > 0 {
+		m := copy(d.hist, d.hist[drop:])
+		d.hist = d.hist[:m]
+		d.rd -= drop
+	}
+}
+
+func (d *inflater) emit(b byte) {
+	d.grow(1)
+	d.hist = append(d.hist, b)
+	d.out++
+}
+
+// match copy the pending match to the output.
+func (d *inflater) match() {
+	n := d.copyLen
+	d.grow(n)
+	start := len(d.hist) - d.copyDist
+	if d.copyDist >= n {
+		d.hist = append(d.hist, d.hist[start:start+n]...)
+	} else {
+		for i := 0; i < n; i++ {
+			d.hist = append(d.hist, d.hist[start+i])
+		}
+	}
+	d.out += int64(n)
+	d.copyLen = 0
+}
+
+// step decompress until the output has at least n unread bytes or a block ends,
+// it returns true at the block boundary.
+func (d *inflater) step(n int) (bool, error) {
+	for len(d.hist)-d.rd < n {
+		switch d.state {
+		case stateHeader:
+			if d.final {
+				d.state = stateDone
+				continue
+			}
+			if err := d.header(); err != nil {
+				return false, err
+			}
+		case stateStored:
+			if d.stored == 0 {
+				d.state = stateHeader
+				return true, nil
+			}
+			c, err := d.read(8)
+			if err != nil {
+				return false, err
+			}
+			d.emit(byte(c))
+			d.stored--
+		case stateCodes:
+			if d.copyLen > 0 {
+				d.match()
+				continue
+			}
+			end, err := d.codes(n)
+			if err != nil {
+				return false, err
+			}
+			if end {
+				d.state = stateHeader
+				return true, nil
+			}
+		default:
+			return false, io.EOF
+		}
+	}
+	return false, nil
+}
+
+func (d *inflater) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	// limit the unread output to keep the window sliding
+	want := len(p)
+	if want > windowSize {
+		want = windowSize
+	}
+	for d.rd == len(d.hist) {
+		if _, err := d.step(want); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, d.hist[d.rd:])
+	d.rd += n
+	return n, nil
+}
+
+func (d *inflater) header() error {
+	v, err := d.read(3)
+	if err != nil {
+		return err
+	}
+	d.final = v&1 != 0
+	switch v >> 1 {
+	case 0:
+		d.bits >>= d.nbits % 8
+		d.nbits -= d.nbits % 8
+		n, err := d.read(16)
+		if err != nil {
+			return err
+		}
+		nc, err := d.read(16)
+		if err != nil {
+			return err
+		}
+		if n != ^nc&0xffff {
+			return errCorrupt
+		}
+		d.stored, d.state = n, stateStored
+	case 1:
+		d.lit, d.dist, d.state = fixedLit, fixedDist, stateCodes
+	case 2:
+		if err = d.dynamic(); err != nil {
+			return err
+		}
+		d.lit, d.dist, d.state = &d.dyn[0], &d.dyn[1], stateCodes
+	default:
+		return errCorrupt
+	}
+	return nil
+}
+
+func (d *inflater) dynamic() error {
+	var lengths [maxCodes + 32]uint8
+	hlit, err := d.read(5)
+	if err != nil {
+		return err
+	}
+	hdist, err := d.read(5)
+	if err != nil {
+		return err
+	}
+	hclen, err := d.read(4)
+	if err != nil {
+		return err
+	}
+	hlit, hdist, hclen = hlit+257, hdist+1, hclen+4
+	if hlit > 286 || hdist > 30 {
+		return errCorrupt
+	}
+	for i := 0; i < hclen; i++ {
+		l, err := d.read(3)
+		if err != nil {
+			return err
+		}
+		lengths[clOrder[i]] = uint8(l)
+	}
+	cl := &d.dyn[0]
+	if err = cl.init(lengths[:19]); err != nil {
+		return err
+	}
+
+	lengths = [maxCodes + 32]uint8{}
+	for i := 0; i < hlit+hdist; {
+		sym, err := d.decode(cl)
+		if err != nil {
+			return err
+		}
+		if sym < 16 {
+			lengths[i] = uint8(sym)
+			i++
+			continue
+		}
+		var l uint8
+		var rep int
+		switch sym {
+		case 16:
+			if i == 0 {
+				return errCorrupt
+			}
+			l = lengths[i-1]
+			rep, err = d.read(2)
+			rep += 3
+		case 17:
+			rep, err = d.read(3)
+			rep += 3
+		default:
+			rep, err = d.read(7)
+			rep += 11
+		}
+		if err != nil {
+			return err
+		}
+		if i+rep > hlit+hdist {
+			return errCorrupt
+		}
+		for ; rep > 0; rep-- {
+			lengths[i] = l
+			i++
+		}
+	}
+	if lengths[256] == 0 {
+		return errCorrupt
+	}
+	if err = d.dyn[0].init(lengths[:hlit]); err != nil {
+		return err
+	}
+	return d.dyn[1].init(lengths[hlit : hlit+hdist])
+}
+
+// codes decode the literals until the output has n unread bytes or a match is found,
+// it returns true at the end of block.
+func (d *inflater) codes(n int) (bool, error) {
+	sym, err := d.decode(d.lit)
+	for err == nil && sym < 256 {
+		d.emit(byte(sym))
+		if len(d.hist)-d.rd >= n {
+			return false, nil
+		}
+		sym, err = d.decode(d.lit)
+	}
+	if err != nil {
+		return false, err
+	}
+	if sym == 256 {
+		return true, nil
+	}
+	if sym -= 257; sym >= len(lenBase) {
+		return false, errCorrupt
+	}
+	extra, err := d.read(uint(lenExtra[sym]))
+	if err != nil {
+		return false, err
+	}
+	length := int(lenBase[sym]) + extra
+	if sym, err = d.decode(d.dist); err != nil {
+		return false, err
+	}
+	if sym >= len(distBase) {
+		return false, errCorrupt
+	}
+	if extra, err = d.read(uint(distExtra[sym])); err != nil {
+		return false, err
+	}
+	distance := int(distBase[sym]) + extra
+	if distance > len(d.hist) {
+		return false, errCorrupt
+	}
+	d.copyLen, d.copyDist = length, distance
+	return false, nil
+}
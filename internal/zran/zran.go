@@ -0,0 +1,186 @@
+// Package zran
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package zran
+
+import (
+	"errors"
+	"io"
+	"sort"
+)
+
+// DefaultSpan is the default distance of the access points in the uncompressed data,
+// each point keeps a 32 KiB window.
+const DefaultSpan = 1 << 20
+
+var errNegative = errors.New("zran: negative position")
+
+// Point is an access point at a deflate block boundary.
+type Point struct {
+	In     int64  // bit offset of the block in the compressed data
+	Out    int64  // offset of the block in the uncompressed data
+	Window []byte // the uncompressed data (up to 32 KiB) before Out
+}
+
+// Index is the access points of a deflate stream, to random access the uncompressed
+// data without decompressing from the start (same as zlib examples/zran.c).
+type Index struct {
+	Points []Point
+	Size   int64 // the uncompressed size
+}
+
+// BuildIndex decompress the deflate stream r, adding an access point about every span bytes.
+func BuildIndex(r io.Reader, span int64) (*Index, error) {
+	if span <= 0 {
+		span = DefaultSpan
+	}
+	d := newInflater(r)
+	idx := &Index{Points: []Point{{}}}
+	last := int64(0)
+	for {
+		// nothing is read from the output, only the window is kept
+		d.rd = len(d.hist)
+		boundary, err := d.step(windowSize)
+		if err != nil {
+			if err == io.EOF && d.state == stateDone {
+				break
+			}
+			return nil, unexpected(err)
+		}
+		if boundary && !d.final && d.out-last >= span {
+			idx.Points = append(idx.Points, Point{In: d.pos(), Out: d.out, Window: d.window()})
+			last = d.out
+		}
+	}
+	idx.Size = d.out
+	return idx, nil
+}
+
+// point returns the last access point before off.
+func (idx *Index) point(off int64) *Point {
+	i := sort.Search(len(idx.Points), func(i int) bool { return idx.Points[i].Out > off })
+	return &idx.Points[i-1]
+}
+
+// Reader is the io.ReadSeeker and io.ReaderAt of the uncompressed data,
+// ReadAt is safe to call concurrently.
+type Reader struct {
+	r    io.ReaderAt
+	size int64
+	idx  *Index
+
+	off    int64
+	dec    *inflater
+	decOff int64
+}
+
+// NewReader returns the Reader of the deflate stream r of size bytes, indexed by idx.
+func NewReader(r io.ReaderAt, size int64, idx *Index) *Reader {
+	return &Reader{r: r, size: size, idx: idx}
+}
+
+// Size returns the uncompressed size.
+func (z *Reader) Size() int64 { return z.idx.Size }
+
+// open returns a decompressor started at the last access point before off,
+// and the offset of the access point.
+func (z *Reader) open(off int64) (*inflater, int64, error) {
+	p := z.idx.point(off)
+	d := newInflater(io.NewSectionReader(z.r, p.In/8, z.size-p.In/8))
+	d.hist = append(d.hist, p.Window...)
+	d.rd = len(d.hist)
+	d.out = p.Out
+	if _, err := d.read(uint(p.In % 8)); err != nil {
+		return nil, 0, err
+	}
+	return d, p.Out, nil
+}
+
+func (z *Reader) Read(p []byte) (int, error) {
+	if z.off >= z.idx.Size {
+		return 0, io.EOF
+	}
+	// reuse the decompressor unless an access point is nearer
+	if z.dec == nil || z.decOff > z.off || z.idx.point(z.off).Out > z.decOff {
+		dec, pos, err := z.open(z.off)
+		if err != nil {
+			return 0, err
+		}
+		z.dec, z.decOff = dec, pos
+	}
+	if z.decOff < z.off {
+		n, err := io.CopyN(io.Discard, z.dec, z.off-z.decOff)
+		z.decOff += n
+		if err != nil {
+			return 0, unexpected(err)
+		}
+	}
+	n, err := z.dec.Read(p)
+	z.decOff += int64(n)
+	z.off = z.decOff
+	if err == io.EOF && z.off < z.idx.Size {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+func (z *Reader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += z.off
+	case io.SeekEnd:
+		offset += z.idx.Size
+	}
+	if offset < 0 {
+		return 0, errNegative
+	}
+	z.off = offset
+	return offset, nil
+}
+
+func (z *Reader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errNegative
+	}
+	if off >= z.idx.Size {
+		return 0, io.EOF
+	}
+	dec, pos, err := z.open(off)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := io.CopyN(io.Discard, dec, off-pos); err != nil {
+		return 0, unexpected(err)
+	}
+	n, err := io.ReadFull(dec, p)
+	if err == io.ErrUnexpectedEOF && off+int64(n) == z.idx.Size {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func (z *Reader) Close() error {
+	z.dec = nil
+	return nil
+}
+
+func unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
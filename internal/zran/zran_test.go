@@ -0,0 +1,145 @@
+// Package zran
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package zran
+
+import (
+	"bytes"
+	"compress/flate"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+// testData returns n bytes mixing random and repeated data, so the deflate stream
+// has stored, fixed and dynamic blocks with long distance matches.
+func testData(n int) []byte {
+	rnd := rand.New(rand.NewSource(1))
+	data := make([]byte, 0, n)
+	for len(data) < n {
+		switch rnd.Intn(3) {
+		case 0:
+			chunk := make([]byte, rnd.Intn(4096))
+			rnd.Read(chunk)
+			data = append(data, chunk...)
+		case 1:
+			data = append(data, bytes.Repeat([]byte("lorem ipsum "), rnd.Intn(512))...)
+		default:
+			if len(data) > 0 {
+				off := rnd.Intn(len(data))
+				end := off + rnd.Intn(300)
+				if end > len(data) {
+					end = len(data)
+				}
+				data = append(data, data[off:end]...)
+			}
+		}
+	}
+	return data[:n]
+}
+
+func deflate(t *testing.T, data []byte, level int) []byte {
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, level)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// flush in the middle to emit the empty stored blocks
+	w.Write(data[:len(data)/2])
+	w.Flush()
+	w.Write(data[len(data)/2:])
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+var levels = []int{flate.NoCompression, flate.BestSpeed, flate.DefaultCompression, flate.BestCompression, flate.HuffmanOnly}
+
+func TestInflate(t *testing.T) {
+	for _, n := range []int{0, 1, 1 << 10, 300 << 10} {
+		data := testData(n)
+		for _, level := range levels {
+			got, err := io.ReadAll(newInflater(bytes.NewReader(deflate(t, data, level))))
+			if err != nil {
+				t.Fatalf("size %d level %d: %v", n, level, err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Fatalf("size %d level %d: output differs", n, level)
+			}
+		}
+	}
+}
+
+func TestInflateCorrupt(t *testing.T) {
+	stream := deflate(t, testData(64<<10), flate.DefaultCompression)
+	if _, err := io.ReadAll(newInflater(bytes.NewReader(stream[:len(stream)/2]))); err == nil {
+		t.Fatal("truncated stream: no error")
+	}
+	if _, err := io.ReadAll(newInflater(bytes.NewReader([]byte{0xff, 0xff, 0xff}))); err == nil {
+		t.Fatal("invalid block type: no error")
+	}
+}
+
+func TestReader(t *testing.T) {
+	data := testData(1 << 20)
+	for _, level := range levels {
+		stream := deflate(t, data, level)
+		idx, err := BuildIndex(bytes.NewReader(stream), 64<<10)
+		if err != nil {
+			t.Fatalf("level %d: %v", level, err)
+		}
+		if idx.Size != int64(len(data)) {
+			t.Fatalf("level %d: size %d, want %d", level, idx.Size, len(data))
+		}
+		if level != flate.NoCompression && len(idx.Points) < 2 {
+			t.Fatalf("level %d: %d access points", level, len(idx.Points))
+		}
+
+		z := NewReader(bytes.NewReader(stream), int64(len(stream)), idx)
+		rnd := rand.New(rand.NewSource(int64(level) + 2))
+		for i := 0; i < 50; i++ {
+			off := rnd.Int63n(int64(len(data)))
+			buf := make([]byte, rnd.Intn(100<<10))
+			n, err := z.ReadAt(buf, off)
+			want := data[off:]
+			if len(want) > len(buf) {
+				want = want[:len(buf)]
+			} else if err != io.EOF {
+				t.Fatalf("level %d: ReadAt(%d) at the end: %v", level, off, err)
+			}
+			if n != len(want) || !bytes.Equal(buf[:n], want) {
+				t.Fatalf("level %d: ReadAt(%d) differs", level, off)
+			}
+		}
+
+		if _, err := z.Seek(int64(len(data))-1000, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		tail, err := io.ReadAll(z)
+		if err != nil || !bytes.Equal(tail, data[len(data)-1000:]) {
+			t.Fatalf("level %d: read after seek differs: %v", level, err)
+		}
+		if _, err := z.Seek(0, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		all, err := io.ReadAll(z)
+		if err != nil || !bytes.Equal(all, data) {
+			t.Fatalf("level %d: read from the start differs: %v", level, err)
+		}
+	}
+}
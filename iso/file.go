@@ -0,0 +1,173 @@
+// Package iso
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package iso
+
+import (
+	"io"
+	"io/fs"
+	"path"
+	"time"
+
+	"github.com/pashifika/compress"
+)
+
+// Header is the Sys() of the image entries, it implements compress.Owner with Rock Ridge.
+type Header struct {
+	Name    string // the name of the directory record
+	Extent  uint32 // the first sector of the data
+	ModTime time.Time
+
+	// Rock Ridge, Mode is 0 if the image has no Rock Ridge
+	Mode  uint32 // the Unix mode of the entry
+	Nlink uint32
+	Uid   int
+	Gid   int
+}
+
+func (h *Header) Owner() (uid, gid int, ok bool) { return h.Uid, h.Gid, h.Mode != 0 }
+
+type File struct {
+	header *Header // nil for the root
+	name   string
+	isDir  bool
+	size   int64
+	mode   fs.FileMode
+	link   string // the symbolic link target
+
+	data *io.SectionReader // the file data, or the link target
+	sr   *io.SectionReader // the opened reader
+
+	dirReadAt  int
+	dirEntries func(path string, n int) ([]fs.DirEntry, error)
+}
+
+func (f *File) Root() string {
+	if f.isDir {
+		return f.name + "/"
+	}
+	return f.name
+}
+
+func (f *File) IsDir() bool { return f.isDir }
+
+func (f *File) Size() int64 { return f.size }
+
+func (f *File) Write(_ []byte) (n int, err error) {
+	return 0, compress.ErrWriterNotSupport
+}
+
+func (f *File) OpenFile() error {
+	f.sr = io.NewSectionReader(f.data, 0, f.size)
+	return nil
+}
+
+// ------ to fs.FileInfo ------
+
+func (f *File) Mode() fs.FileMode { return f.mode }
+
+func (f *File) ModTime() time.Time {
+	if f.header == nil {
+		return time.Time{}
+	}
+	return f.header.ModTime
+}
+
+func (f *File) Sys() interface{} {
+	if f.header == nil {
+		return nil
+	}
+	return f.header
+}
+
+// ------ to fs.File ------
+
+func (f *File) Stat() (fs.FileInfo, error) { return f, nil }
+
+func (f *File) Read(b []byte) (int, error) {
+	if f.sr == nil {
+		return 0, fs.ErrClosed
+	}
+	return f.sr.Read(b)
+}
+
+// Seek implements io.Seeker, the files are stored as is.
+func (f *File) Seek(offset int64, whence int) (int64, error) {
+	if f.sr == nil {
+		return 0, fs.ErrClosed
+	}
+	return f.sr.Seek(offset, whence)
+}
+
+// ReadAt implements io.ReaderAt, it does not depend on the file being opened.
+func (f *File) ReadAt(b []byte, off int64) (int, error) {
+	if f.data == nil {
+		return 0, fs.ErrInvalid
+	}
+	return f.data.ReadAt(b, off)
+}
+
+func (f *File) Close() error {
+	f.sr = nil
+	return nil
+}
+
+// ------ to fs.DirEntry ------
+
+func (f *File) Name() string { return path.Base(f.name) }
+
+func (f *File) Type() fs.FileMode { return f.mode.Type() }
+
+func (f *File) Info() (fs.FileInfo, error) { return f, nil }
+
+func (f *File) ReadDir(n int) ([]fs.DirEntry, error) {
+	if f.dirEntries != nil {
+		return f.dirEntries(f.name, n)
+	}
+	return nil, fs.ErrNotExist
+}
+
+// extents is the data of a file recorded in several extents.
+type extents []*io.SectionReader
+
+func (e extents) ReadAt(p []byte, off int64) (int, error) {
+	n := 0
+	for _, s := range e {
+		if len(p) == 0 {
+			break
+		}
+		if off >= s.Size() {
+			off -= s.Size()
+			continue
+		}
+		m, err := s.ReadAt(p, off)
+		if err == io.EOF && off+int64(m) < s.Size() {
+			// the extent is past the end of the image
+			err = io.ErrUnexpectedEOF
+		}
+		n += m
+		p = p[m:]
+		off = 0
+		if err != nil && err != io.EOF {
+			return n, err
+		}
+	}
+	if len(p) > 0 {
+		return n, io.EOF
+	}
+	return n, nil
+}
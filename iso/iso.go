@@ -0,0 +1,483 @@
+// Package iso
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package iso
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"unicode/utf16"
+
+	"golang.org/x/text/encoding"
+
+	"github.com/pashifika/compress"
+)
+
+const (
+	maxDepth   = 64
+	maxDirSize = 64 << 20
+)
+
+var (
+	errNotISO = errors.New("iso: not an ISO 9660 image")
+	errLoop   = errors.New("iso: directory loop")
+)
+
+// ReadCloser is the ISO 9660 image, the names are read from Rock Ridge if the image
+// has it, or from the Joliet tree, or from the primary volume descriptor.
+type ReadCloser struct {
+	file      *os.File
+	volumeID  string
+	joliet    bool
+	rockRidge bool
+	entries   map[string]*compress.DirIndex
+	dirs      map[string]int
+	files     map[string]int
+	index     []*File
+
+	root fs.FileInfo
+}
+
+func (rc *ReadCloser) Name() string { return "iso" }
+
+func (rc *ReadCloser) SetRootInfo(info os.FileInfo) { rc.root = info }
+
+func (rc *ReadCloser) SetCharset(_ []encoding.Encoding, _ bool) {}
+
+// VolumeID returns the volume identifier of the image.
+func (rc *ReadCloser) VolumeID() string { return rc.volumeID }
+
+// Joliet reports whether the names are read from the Joliet tree.
+func (rc *ReadCloser) Joliet() bool { return rc.joliet }
+
+// RockRidge reports whether the names, modes and links are read from Rock Ridge.
+func (rc *ReadCloser) RockRidge() bool { return rc.rockRidge }
+
+func (rc *ReadCloser) OpenReader(path string) (fs.FS, error) {
+	return rc.OpenReaderWithPassword(path, "")
+}
+
+// OpenReaderWithPassword is the same as OpenReader, ISO 9660 has no password.
+func (rc *ReadCloser) OpenReaderWithPassword(path, _ string) (fs.FS, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	res := &ReadCloser{
+		file: f,
+		entries: map[string]*compress.DirIndex{
+			compress.DefaultArchiverRoot: compress.NewDirEntries(),
+		},
+		dirs:  map[string]int{},
+		files: map[string]int{},
+		index: []*File{},
+		root:  rc.root,
+	}
+	if res.root == nil {
+		res.root = info
+	}
+	if err = res.scan(); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return res, nil
+}
+
+// scan reads the volume descriptors and the directories to build the index.
+func (rc *ReadCloser) scan() error {
+	var pvd, svd []byte
+	for sector := int64(16); ; sector++ {
+		vd := make([]byte, sectorSize)
+		if _, err := rc.file.ReadAt(vd, sector*sectorSize); err != nil || string(vd[1:6]) != "CD001" {
+			break
+		}
+		switch vd[0] {
+		case 1:
+			if pvd == nil {
+				pvd = vd
+			}
+		case 2:
+			if esc := string(vd[88:91]); svd == nil && (esc == "%/@" || esc == "%/C" || esc == "%/E") {
+				svd = vd
+			}
+		}
+		if vd[0] == 255 || sector > 64 {
+			break
+		}
+	}
+	if pvd == nil {
+		return errNotISO
+	}
+
+	rootRec, err := parseRecord(pvd[156:190], false)
+	if err != nil {
+		return errNotISO
+	}
+	rc.volumeID = strings.TrimRight(string(pvd[40:72]), " ")
+	rc.rockRidge = rc.hasRockRidge(rootRec)
+	if !rc.rockRidge && svd != nil {
+		if rootRec, err = parseRecord(svd[156:190], true); err != nil {
+			return errNotISO
+		}
+		rc.joliet = true
+		rc.volumeID = jolietString(svd[40:72])
+	}
+
+	// Set root info
+	rootIdx := len(rc.index)
+	rc.dirs[compress.DefaultArchiverRoot] = rootIdx
+	rc.index = append(rc.index, &File{
+		name:       compress.DefaultArchiverRoot,
+		mode:       rc.root.Mode() + os.ModeDir,
+		isDir:      true,
+		dirEntries: rc.GetDirEntries,
+	})
+	return rc.walk(rootRec, compress.DefaultArchiverRoot, 0, map[uint32]bool{})
+}
+
+// hasRockRidge reports whether the "." record of the root has the SP entry.
+func (rc *ReadCloser) hasRockRidge(root *record) bool {
+	b := make([]byte, sectorSize)
+	if _, err := rc.file.ReadAt(b, int64(root.extent)*sectorSize); err != nil || b[0] < 34 {
+		return false
+	}
+	area := systemUse(b)
+	return len(area) >= 7 && string(area[:2]) == "SP" && area[4] == 0xbe && area[5] == 0xef
+}
+
+// jolietString decodes the UTF-16 identifier of the Joliet volume descriptor.
+func jolietString(b []byte) string {
+	u := make([]uint16, len(b)/2)
+	for i := range u {
+		u[i] = binary.BigEndian.Uint16(b[i*2:])
+	}
+	return strings.TrimRight(string(utf16.Decode(u)), " \x00")
+}
+
+// readDir returns the records of the directory dir, the "." and ".." are not included.
+func (rc *ReadCloser) readDir(dir *record) ([]*record, error) {
+	if dir.size > maxDirSize {
+		return nil, errRecord
+	}
+	buf := make([]byte, dir.size)
+	if _, err := rc.file.ReadAt(buf, int64(dir.extent)*sectorSize); err != nil {
+		return nil, err
+	}
+	var (
+		records []*record
+		skip    int
+	)
+	for off := 0; off < len(buf); {
+		l := int(buf[off])
+		if l == 0 {
+			// the records do not cross the sectors
+			off = (off/sectorSize + 1) * sectorSize
+			continue
+		}
+		if off+l > len(buf) {
+			return nil, errRecord
+		}
+		rec, err := parseRecord(buf[off:off+l], rc.joliet)
+		if err != nil {
+			return nil, err
+		}
+		if rc.rockRidge {
+			if area := systemUse(buf[off : off+l]); len(area) > skip {
+				if err = rec.parseSUSP(rc.file, area[skip:]); err != nil {
+					return nil, err
+				}
+			}
+			if rec.name == "." && off == 0 {
+				skip = rec.skip
+			}
+		}
+		off += l
+		if rec.name != "." && rec.name != ".." {
+			records = append(records, rec)
+		}
+	}
+	return records, nil
+}
+
+// walk adds the entries of the directory dir named name to the index.
+func (rc *ReadCloser) walk(dir *record, name string, depth int, visited map[uint32]bool) error {
+	if depth > maxDepth || visited[dir.extent] {
+		return &fs.PathError{Op: "readdir", Path: name, Err: errLoop}
+	}
+	visited[dir.extent] = true
+	records, err := rc.readDir(dir)
+	if err != nil {
+		return &fs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+
+	for i := 0; i < len(records); i++ {
+		rec := records[i]
+		if rec.relocate {
+			continue // it is listed by the CL record
+		}
+		// the extents of a multi-extent file are the following records
+		sections := extents{io.NewSectionReader(rc.file, int64(rec.extent)*sectorSize, int64(rec.size))}
+		size := int64(rec.size)
+		for rec.flags&flagMultiExtent != 0 && i+1 < len(records) {
+			i++
+			next := records[i]
+			sections = append(sections, io.NewSectionReader(rc.file, int64(next.extent)*sectorSize, int64(next.size)))
+			size += int64(next.size)
+			if next.flags&flagMultiExtent == 0 {
+				break
+			}
+		}
+
+		base := rec.name
+		if rec.rrName != "" {
+			base = rec.rrName
+		}
+		if base == "" || base == "." || base == ".." || strings.ContainsRune(base, '/') {
+			continue
+		}
+		entry := &File{
+			header: &Header{
+				Name:    rec.name,
+				Extent:  rec.extent,
+				ModTime: rec.modTime,
+				Mode:    rec.mode,
+				Nlink:   rec.nlink,
+				Uid:     int(rec.uid),
+				Gid:     int(rec.gid),
+			},
+			name: path.Join(name, base),
+		}
+		if !rec.rrTime.IsZero() {
+			entry.header.ModTime = rec.rrTime
+		}
+
+		isDir := rec.flags&flagDir != 0
+		if isDir && depth == 0 && rc.rockRidge && (base == "rr_moved" || base == ".rr_moved") && rc.relocated(rec) {
+			continue
+		}
+		if rec.child != 0 {
+			// the relocated directory
+			if rec, err = rc.dotRecord(rec.child); err != nil {
+				return &fs.PathError{Op: "readdir", Path: entry.name, Err: err}
+			}
+			isDir = true
+		}
+		switch {
+		case isDir:
+			entry.isDir = true
+			entry.mode = fs.ModeDir | 0555
+			entry.dirEntries = rc.GetDirEntries
+		case rec.hasLink:
+			entry.link = rec.link
+			entry.size = int64(len(rec.link))
+			entry.mode = fs.ModeSymlink | 0777
+			entry.data = io.NewSectionReader(strings.NewReader(rec.link), 0, entry.size)
+		default:
+			entry.size = size
+			entry.mode = 0444
+			entry.data = io.NewSectionReader(sections, 0, size)
+		}
+		if entry.header.Mode != 0 {
+			entry.mode = unixMode(entry.header.Mode)
+		}
+		rc.add(name, entry)
+		if entry.isDir {
+			if err = rc.walk(rec, entry.name, depth+1, visited); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// relocated reports whether all the entries of dir are relocated, it is the
+// directory of the deep directories which are listed by CL.
+func (rc *ReadCloser) relocated(dir *record) bool {
+	records, err := rc.readDir(dir)
+	if err != nil {
+		return false
+	}
+	for _, rec := range records {
+		if !rec.relocate {
+			return false
+		}
+	}
+	return true
+}
+
+// dotRecord returns the "." record of the directory at extent.
+func (rc *ReadCloser) dotRecord(extent uint32) (*record, error) {
+	b := make([]byte, sectorSize)
+	if _, err := rc.file.ReadAt(b, int64(extent)*sectorSize); err != nil {
+		return nil, err
+	}
+	return parseRecord(b, rc.joliet)
+}
+
+// add adds entry to the index and to the directory dir.
+func (rc *ReadCloser) add(dir string, entry *File) {
+	idx := len(rc.index)
+	rc.index = append(rc.index, entry)
+	if entry.isDir {
+		rc.dirs[entry.name] = idx
+		rc.entries[entry.name] = compress.NewDirEntries()
+	} else {
+		rc.files[entry.name] = idx
+	}
+	rc.entries[dir].Add(idx)
+}
+
+// unixMode returns the fs.FileMode of the Rock Ridge mode.
+func unixMode(m uint32) fs.FileMode {
+	mode := fs.FileMode(m & 0777)
+	switch m & 0170000 {
+	case 0040000:
+		mode |= fs.ModeDir
+	case 0120000:
+		mode |= fs.ModeSymlink
+	case 0140000:
+		mode |= fs.ModeSocket
+	case 0060000:
+		mode |= fs.ModeDevice
+	case 0020000:
+		mode |= fs.ModeDevice | fs.ModeCharDevice
+	case 0010000:
+		mode |= fs.ModeNamedPipe
+	}
+	if m&04000 != 0 {
+		mode |= fs.ModeSetuid
+	}
+	if m&02000 != 0 {
+		mode |= fs.ModeSetgid
+	}
+	if m&01000 != 0 {
+		mode |= fs.ModeSticky
+	}
+	return mode
+}
+
+// Open opens the named file in the image, using the semantics of fs.FS.Open:
+// paths are always slash separated, with no leading / or ../ elements.
+func (rc *ReadCloser) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	idx, ok := rc.dirs[name]
+	if ok {
+		return rc.getFile(idx)
+	}
+	idx, ok = rc.files[name]
+	if ok {
+		return rc.getFile(idx)
+	}
+	return nil, &fs.PathError{Op: "info", Path: name, Err: fs.ErrNotExist}
+}
+
+// Lstat returns the fs.FileInfo of the named file, the symbolic link is not followed.
+func (rc *ReadCloser) Lstat(name string) (fs.FileInfo, error) {
+	file, err := rc.lookup("lstat", name)
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// ReadLink returns the destination of the named symbolic link.
+func (rc *ReadCloser) ReadLink(name string) (string, error) {
+	file, err := rc.lookup("readlink", name)
+	if err != nil {
+		return "", err
+	}
+	if file.mode&os.ModeSymlink == 0 {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+	return file.link, nil
+}
+
+// lookup returns the named file without opening it.
+func (rc *ReadCloser) lookup(op, name string) (*File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	if idx, ok := rc.dirs[name]; ok {
+		return rc.index[idx], nil
+	}
+	if idx, ok := rc.files[name]; ok {
+		return rc.index[idx], nil
+	}
+	return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+}
+
+func (rc *ReadCloser) GetDirEntries(path string, n int) ([]fs.DirEntry, error) {
+	dIdx, ok := rc.dirs[path]
+	if !ok {
+		return nil, fs.ErrNotExist
+	}
+	dir, err := rc.getFile(dIdx)
+	if err != nil {
+		return nil, err
+	}
+	list, err := rc.entries[path].Page(&dir.dirReadAt, n)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]fs.DirEntry, len(list))
+	for i, fIdx := range list {
+		// the entries are not opened to be listed
+		entries[i] = rc.index[fIdx]
+	}
+	return entries, nil
+}
+
+func (rc *ReadCloser) getFile(idx int) (*File, error) {
+	if idx >= len(rc.index) || idx < 0 {
+		return nil, fs.ErrInvalid
+	}
+	file := rc.index[idx]
+	if !file.isDir {
+		err := file.OpenFile()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return file, nil
+}
+
+func (rc *ReadCloser) Reset() {
+	*rc = ReadCloser{}
+}
+
+// Close closes the image file, rendering it unusable for I/O.
+func (rc *ReadCloser) Close() error {
+	if rc == nil || rc.file == nil {
+		return nil
+	}
+	err := rc.file.Close()
+	rc.Reset()
+	return err
+}
@@ -0,0 +1,87 @@
+// Package iso
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package iso
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/pashifika/compress/internal/archivetest"
+)
+
+var lorem = strings.Repeat("lorem ipsum dolor sit amet\n", 100)
+
+func open(path string) (fs.FS, error) { return (&ReadCloser{}).OpenReader(path) }
+
+// TestReader reads the images of the primary volume only, with Joliet and with Rock Ridge.
+func TestReader(t *testing.T) {
+	archivetest.Check(t, open, "testdata/plain.iso", map[string]string{
+		"HELLO.TXT":     "hello, world\n",
+		"DIR/LOREM.TXT": lorem,
+	})
+	want := map[string]string{
+		"hello.txt":     "hello, world\n",
+		"dir/lorem.txt": lorem,
+	}
+	archivetest.Check(t, open, "testdata/joliet.iso", want)
+	archivetest.Check(t, open, "testdata/rr.iso", want)
+
+	fsys, err := open("testdata/rr.iso")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc := fsys.(*ReadCloser)
+	defer rc.Close()
+	if !rc.RockRidge() || rc.Joliet() {
+		t.Errorf("rock ridge %v, joliet %v", rc.RockRidge(), rc.Joliet())
+	}
+	if target, err := rc.ReadLink("link"); err != nil || target != "hello.txt" {
+		t.Errorf("link target %q, %v", target, err)
+	}
+	info, err := rc.Lstat("hello.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uid, gid, ok := info.Sys().(*Header).Owner(); info.Mode() != 0644 || uid != 1000 || gid != 100 || !ok {
+		t.Errorf("hello.txt mode %v, owner %d:%d", info.Mode(), uid, gid)
+	}
+}
+
+func TestTruncated(t *testing.T) {
+	for _, fixture := range []string{"testdata/plain.iso", "testdata/joliet.iso", "testdata/rr.iso"} {
+		archivetest.Truncated(t, open, fixture)
+	}
+}
+
+func TestCorrupt(t *testing.T) {
+	const (
+		fixture = "testdata/plain.iso"
+		pvd     = 16 * sectorSize
+		root    = 20 * sectorSize // the records ".", "..", "DIR" and "HELLO.TXT;1"
+	)
+	archivetest.Corrupt(t, open, fixture, pvd+1, 'X')                     // magic
+	archivetest.Corrupt(t, open, fixture, pvd+156, 16)                    // root record length
+	archivetest.Corrupt(t, open, fixture, pvd+156+2, 0xff, 0xff, 0xff, 0) // root extent past the end
+	archivetest.Corrupt(t, open, fixture, root, 16)                       // record length
+	archivetest.Corrupt(t, open, fixture, root+34+34+10, 0xff, 0xff, 0xff, 0x7f)
+	archivetest.Corrupt(t, open, fixture, root+34+34+36+2, 0xff, 0xff, 0, 0) // file extent past the end
+	archivetest.Mangle(t, open, fixture, pvd+156, pvd+190)
+	archivetest.Mangle(t, open, fixture, root, root+34+34+36+44)
+	archivetest.Mangle(t, open, "testdata/rr.iso", root, root+400)
+}
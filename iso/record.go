@@ -0,0 +1,269 @@
+// Package iso
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package iso
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+const (
+	sectorSize = 2048
+
+	flagHidden      = 0x01
+	flagDir         = 0x02
+	flagMultiExtent = 0x80
+)
+
+var errRecord = errors.New("iso: invalid directory record")
+
+// record is a directory record, with the Rock Ridge entries of its system use area.
+type record struct {
+	extent  uint32
+	size    uint32
+	modTime time.Time
+	flags   byte
+	name    string
+
+	// Rock Ridge
+	rr       bool
+	rrName   string
+	mode     uint32
+	nlink    uint32
+	uid      uint32
+	gid      uint32
+	link     string
+	hasLink  bool
+	rrTime   time.Time
+	child    uint32 // the CL location of the relocated directory
+	relocate bool   // the RE entry, the directory is relocated from elsewhere
+	skip     int    // the SP bytes skipped at the start of the system use area
+}
+
+// parseRecord parses the directory record of b, joliet decodes the UTF-16 names.
+func parseRecord(b []byte, joliet bool) (*record, error) {
+	if len(b) < 34 || int(b[0]) < 34 || int(b[0]) > len(b) {
+		return nil, errRecord
+	}
+	b = b[:b[0]]
+	nameLen := int(b[32])
+	if 33+nameLen > len(b) {
+		return nil, errRecord
+	}
+	r := &record{
+		extent:  binary.LittleEndian.Uint32(b[2:]),
+		size:    binary.LittleEndian.Uint32(b[10:]),
+		modTime: recordTime(b[18:25]),
+		flags:   b[25],
+	}
+	name := b[33 : 33+nameLen]
+	switch {
+	case nameLen == 1 && name[0] == 0:
+		r.name = "."
+	case nameLen == 1 && name[0] == 1:
+		r.name = ".."
+	case joliet:
+		u := make([]uint16, nameLen/2)
+		for i := range u {
+			u[i] = binary.BigEndian.Uint16(name[i*2:])
+		}
+		r.name = string(utf16.Decode(u))
+	default:
+		r.name = string(name)
+	}
+	if r.flags&flagDir == 0 {
+		// the version and the empty extension are not part of the name
+		if i := strings.LastIndexByte(r.name, ';'); i >= 0 {
+			r.name = r.name[:i]
+		}
+		r.name = strings.TrimSuffix(r.name, ".")
+	}
+	return r, nil
+}
+
+// systemUse returns the system use area of the record b.
+func systemUse(b []byte) []byte {
+	b = b[:b[0]]
+	start := 33 + int(b[32])
+	if start%2 == 1 {
+		start++
+	}
+	if start > len(b) {
+		return nil
+	}
+	return b[start:]
+}
+
+// recordTime returns the 7 bytes time of the directory records.
+func recordTime(b []byte) time.Time {
+	if b[0] == 0 && b[1] == 0 && b[2] == 0 {
+		return time.Time{}
+	}
+	loc := time.FixedZone("", int(int8(b[6]))*15*60)
+	return time.Date(1900+int(b[0]), time.Month(b[1]), int(b[2]), int(b[3]), int(b[4]), int(b[5]), 0, loc)
+}
+
+// longTime returns the 17 bytes time of the volume descriptors and the long form of TF.
+func longTime(b []byte) time.Time {
+	digits := func(s []byte) int {
+		n, _ := strconv.Atoi(string(s))
+		return n
+	}
+	year := digits(b[0:4])
+	if year == 0 {
+		return time.Time{}
+	}
+	loc := time.FixedZone("", int(int8(b[16]))*15*60)
+	return time.Date(year, time.Month(digits(b[4:6])), digits(b[6:8]), digits(b[8:10]), digits(b[10:12]),
+		digits(b[12:14]), digits(b[14:16])*int(time.Second/100), loc)
+}
+
+// parseSUSP parses the Rock Ridge entries of the system use area, the continuation
+// areas are read from r.
+func (rec *record) parseSUSP(r io.ReaderAt, area []byte) error {
+	var name, link bytes.Buffer
+	linkDone, complete := false, true
+	for depth := 0; depth < 16 && len(area) > 0; depth++ {
+		var next []byte
+		for len(area) >= 4 {
+			sig, l := string(area[:2]), int(area[2])
+			if l < 4 || l > len(area) {
+				break
+			}
+			e := area[4:l]
+			area = area[l:]
+			switch sig {
+			case "SP":
+				if len(e) >= 3 {
+					rec.skip = int(e[2])
+				}
+			case "RR":
+				rec.rr = true
+			case "PX":
+				if len(e) >= 32 {
+					rec.rr = true
+					rec.mode = binary.LittleEndian.Uint32(e[0:])
+					rec.nlink = binary.LittleEndian.Uint32(e[8:])
+					rec.uid = binary.LittleEndian.Uint32(e[16:])
+					rec.gid = binary.LittleEndian.Uint32(e[24:])
+				}
+			case "NM":
+				if len(e) >= 1 && e[0]&0x06 == 0 {
+					rec.rr = true
+					name.Write(e[1:])
+				}
+			case "SL":
+				if len(e) >= 1 && !linkDone {
+					rec.rr, rec.hasLink = true, true
+					complete = parseSL(&link, e[1:], complete)
+					linkDone = e[0]&0x01 == 0
+				}
+			case "TF":
+				if t, ok := parseTF(e); ok {
+					rec.rrTime = t
+				}
+			case "CL":
+				if len(e) >= 4 {
+					rec.child = binary.LittleEndian.Uint32(e)
+				}
+			case "RE":
+				rec.relocate = true
+			case "CE":
+				if len(e) >= 24 {
+					block := binary.LittleEndian.Uint32(e[0:])
+					off := binary.LittleEndian.Uint32(e[8:])
+					size := binary.LittleEndian.Uint32(e[16:])
+					if size > sectorSize {
+						return errRecord
+					}
+					next = make([]byte, size)
+					if _, err := r.ReadAt(next, int64(block)*sectorSize+int64(off)); err != nil {
+						return err
+					}
+				}
+			case "ST":
+				area = nil
+			}
+		}
+		area = next
+	}
+	if name.Len() > 0 {
+		rec.rrName = name.String()
+	}
+	if rec.hasLink {
+		rec.link = link.String()
+	}
+	return nil
+}
+
+// parseSL appends the components of the SL entry to link, complete reports whether
+// the last component is complete, as it is returned.
+func parseSL(link *bytes.Buffer, b []byte, complete bool) bool {
+	for len(b) >= 2 {
+		flags, l := b[0], int(b[1])
+		if 2+l > len(b) {
+			break
+		}
+		if link.Len() > 0 && complete && !bytes.HasSuffix(link.Bytes(), []byte("/")) {
+			link.WriteByte('/')
+		}
+		switch {
+		case flags&0x02 != 0:
+			link.WriteString(".")
+		case flags&0x04 != 0:
+			link.WriteString("..")
+		case flags&0x08 != 0:
+			link.Reset()
+			link.WriteString("/")
+		default:
+			link.Write(b[2 : 2+l])
+		}
+		complete = flags&0x01 == 0
+		b = b[2+l:]
+	}
+	return complete
+}
+
+// parseTF returns the modification time of the TF entry.
+func parseTF(e []byte) (time.Time, bool) {
+	if len(e) < 1 {
+		return time.Time{}, false
+	}
+	flags := e[0]
+	size := 7
+	if flags&0x80 != 0 {
+		size = 17
+	}
+	off := 1
+	if flags&0x01 != 0 {
+		off += size // creation
+	}
+	if flags&0x02 == 0 || off+size > len(e) {
+		return time.Time{}, false
+	}
+	if size == 17 {
+		return longTime(e[off : off+size]), true
+	}
+	return recordTime(e[off : off+size]), true
+}
@@ -0,0 +1,262 @@
+// Package compress
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package compress
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// JunkMode is the handling of the OS junk entries of the archive, set by FileSystem.Junk.
+type JunkMode int
+
+const (
+	// JunkShow leaves the junk entries in the archive.
+	JunkShow JunkMode = iota
+	// JunkHide hides the junk entries from Open, ReadDir, fs.WalkDir and Stream.
+	JunkHide
+	// JunkMerge hides the junk entries as JunkHide, and the AppleDouble "._" file of
+	// an entry is merged into it: its fs.FileInfo from fs.Stat or ReadDir implements
+	// AppleDoubleInfo.
+	JunkMerge
+)
+
+// JunkNames is the names of the OS junk entries, matched without case.
+var JunkNames = []string{
+	"__MACOSX",
+	".DS_Store",
+	".AppleDouble",
+	".Spotlight-V100",
+	".Trashes",
+	".fseventsd",
+	".TemporaryItems",
+	"Icon\r",
+	"Thumbs.db",
+	"ehthumbs.db",
+	"desktop.ini",
+	"$RECYCLE.BIN",
+	"System Volume Information",
+}
+
+// IsJunk reports whether the last element of the path name is an OS junk entry,
+// one of JunkNames or an AppleDouble "._" file.
+func IsJunk(name string) bool {
+	base := path.Base(name)
+	if strings.HasPrefix(base, "._") {
+		return true
+	}
+	for _, junk := range JunkNames {
+		if strings.EqualFold(base, junk) {
+			return true
+		}
+	}
+	return false
+}
+
+// junkFS hides the entries reported by junk, and the entries inside them.
+type junkFS struct {
+	fs.FS
+	junk  func(name string) bool
+	merge bool
+}
+
+// hidden reports whether name or one of its directories is junk.
+func (j *junkFS) hidden(name string) bool {
+	if name == DefaultArchiverRoot {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if name[i] == '/' && j.junk(name[:i]) {
+			return true
+		}
+	}
+	return j.junk(name)
+}
+
+func (j *junkFS) Open(name string) (fs.File, error) {
+	if fs.ValidPath(name) && j.hidden(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	f, err := j.FS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := f.Stat(); err == nil && info.IsDir() {
+		if d, ok := f.(fs.ReadDirFile); ok {
+			return &junkDir{ReadDirFile: d, fsys: j, name: name}, nil
+		}
+	}
+	return f, nil
+}
+
+func (j *junkFS) Stat(name string) (fs.FileInfo, error) {
+	if fs.ValidPath(name) && j.hidden(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+	info, err := fs.Stat(j.FS, name)
+	if err != nil {
+		return nil, err
+	}
+	return j.info(name, info), nil
+}
+
+// ReadDir reads the named directory without the junk entries.
+func (j *junkFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if fs.ValidPath(name) && j.hidden(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	entries, err := fs.ReadDir(j.FS, name)
+	return j.filter(name, entries), err
+}
+
+func (j *junkFS) ReadLink(name string) (string, error) {
+	if fs.ValidPath(name) && j.hidden(name) {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrNotExist}
+	}
+	return ReadLink(j.FS, name)
+}
+
+func (j *junkFS) Lstat(name string) (fs.FileInfo, error) {
+	if fs.ValidPath(name) && j.hidden(name) {
+		return nil, &fs.PathError{Op: "lstat", Path: name, Err: fs.ErrNotExist}
+	}
+	info, err := Lstat(j.FS, name)
+	if err != nil {
+		return nil, err
+	}
+	return j.info(name, info), nil
+}
+
+func (j *junkFS) ArchiveComment() string { return ArchiveComment(j.FS) }
+
+func (j *junkFS) Collisions() [][]string { return Collisions(j.FS) }
+
+// Stream calls fn for the entries which are not junk, in the archive order if the
+// archive implements Streamer.
+func (j *junkFS) Stream(fn StreamFunc) error {
+	s, ok := j.FS.(Streamer)
+	if !ok {
+		return walkStream(j, fn)
+	}
+	return s.Stream(func(name string, info fs.FileInfo, r io.Reader) error {
+		if j.hidden(name) {
+			return nil
+		}
+		return fn(name, j.info(name, info), r)
+	})
+}
+
+// filter removes the junk entries of the directory dir.
+func (j *junkFS) filter(dir string, entries []fs.DirEntry) []fs.DirEntry {
+	res := entries[:0]
+	for _, e := range entries {
+		if e == nil {
+			// the unread slots of the Decoder which fill a full length slice
+			continue
+		}
+		name := path.Join(dir, e.Name())
+		if j.junk(name) {
+			continue
+		}
+		if j.merge {
+			e = &junkEntry{DirEntry: e, fsys: j, name: name}
+		}
+		res = append(res, e)
+	}
+	return res
+}
+
+// info returns info with the AppleDouble file of name merged.
+func (j *junkFS) info(name string, info fs.FileInfo) fs.FileInfo {
+	if !j.merge || name == DefaultArchiverRoot {
+		return info
+	}
+	dir, base := path.Split(name)
+	for _, side := range []string{path.Join("__MACOSX", dir, "._"+base), path.Join(dir, "._"+base)} {
+		if s, err := Lstat(j.FS, side); err == nil && s.Mode().IsRegular() {
+			return &appleInfo{FileInfo: info, fsys: j.FS, name: side}
+		}
+	}
+	return info
+}
+
+// junkDir reads the directory without the junk entries.
+type junkDir struct {
+	fs.ReadDirFile
+	fsys *junkFS
+	name string
+}
+
+func (d *junkDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	for {
+		entries, err := d.ReadDirFile.ReadDir(n)
+		read := len(entries)
+		entries = d.fsys.filter(d.name, entries)
+		if len(entries) > 0 || err != nil || n <= 0 || read == 0 {
+			return entries, err
+		}
+	}
+}
+
+// junkEntry is the directory entry with the AppleDouble file merged.
+type junkEntry struct {
+	fs.DirEntry
+	fsys *junkFS
+	name string
+}
+
+func (e *junkEntry) Info() (fs.FileInfo, error) {
+	info, err := e.DirEntry.Info()
+	if err != nil {
+		return nil, err
+	}
+	return e.fsys.info(e.name, info), nil
+}
+
+// AppleDoubleInfo is implemented by the fs.FileInfo of the entries which have
+// an AppleDouble file, when the archive is opened with JunkMerge.
+type AppleDoubleInfo interface {
+	AppleDouble() (*AppleDouble, error)
+}
+
+// appleInfo is the fs.FileInfo of an entry and the name of its AppleDouble file.
+type appleInfo struct {
+	fs.FileInfo
+	fsys fs.FS
+	name string
+}
+
+func (a *appleInfo) AppleDouble() (*AppleDouble, error) {
+	f, err := a.fsys.Open(a.name)
+	if err != nil {
+		return nil, err
+	}
+	//goland:noinspection ALL
+	defer f.Close()
+	ad, err := ParseAppleDouble(f)
+	if err != nil {
+		return nil, &fs.PathError{Op: "appledouble", Path: a.name, Err: err}
+	}
+	return ad, nil
+}
+
+// errAppleDouble is the error of the AppleDouble file which is not valid.
+var errAppleDouble = errors.New("not a valid AppleDouble file")
@@ -0,0 +1,354 @@
+// Package lha
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package lha
+
+import (
+	"bufio"
+	"errors"
+	"io"
+)
+
+const (
+	numC     = 510 // the literals and the match lengths
+	numT     = 19  // the code lengths of the c tree
+	cBits    = 9
+	tBits    = 5
+	minMatch = 3
+)
+
+var errData = errors.New("lha: invalid compressed data")
+
+// bitReader reads the bits from the most significant, the bits after the end are zeros.
+type bitReader struct {
+	r   io.ByteReader
+	buf uint32
+	n   uint
+}
+
+func (b *bitReader) fill(n uint) {
+	for b.n < n {
+		c, err := b.r.ReadByte()
+		if err != nil {
+			c = 0
+		}
+		b.buf |= uint32(c) << (24 - b.n)
+		b.n += 8
+	}
+}
+
+// peek returns the next n bits, n <= 16.
+func (b *bitReader) peek(n uint) uint32 {
+	b.fill(n)
+	return b.buf >> (32 - n)
+}
+
+func (b *bitReader) skip(n uint) {
+	b.fill(n)
+	b.buf <<= n
+	b.n -= n
+}
+
+func (b *bitReader) bits(n uint) int {
+	if n == 0 {
+		return 0
+	}
+	v := b.peek(n)
+	b.skip(n)
+	return int(v)
+}
+
+// huffman is the canonical Huffman code of a block.
+type huffman struct {
+	lens   []uint8
+	table  []uint16
+	maxLen uint
+	single int // the only symbol if >= 0, it has no code
+}
+
+// build makes the decoding table of the code lengths, the code must be complete.
+func (h *huffman) build() error {
+	var count [17]int
+	h.maxLen = 0
+	for _, l := range h.lens {
+		if l > 16 {
+			return errData
+		}
+		count[l]++
+		if uint(l) > h.maxLen {
+			h.maxLen = uint(l)
+		}
+	}
+	total := 0
+	for l := 1; l <= 16; l++ {
+		total += count[l] << (16 - l)
+	}
+	if total != 1<<16 {
+		return errData
+	}
+	var next [17]int
+	code := 0
+	for l := 1; l <= 16; l++ {
+		next[l] = code
+		code = (code + count[l]) << 1
+	}
+	if cap(h.table) < 1<<h.maxLen {
+		h.table = make([]uint16, 1<<h.maxLen)
+	}
+	h.table = h.table[:1<<h.maxLen]
+	for s, l := range h.lens {
+		if l == 0 {
+			continue
+		}
+		shift := h.maxLen - uint(l)
+		start := next[l] << shift
+		next[l]++
+		for i := start; i < start+1<<shift; i++ {
+			h.table[i] = uint16(s)
+		}
+	}
+	h.single = -1
+	return nil
+}
+
+func (h *huffman) decode(br *bitReader) int {
+	if h.single >= 0 {
+		return h.single
+	}
+	s := h.table[br.peek(h.maxLen)]
+	br.skip(uint(h.lens[s]))
+	return int(s)
+}
+
+// setSingle set the code to the only symbol s.
+func (h *huffman) setSingle(s, n int) error {
+	if s >= n {
+		return errData
+	}
+	for i := range h.lens {
+		h.lens[i] = 0
+	}
+	h.single = s
+	return nil
+}
+
+// decoder decodes the -lh4- to -lh7- methods, which are LZSS with the static Huffman blocks.
+type decoder struct {
+	br      bitReader
+	numP    int
+	pBits   uint
+	window  []byte
+	mask    int
+	pos     int
+	remain  int64 // the bytes to decode
+	block   int   // the symbols left in the block
+	copyLen int
+	copyPos int
+
+	c, t, p huffman
+}
+
+// newDecoder returns the decoder of size bytes, dictBits is the dictionary size.
+func newDecoder(r io.Reader, dictBits uint, size int64) *decoder {
+	d := &decoder{
+		numP:   int(dictBits) + 1,
+		pBits:  4,
+		window: make([]byte, 1<<dictBits),
+		mask:   1<<dictBits - 1,
+		remain: size,
+	}
+	if dictBits > 13 {
+		d.pBits = 5
+	}
+	br, ok := r.(io.ByteReader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	d.br.r = br
+	for i := range d.window {
+		d.window[i] = ' '
+	}
+	d.c.lens = make([]uint8, numC)
+	d.t.lens = make([]uint8, numT)
+	d.p.lens = make([]uint8, d.numP)
+	return d
+}
+
+func (d *decoder) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) && d.remain > 0 {
+		if d.copyLen == 0 {
+			c, err := d.decodeC()
+			if err != nil {
+				return n, err
+			}
+			if c < 256 {
+				d.put(byte(c))
+				p[n] = byte(c)
+				n++
+				continue
+			}
+			d.copyLen = c - 256 + minMatch
+			d.copyPos = (d.pos - d.decodeP() - 1) & d.mask
+		}
+		for d.copyLen > 0 && n < len(p) && d.remain > 0 {
+			c := d.window[d.copyPos]
+			d.copyPos = (d.copyPos + 1) & d.mask
+			d.copyLen--
+			d.put(c)
+			p[n] = c
+			n++
+		}
+	}
+	if n == 0 && d.remain == 0 {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+func (d *decoder) put(c byte) {
+	d.window[d.pos] = c
+	d.pos = (d.pos + 1) & d.mask
+	d.remain--
+}
+
+func (d *decoder) decodeC() (int, error) {
+	if d.block == 0 {
+		d.block = d.br.bits(16)
+		if err := d.readPtLen(&d.t, numT, tBits, 3); err != nil {
+			return 0, err
+		}
+		if err := d.readCLen(); err != nil {
+			return 0, err
+		}
+		if err := d.readPtLen(&d.p, d.numP, d.pBits, -1); err != nil {
+			return 0, err
+		}
+		if d.block == 0 {
+			return 0, errData
+		}
+	}
+	d.block--
+	return d.c.decode(&d.br), nil
+}
+
+func (d *decoder) decodeP() int {
+	j := d.p.decode(&d.br)
+	if j != 0 {
+		j = 1<<(j-1) + d.br.bits(uint(j-1))
+	}
+	return j
+}
+
+// readPtLen reads the code lengths of the t and p trees, special is the index
+// after which the count of the zero lengths follows.
+func (d *decoder) readPtLen(h *huffman, nn int, nBits uint, special int) error {
+	n := d.br.bits(nBits)
+	if n == 0 {
+		return h.setSingle(d.br.bits(nBits), nn)
+	}
+	if n > nn {
+		return errData
+	}
+	i := 0
+	for i < n {
+		c := d.br.bits(3)
+		if c == 7 {
+			for d.br.bits(1) == 1 {
+				if c++; c > 16 {
+					return errData
+				}
+			}
+		}
+		h.lens[i] = uint8(c)
+		i++
+		if i == special {
+			for z := d.br.bits(2); z > 0; z-- {
+				if i == nn {
+					return errData
+				}
+				h.lens[i] = 0
+				i++
+			}
+		}
+	}
+	for ; i < nn; i++ {
+		h.lens[i] = 0
+	}
+	return h.build()
+}
+
+// readCLen reads the code lengths of the c tree, coded by the t tree.
+func (d *decoder) readCLen() error {
+	n := d.br.bits(cBits)
+	if n == 0 {
+		return d.c.setSingle(d.br.bits(cBits), numC)
+	}
+	if n > numC {
+		return errData
+	}
+	i := 0
+	for i < n {
+		c := d.t.decode(&d.br)
+		if c > 2 {
+			d.c.lens[i] = uint8(c - 2)
+			i++
+			continue
+		}
+		zeros := 1
+		switch c {
+		case 1:
+			zeros = d.br.bits(4) + 3
+		case 2:
+			zeros = d.br.bits(cBits) + 20
+		}
+		if i+zeros > numC {
+			return errData
+		}
+		for ; zeros > 0; zeros-- {
+			d.c.lens[i] = 0
+			i++
+		}
+	}
+	for ; i < numC; i++ {
+		d.c.lens[i] = 0
+	}
+	return d.c.build()
+}
+
+// crcTable is the CRC-16 of LHA, the polynomial 0x8005 reflected.
+var crcTable = func() (t [256]uint16) {
+	for i := range t {
+		c := uint16(i)
+		for k := 0; k < 8; k++ {
+			if c&1 != 0 {
+				c = c>>1 ^ 0xa001
+			} else {
+				c >>= 1
+			}
+		}
+		t[i] = c
+	}
+	return t
+}()
+
+func crc16(crc uint16, p []byte) uint16 {
+	for _, b := range p {
+		crc = crcTable[byte(crc)^b] ^ crc>>8
+	}
+	return crc
+}
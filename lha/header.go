@@ -0,0 +1,305 @@
+// Package lha
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package lha
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"time"
+)
+
+// the extended header types
+const (
+	extFileName  = 0x01
+	extDirName   = 0x02
+	extComment   = 0x3f
+	extAttribute = 0x40
+	extWinTime   = 0x41
+	extUnixMode  = 0x50
+	extUnixOwner = 0x51
+	extUnixTime  = 0x54
+)
+
+const (
+	attribReadOnly = 0x01
+	attribDir      = 0x10
+)
+
+var (
+	errNotLha = errors.New("lha: not a valid lzh file")
+	errHeader = errors.New("lha: invalid header")
+)
+
+// Header is the Sys() of the archive entries, it implements compress.Owner with
+// the Unix extended headers.
+type Header struct {
+	Name       string // the decoded path of the entry
+	Method     string // the compression method, as "-lh5-"
+	PackedSize int64
+	Size       int64
+	ModTime    time.Time
+	Attribute  byte // the MS-DOS attributes
+	Level      byte // the header level, 0 to 2
+	OS         byte // the OS identifier, as 'U' for Unix or 'M' for MS-DOS
+	CRC        uint16
+	Comment    string
+
+	// Unix, Mode is 0 if the header has no Unix permission
+	Mode uint16
+	Uid  int
+	Gid  int
+
+	owner bool
+}
+
+func (h *Header) Owner() (uid, gid int, ok bool) { return h.Uid, h.Gid, h.owner }
+
+// header is the parsed header, the names are not decoded yet.
+type header struct {
+	Header
+	dir    []byte // the directory, separated by '/'
+	name   []byte
+	offset int64 // the data offset in the archive
+}
+
+// readHeader reads the header at off, it returns io.EOF at the end of the archive.
+func readHeader(r io.ReaderAt, off, size int64) (*header, error) {
+	var b [22]byte
+	if off >= size {
+		return nil, io.EOF
+	}
+	if n, err := r.ReadAt(b[:], off); n < len(b) {
+		if b[0] == 0 && n > 0 {
+			return nil, io.EOF
+		}
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	if b[0] == 0 && b[1] == 0 {
+		return nil, io.EOF
+	}
+	if b[2] != '-' || b[3] != 'l' || b[6] != '-' {
+		return nil, errNotLha
+	}
+	h := &header{Header: Header{
+		Method:     string(b[2:7]),
+		PackedSize: int64(binary.LittleEndian.Uint32(b[7:])),
+		Size:       int64(binary.LittleEndian.Uint32(b[11:])),
+		Attribute:  b[19],
+		Level:      b[20],
+	}}
+	stamp := binary.LittleEndian.Uint32(b[15:])
+	switch h.Level {
+	case 0, 1:
+		h.ModTime = msDosTimeToTime(uint16(stamp>>16), uint16(stamp))
+		return h, h.readLevel01(r, off, int(b[0])+2, b[1])
+	case 2:
+		h.ModTime = time.Unix(int64(stamp), 0).UTC()
+		return h, h.readLevel2(r, off, int(binary.LittleEndian.Uint16(b[:])))
+	}
+	return nil, fmt.Errorf("lha: unsupported header level [%d] %s", h.Level, h.Method)
+}
+
+func (h *header) readLevel01(r io.ReaderAt, off int64, total int, sum byte) error {
+	b := make([]byte, total)
+	if _, err := r.ReadAt(b, off); err != nil {
+		return errHeader
+	}
+	var s byte
+	for _, c := range b[2:] {
+		s += c
+	}
+	if s != sum || total < 24 || total < 24+int(b[21]) {
+		return errNotLha
+	}
+	p := 22 + int(b[21])
+	h.name = slash(b[22:p])
+	h.CRC = binary.LittleEndian.Uint16(b[p:])
+	p += 2
+	if h.Level == 0 {
+		// the Unix extension of LHa for UNIX: OS, mtime, mode, uid, gid
+		if total-p >= 11 && b[p] == 'U' {
+			h.OS = 'U'
+			h.ModTime = time.Unix(int64(binary.LittleEndian.Uint32(b[p+1:])), 0).UTC()
+			h.Mode = binary.LittleEndian.Uint16(b[p+5:])
+			h.Uid = int(binary.LittleEndian.Uint16(b[p+7:]))
+			h.Gid = int(binary.LittleEndian.Uint16(b[p+9:]))
+			h.owner = true
+		}
+		h.offset = off + int64(total)
+		return nil
+	}
+
+	// level 1: the OS and the size of the first extended header end the base header,
+	// the packed size includes the extended headers
+	if total-p < 3 {
+		return errHeader
+	}
+	h.OS = b[p]
+	next := int(binary.LittleEndian.Uint16(b[total-2:]))
+	pos := off + int64(total)
+	for next > 0 {
+		if next < 3 || int64(next) > h.PackedSize {
+			return errHeader
+		}
+		ext := make([]byte, next)
+		if _, err := r.ReadAt(ext, pos); err != nil {
+			return errHeader
+		}
+		h.extension(ext[0], ext[1:next-2])
+		pos += int64(next)
+		h.PackedSize -= int64(next)
+		next = int(binary.LittleEndian.Uint16(ext[next-2:]))
+	}
+	h.offset = pos
+	return nil
+}
+
+func (h *header) readLevel2(r io.ReaderAt, off int64, total int) error {
+	if total < 26 {
+		return errHeader
+	}
+	b := make([]byte, total)
+	if _, err := r.ReadAt(b, off); err != nil {
+		return errHeader
+	}
+	h.CRC = binary.LittleEndian.Uint16(b[21:])
+	h.OS = b[23]
+	p := 24
+	for next := int(binary.LittleEndian.Uint16(b[p:])); next > 0; {
+		// the type, the data and the size of the next extended header
+		p += 2
+		if next < 3 || p+next > total {
+			return errHeader
+		}
+		h.extension(b[p], b[p+1:p+next-2])
+		p += next - 2
+		next = int(binary.LittleEndian.Uint16(b[p:]))
+	}
+	h.offset = off + int64(total)
+	return nil
+}
+
+// extension applies the extended header of type t.
+func (h *header) extension(t byte, b []byte) {
+	switch t {
+	case extFileName:
+		h.name = slash(b)
+	case extDirName:
+		h.dir = slash(b)
+	case extComment:
+		h.Comment = string(b)
+	case extAttribute:
+		if len(b) >= 1 {
+			h.Attribute = b[0]
+		}
+	case extWinTime:
+		if len(b) >= 16 {
+			// creation, modification and access times, in 100ns since 1601
+			ft := int64(binary.LittleEndian.Uint64(b[8:])) - 116444736000000000
+			h.ModTime = time.Unix(ft/1e7, ft%1e7*100).UTC()
+		}
+	case extUnixMode:
+		if len(b) >= 2 {
+			h.Mode = binary.LittleEndian.Uint16(b)
+		}
+	case extUnixOwner:
+		if len(b) >= 4 {
+			h.Gid = int(binary.LittleEndian.Uint16(b))
+			h.Uid = int(binary.LittleEndian.Uint16(b[2:]))
+			h.owner = true
+		}
+	case extUnixTime:
+		if len(b) >= 4 {
+			h.ModTime = time.Unix(int64(binary.LittleEndian.Uint32(b)), 0).UTC()
+		}
+	}
+}
+
+// path returns the raw path of the entry, the directory and the name are joined by '/'.
+func (h *header) path() []byte {
+	if len(h.dir) == 0 {
+		return h.name
+	}
+	p := append([]byte(nil), h.dir...)
+	if p[len(p)-1] != '/' {
+		p = append(p, '/')
+	}
+	return append(p, h.name...)
+}
+
+// slash returns a copy of b where the 0xff separators are '/', the backslashes are
+// replaced after the name is decoded as they may be a part of a Shift-JIS character.
+func slash(b []byte) []byte {
+	return bytes.ReplaceAll(b, []byte{0xff}, []byte{'/'})
+}
+
+// msDosTimeToTime converts the MS-DOS date and time to time.Time, in UTC as zip.
+func msDosTimeToTime(dosDate, dosTime uint16) time.Time {
+	return time.Date(
+		int(dosDate>>9+1980),
+		time.Month(dosDate>>5&0xf),
+		int(dosDate&0x1f),
+		int(dosTime>>11),
+		int(dosTime>>5&0x3f),
+		int(dosTime&0x1f*2),
+		0,
+		time.UTC,
+	)
+}
+
+// fileMode returns the fs.FileMode of the Unix permission, or of the MS-DOS attributes.
+func (h *header) fileMode() fs.FileMode {
+	isDir := h.Method == "-lhd-"
+	if h.Mode == 0 {
+		switch {
+		case isDir || h.Attribute&attribDir != 0:
+			return fs.ModeDir | 0755
+		case h.Attribute&attribReadOnly != 0:
+			return 0444
+		}
+		return 0644
+	}
+	mode := fs.FileMode(h.Mode & 0777)
+	switch h.Mode & 0170000 {
+	case 0040000:
+		mode |= fs.ModeDir
+	case 0120000:
+		mode |= fs.ModeSymlink
+	}
+	if h.Mode&04000 != 0 {
+		mode |= fs.ModeSetuid
+	}
+	if h.Mode&02000 != 0 {
+		mode |= fs.ModeSetgid
+	}
+	if h.Mode&01000 != 0 {
+		mode |= fs.ModeSticky
+	}
+	if isDir && mode&fs.ModeSymlink == 0 {
+		// LHa for UNIX stores the symbolic links as -lhd-
+		mode |= fs.ModeDir
+	}
+	return mode
+}
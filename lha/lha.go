@@ -0,0 +1,392 @@
+// Package lha
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package lha
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+
+	"github.com/pashifika/compress"
+)
+
+var errChecksum = errors.New("lha: checksum error")
+
+// ReadCloser is the lzh file, each entry is compressed on its own and read at random.
+type ReadCloser struct {
+	file    *os.File
+	size    int64
+	entries map[string]*compress.DirIndex
+	dirs    map[string]int
+	files   map[string]int
+	index   []*File
+
+	charset []encoding.Encoding
+	skipErr bool
+	root    fs.FileInfo
+}
+
+func (rc *ReadCloser) Name() string { return "lha" }
+
+func (rc *ReadCloser) SetRootInfo(info os.FileInfo) { rc.root = info }
+
+// SetCharset set the charsets to decode the file names which are not UTF-8, as Shift-JIS.
+func (rc *ReadCloser) SetCharset(charset []encoding.Encoding, skipErr bool) {
+	rc.charset = charset
+	rc.skipErr = skipErr
+}
+
+// OpenReader will open the lzh file specified by name and return a ReadCloser.
+func (rc *ReadCloser) OpenReader(path string) (fs.FS, error) {
+	return rc.OpenReaderWithPassword(path, "")
+}
+
+// OpenReaderWithPassword is the same as OpenReader, lha has no password.
+func (rc *ReadCloser) OpenReaderWithPassword(path, _ string) (fs.FS, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	res := &ReadCloser{
+		file: f,
+		entries: map[string]*compress.DirIndex{
+			compress.DefaultArchiverRoot: compress.NewDirEntries(),
+		},
+		dirs:    map[string]int{},
+		files:   map[string]int{},
+		index:   []*File{},
+		charset: rc.charset,
+		skipErr: rc.skipErr,
+		root:    rc.root,
+	}
+	info, err := f.Stat()
+	if err == nil {
+		res.size = info.Size()
+		if res.root == nil {
+			res.root = info
+		}
+		err = res.scan()
+	}
+	if err != nil {
+		_ = res.Close()
+		return nil, err
+	}
+	return res, nil
+}
+
+// scan reads the headers, and builds the index of the entries.
+func (rc *ReadCloser) scan() error {
+	for off, seq := int64(0), 0; ; seq++ {
+		h, err := readHeader(rc.file, off, rc.size)
+		if err == io.EOF {
+			if seq == 0 {
+				return errNotLha
+			}
+			break
+		}
+		if err != nil {
+			if seq > 0 && err == errNotLha {
+				err = errHeader
+			}
+			return fmt.Errorf("reading header [%d] %d\n  error: %w", seq, off, err)
+		}
+		if h.offset+h.PackedSize > rc.size || h.PackedSize < 0 {
+			return fmt.Errorf("reading header [%d] %s\n  error: %w", seq, h.Method, io.ErrUnexpectedEOF)
+		}
+		if h.Name, err = rc.decodeName(h.path()); err != nil {
+			return fmt.Errorf("decoding name [%d] %s\n  error: %w", seq, h.path(), err)
+		}
+		rc.add(h)
+		off = h.offset + h.PackedSize
+	}
+
+	// Set root info
+	rc.dirs[compress.DefaultArchiverRoot] = len(rc.index)
+	rc.index = append(rc.index, &File{
+		name:       compress.DefaultArchiverRoot,
+		mode:       rc.root.Mode() + os.ModeDir,
+		isDir:      true,
+		dirEntries: rc.GetDirEntries,
+	})
+	return nil
+}
+
+// decodeName returns the name decoded by the charset if it is not UTF-8, the backslash
+// separators are replaced by '/'.
+func (rc *ReadCloser) decodeName(b []byte) (string, error) {
+	name := string(b)
+	if rc.charset != nil && !isASCII(b) {
+		var err error
+		for _, enc := range rc.charset {
+			var s string
+			if s, err = enc.NewDecoder().String(string(b)); err == nil {
+				name = s
+				break
+			}
+		}
+		if err != nil && !rc.skipErr {
+			return "", err
+		}
+	}
+	return strings.ReplaceAll(name, "\\", "/"), nil
+}
+
+func isASCII(b []byte) bool {
+	for _, c := range b {
+		if c >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// add adds the entry of h to the index, the entry of the same name is replaced.
+func (rc *ReadCloser) add(h *header) {
+	name, mode := h.Name, h.fileMode()
+	link := ""
+	if mode&fs.ModeSymlink != 0 {
+		// LHa for UNIX stores the symbolic links as "name|target"
+		if i := strings.IndexByte(name, '|'); i >= 0 {
+			name, link = name[:i], name[i+1:]
+		}
+	}
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if name == "" {
+		return
+	}
+	h.Name = name
+	entry := &File{
+		header:   &h.Header,
+		name:     name,
+		mode:     mode,
+		link:     link,
+		offset:   h.offset,
+		fileOpen: rc.openEntry,
+	}
+	if mode.IsDir() {
+		entry.isDir = true
+		entry.dirEntries = rc.GetDirEntries
+		if idx, ok := rc.dirs[name]; ok {
+			rc.index[idx] = entry
+			return
+		}
+		rc.dirs[name] = rc.addIndex(entry)
+		return
+	}
+	if link != "" {
+		entry.size = int64(len(link))
+	} else {
+		entry.size = h.Size
+	}
+	if idx, ok := rc.files[name]; ok {
+		rc.index[idx] = entry
+		return
+	}
+	rc.files[name] = rc.addIndex(entry)
+}
+
+// addIndex adds entry to the index and to its parent directory, the parent
+// directories which are not stored are created.
+func (rc *ReadCloser) addIndex(entry *File) int {
+	idx := len(rc.index)
+	rc.index = append(rc.index, entry)
+	dir := path.Dir(entry.name)
+	if _, ok := rc.entries[dir]; !ok {
+		rc.entries[dir] = compress.NewDirEntries()
+		if _, ok = rc.dirs[dir]; !ok {
+			rc.dirs[dir] = rc.addIndex(&File{
+				name:       dir,
+				isDir:      true,
+				mode:       fs.ModeDir | 0755,
+				dirEntries: rc.GetDirEntries,
+			})
+		}
+	}
+	rc.entries[dir].Add(idx)
+	return idx
+}
+
+// dictBits is the dictionary size of the LZSS methods.
+var dictBits = map[string]uint{
+	"-lh4-": 12,
+	"-lh5-": 13,
+	"-lh6-": 15,
+	"-lh7-": 16,
+}
+
+// openEntry returns the reader of f, the CRC-16 of the data is checked at the end.
+func (rc *ReadCloser) openEntry(f *File) (io.ReadCloser, error) {
+	if f.link != "" {
+		return ioutil.NopCloser(strings.NewReader(f.link)), nil
+	}
+	h := f.header
+	sr := io.NewSectionReader(rc.file, f.offset, h.PackedSize)
+	var r io.Reader
+	switch h.Method {
+	case "-lh0-", "-lz4-":
+		r = sr
+	default:
+		bits, ok := dictBits[h.Method]
+		if !ok {
+			return nil, fmt.Errorf("lha: unsupported compression method %s", h.Method)
+		}
+		r = newDecoder(bufio.NewReader(sr), bits, h.Size)
+	}
+	return ioutil.NopCloser(&checksumReader{r: r, remain: h.Size, want: h.CRC}), nil
+}
+
+// checksumReader reads size bytes from r, and checks their CRC-16 at the end.
+type checksumReader struct {
+	r      io.Reader
+	remain int64
+	crc    uint16
+	want   uint16
+}
+
+func (c *checksumReader) Read(p []byte) (int, error) {
+	if c.remain <= 0 {
+		if c.crc != c.want {
+			return 0, errChecksum
+		}
+		return 0, io.EOF
+	}
+	if int64(len(p)) > c.remain {
+		p = p[:c.remain]
+	}
+	n, err := c.r.Read(p)
+	c.crc = crc16(c.crc, p[:n])
+	c.remain -= int64(n)
+	switch {
+	case c.remain <= 0 && c.crc != c.want:
+		return n, errChecksum
+	case err == io.EOF && c.remain > 0:
+		err = io.ErrUnexpectedEOF
+	case err == io.EOF:
+		err = nil
+	}
+	return n, err
+}
+
+// Open opens the named file in the lzh file, using the semantics of fs.FS.Open:
+// paths are always slash separated, with no leading / or ../ elements.
+func (rc *ReadCloser) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	idx, ok := rc.dirs[name]
+	if ok {
+		return rc.getFile(idx)
+	}
+	idx, ok = rc.files[name]
+	if ok {
+		return rc.getFile(idx)
+	}
+	return nil, &fs.PathError{Op: "info", Path: name, Err: fs.ErrNotExist}
+}
+
+// Lstat returns the fs.FileInfo of the named file, the symbolic link is not followed.
+func (rc *ReadCloser) Lstat(name string) (fs.FileInfo, error) {
+	file, err := rc.lookup("lstat", name)
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// ReadLink returns the destination of the named symbolic link.
+func (rc *ReadCloser) ReadLink(name string) (string, error) {
+	file, err := rc.lookup("readlink", name)
+	if err != nil {
+		return "", err
+	}
+	if file.mode&os.ModeSymlink == 0 {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+	return file.link, nil
+}
+
+func (rc *ReadCloser) lookup(op, name string) (*File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	if idx, ok := rc.dirs[name]; ok {
+		return rc.index[idx], nil
+	}
+	if idx, ok := rc.files[name]; ok {
+		return rc.index[idx], nil
+	}
+	return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+}
+func (rc *ReadCloser) GetDirEntries(path string, n int) ([]fs.DirEntry, error) {
+	dIdx, ok := rc.dirs[path]
+	if !ok {
+		return nil, fs.ErrNotExist
+	}
+	dir, err := rc.getFile(dIdx)
+	if err != nil {
+		return nil, err
+	}
+	list, err := rc.entries[path].Page(&dir.dirReadAt, n)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]fs.DirEntry, len(list))
+	for i, fIdx := range list {
+		// the entries are not opened to be listed
+		entries[i] = rc.index[fIdx]
+	}
+	return entries, nil
+}
+
+func (rc *ReadCloser) getFile(idx int) (*File, error) {
+	if idx >= len(rc.index) || idx < 0 {
+		return nil, fs.ErrInvalid
+	}
+	file := rc.index[idx]
+	if !file.isDir {
+		err := file.OpenFile()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return file, nil
+}
+
+func (rc *ReadCloser) Reset() {
+	*rc = ReadCloser{}
+}
+
+// Close closes the lzh file, rendering it unusable for I/O.
+func (rc *ReadCloser) Close() error {
+	if rc == nil || rc.file == nil {
+		return nil
+	}
+	err := rc.file.Close()
+	rc.Reset()
+	return err
+}
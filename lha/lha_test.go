@@ -0,0 +1,107 @@
+// Package lha
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package lha
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"io/fs"
+	"testing"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/japanese"
+
+	"github.com/pashifika/compress/internal/archivetest"
+)
+
+const fixture = "testdata/test.lzh"
+
+func open(path string) (fs.FS, error) {
+	reader := &ReadCloser{}
+	reader.SetCharset([]encoding.Encoding{japanese.ShiftJIS}, false)
+	return reader.OpenReader(path)
+}
+
+// TestReader reads the archive of the header levels 0 to 2, with the -lh0- and
+// -lh4- to -lh7- methods, the Shift_JIS names, a directory and a symbolic link.
+func TestReader(t *testing.T) {
+	want := map[string]string{
+		"hello.txt":    "0aa336b687946c677e0f657316ff2bfe",
+		"日本語/ファイル.txt": "9980738e0eec4b263a581ddcb324f717",
+		"bin/rand.bin": "b9c150e09a82019a923f761758fb3619",
+		"表/lh6.dat":    "b87e4bca75471e6c0a70cb627687d63d",
+		"lh7.dat":      "064c11bc5eb70d33f75ed9c35e286292",
+		"aaa":          "3f6e8137edac32111f8ed79616c11057",
+		"empty":        "d41d8cd98f00b204e9800998ecf8427e",
+		"ソ.txt":        "a72966b3c2834e2b853ee06a7bff45fc",
+		"lvl0unix":     "b8cfb1da348bdb636cdcb861526956b2",
+	}
+	fsys, err := open(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc := fsys.(*ReadCloser)
+	files, err := archivetest.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, sum := range want {
+		data, ok := files[name]
+		if !ok {
+			t.Errorf("%s is missing", name)
+			continue
+		}
+		if got := md5.Sum(data); hex.EncodeToString(got[:]) != sum {
+			t.Errorf("%s has wrong data", name)
+		}
+	}
+	if len(files) != len(want) {
+		t.Errorf("%d files, want %d", len(files), len(want))
+	}
+
+	fsys, err = open(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc = fsys.(*ReadCloser)
+	defer rc.Close()
+	if target, err := rc.ReadLink("link"); err != nil || target != "hello.txt" {
+		t.Errorf("link target %q, %v", target, err)
+	}
+	for name, mode := range map[string]fs.FileMode{"emptydir": fs.ModeDir | 0700, "bin/rand.bin": 0755, "表/lh6.dat": 0640} {
+		if info, err := rc.Lstat(name); err != nil || info.Mode() != mode {
+			t.Errorf("%s: mode %v, %v", name, info.Mode(), err)
+		}
+	}
+}
+
+func TestTruncated(t *testing.T) { archivetest.Truncated(t, open, fixture) }
+
+func TestCorrupt(t *testing.T) {
+	archivetest.Corrupt(t, open, fixture, 2, 'X')                    // method
+	archivetest.Corrupt(t, open, fixture, 4, 'x')                    // unsupported method
+	archivetest.Corrupt(t, open, fixture, 1, 0)                      // checksum
+	archivetest.Corrupt(t, open, fixture, 7, 0xff, 0xff, 0xff, 0x7f) // packed size
+	archivetest.Corrupt(t, open, fixture, 11, 0xff, 0xff)            // size
+	archivetest.Corrupt(t, open, fixture, 20, 9)                     // level
+	archivetest.Corrupt(t, open, fixture, 200, 0x55)                 // data, the CRC
+	archivetest.Mangle(t, open, fixture, 0, 40)
+	archivetest.Mangle(t, open, fixture, 2450, 2450+80)   // level 1 and its extended headers
+	archivetest.Mangle(t, open, fixture, 5523, 5523+59)   // level 2
+	archivetest.Mangle(t, open, fixture, 25596, 25596+60) // the symbolic link
+}
@@ -0,0 +1,123 @@
+// Package compress
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package compress
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// LookupOption is the matching of the names opened in the archive, set by FileSystem.Lookup.
+// The names of the archive are always matched as is first.
+type LookupOption int
+
+const (
+	// LookupFoldCase matches the names without case, by the Unicode case folding.
+	LookupFoldCase LookupOption = 1 << iota
+	// LookupNormalize matches the NFC and the NFD forms of the names, as the NFD names
+	// of the archives created on macOS.
+	LookupNormalize
+)
+
+// ErrNameCollision is returned when a name matches several names of the archive by the lookup options.
+var ErrNameCollision = errors.New("names collide after normalization")
+
+// LookupDecoder is implemented by the Decoder which support the lookup options.
+type LookupDecoder interface {
+	SetLookup(opt LookupOption)
+}
+
+// CollisionReporter is implemented by the archive fs.FS opened with the lookup options,
+// Collisions returns the groups of the names which match the same name.
+type CollisionReporter interface {
+	Collisions() [][]string
+}
+
+// Collisions returns the names of fsys which match the same name by the lookup options,
+// or nil if fsys has none or was not opened with the lookup options.
+func Collisions(fsys fs.FS) [][]string {
+	if c, ok := fsys.(CollisionReporter); ok {
+		return c.Collisions()
+	}
+	return nil
+}
+
+// Key returns the name matched by o, the names of the same key are equivalent.
+func (o LookupOption) Key(name string) string {
+	if o&LookupFoldCase != 0 {
+		name = cases.Fold().String(name)
+	}
+	if o&LookupNormalize != 0 {
+		name = norm.NFC.String(name)
+	}
+	return name
+}
+
+// NameIndex finds the names of the archive by the lookup options.
+type NameIndex struct {
+	opt   LookupOption
+	names map[string][]string
+}
+
+func NewNameIndex(opt LookupOption) *NameIndex {
+	return &NameIndex{opt: opt, names: map[string][]string{}}
+}
+
+// Add adds the name of the archive to the index.
+func (n *NameIndex) Add(name string) {
+	key := n.opt.Key(name)
+	for _, s := range n.names[key] {
+		if s == name {
+			return
+		}
+	}
+	n.names[key] = append(n.names[key], name)
+}
+
+// Find returns the name of the archive which matches name, the error is fs.ErrNotExist
+// if there is none, or wraps ErrNameCollision if there are several.
+func (n *NameIndex) Find(name string) (string, error) {
+	names := n.names[n.opt.Key(name)]
+	switch len(names) {
+	case 0:
+		return "", fs.ErrNotExist
+	case 1:
+		return names[0], nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrNameCollision, strings.Join(names, ", "))
+}
+
+// Collisions returns the groups of the names which have the same key, sorted by name.
+func (n *NameIndex) Collisions() [][]string {
+	var res [][]string
+	for _, names := range n.names {
+		if len(names) > 1 {
+			group := append([]string(nil), names...)
+			sort.Strings(group)
+			res = append(res, group)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i][0] < res[j][0] })
+	return res
+}
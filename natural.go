@@ -0,0 +1,83 @@
+// Package compress
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package compress
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// NaturalLess reports whether a sorts before b in the natural order: the runs of digits
+// are compared by their numeric value, as "page2" before "page10", and the other
+// characters are compared without case. The equal names are ordered by their bytes.
+func NaturalLess(a, b string) bool {
+	if c := naturalCompare(a, b); c != 0 {
+		return c < 0
+	}
+	return a < b
+}
+
+func naturalCompare(a, b string) int {
+	for a != "" && b != "" {
+		if isDigit(a[0]) && isDigit(b[0]) {
+			var x, y string
+			x, a = digits(a)
+			y, b = digits(b)
+			tx, ty := strings.TrimLeft(x, "0"), strings.TrimLeft(y, "0")
+			switch {
+			case len(tx) != len(ty):
+				return sign(len(tx) - len(ty))
+			case tx != ty:
+				return strings.Compare(tx, ty)
+			case len(x) != len(y):
+				// "01" after "1"
+				return sign(len(x) - len(y))
+			}
+			continue
+		}
+		ra, na := utf8.DecodeRuneInString(a)
+		rb, nb := utf8.DecodeRuneInString(b)
+		if ra, rb = unicode.ToLower(ra), unicode.ToLower(rb); ra != rb {
+			return sign(int(ra) - int(rb))
+		}
+		a, b = a[na:], b[nb:]
+	}
+	return sign(len(a) - len(b))
+}
+
+func isDigit(c byte) bool { return '0' <= c && c <= '9' }
+
+// digits splits the leading run of digits of s.
+func digits(s string) (string, string) {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
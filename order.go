@@ -0,0 +1,158 @@
+// Package compress
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package compress
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"sort"
+)
+
+// DirOrder is the order of the directory entries of the archive, set by FileSystem.DirOrder.
+type DirOrder int
+
+const (
+	// DirOrderDefault leaves the order to the decoder, fs.ReadDir and fs.WalkDir sort
+	// the entries by name.
+	DirOrderDefault DirOrder = iota
+	// DirOrderArchive lists the entries in the order they are stored in the archive.
+	DirOrderArchive
+	// DirOrderName lists the entries sorted by name, the same as fs.ReadDir.
+	DirOrderName
+	// DirOrderNatural lists the entries sorted by NaturalLess of their names.
+	DirOrderNatural
+)
+
+// ArchiveOrderFS is implemented by the archive fs.FS whose directories are not read
+// in the archive order, ReadDirArchive reads the named directory in the archive order.
+type ArchiveOrderFS interface {
+	fs.FS
+
+	ReadDirArchive(name string) ([]fs.DirEntry, error)
+}
+
+// dirLess returns the comparison of the directory entries of order, nil for the archive order.
+func dirLess(order DirOrder) func(a, b fs.DirEntry) bool {
+	switch order {
+	case DirOrderName:
+		return func(a, b fs.DirEntry) bool { return a.Name() < b.Name() }
+	case DirOrderNatural:
+		return func(a, b fs.DirEntry) bool { return NaturalLess(a.Name(), b.Name()) }
+	}
+	return nil
+}
+
+// orderFS lists the directories of the archive in the order of less, or in the
+// archive order if less is nil.
+type orderFS struct {
+	fs.FS
+	less func(a, b fs.DirEntry) bool
+}
+
+func (o *orderFS) Open(name string) (fs.File, error) {
+	f, err := o.FS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := f.Stat(); err == nil && info.IsDir() {
+		if d, ok := f.(fs.ReadDirFile); ok {
+			return &orderDir{ReadDirFile: d, fsys: o, name: name}, nil
+		}
+	}
+	return f, nil
+}
+
+// ReadDir reads the named directory in the order of o.
+func (o *orderFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	var entries []fs.DirEntry
+	if a, ok := o.FS.(ArchiveOrderFS); ok {
+		var err error
+		if entries, err = a.ReadDirArchive(name); err != nil {
+			return nil, err
+		}
+	} else {
+		f, err := o.FS.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		//goland:noinspection ALL
+		defer f.Close()
+		d, ok := f.(fs.ReadDirFile)
+		if !ok {
+			return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not implemented")}
+		}
+		if entries, err = d.ReadDir(-1); err != nil {
+			return nil, err
+		}
+	}
+	if o.less != nil {
+		sort.SliceStable(entries, func(i, j int) bool { return o.less(entries[i], entries[j]) })
+	}
+	return entries, nil
+}
+
+func (o *orderFS) ReadLink(name string) (string, error) { return ReadLink(o.FS, name) }
+
+func (o *orderFS) Lstat(name string) (fs.FileInfo, error) { return Lstat(o.FS, name) }
+
+func (o *orderFS) ArchiveComment() string { return ArchiveComment(o.FS) }
+
+func (o *orderFS) Collisions() [][]string { return Collisions(o.FS) }
+
+// Stream calls fn in the archive order if the archive implements Streamer, or in the
+// order of o otherwise.
+func (o *orderFS) Stream(fn StreamFunc) error {
+	if s, ok := o.FS.(Streamer); ok {
+		return s.Stream(fn)
+	}
+	return walkStream(o, fn)
+}
+
+// orderDir reads the directory in the order of its fs.FS.
+type orderDir struct {
+	fs.ReadDirFile
+	fsys    *orderFS
+	name    string
+	entries []fs.DirEntry
+	read    bool
+}
+
+func (d *orderDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if !d.read {
+		entries, err := d.fsys.ReadDir(d.name)
+		if err != nil {
+			return nil, err
+		}
+		d.entries, d.read = entries, true
+	}
+	if n <= 0 {
+		entries := d.entries
+		d.entries = nil
+		return entries, nil
+	}
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(d.entries) {
+		n = len(d.entries)
+	}
+	entries := d.entries[:n]
+	d.entries = d.entries[n:]
+	return entries, nil
+}
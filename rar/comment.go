@@ -0,0 +1,364 @@
+// Package rar
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package rar
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+
+	"github.com/nwaples/rardecode/v2"
+)
+
+const (
+	maxSfxSize = 0x100000 // same as rardecode
+	sigPrefix  = "Rar!\x1A\x07"
+
+	// rar 1.5 - 4.x blocks
+	block15Arc     = 0x73
+	block15File    = 0x74
+	block15Comment = 0x75
+	block15Service = 0x7a
+	block15End     = 0x7b
+	block15HasData = 0x8000
+	arc15Comment   = 0x0002
+	arc15Encrypted = 0x0080
+	file15Large    = 0x0100
+
+	// rar 5 blocks
+	block5Arc      = 1
+	block5File     = 2
+	block5Service  = 3
+	block5Encrypt  = 4
+	block5End      = 5
+	block5HasExtra = 0x0001
+	block5HasData  = 0x0002
+	file5HasMtime  = 0x0002
+	file5HasCRC32  = 0x0004
+)
+
+var (
+	errCorruptComment = errors.New("rar: corrupt comment header")
+	errCorruptBlock   = errors.New("rar: corrupt block size")
+)
+
+// blockReader reads the block headers of a rar volume, the sizes are bounded by
+// the volume size and the block data are skipped by seeking if r is an io.Seeker.
+type blockReader struct {
+	*bufio.Reader
+	r     io.Reader
+	limit int64
+}
+
+func newBlockReader(r io.Reader, size int64) *blockReader {
+	return &blockReader{Reader: bufio.NewReader(r), r: r, limit: size}
+}
+
+// next returns the next n bytes.
+func (br *blockReader) next(n uint64) ([]byte, error) {
+	if n > uint64(br.limit) {
+		return nil, errCorruptBlock
+	}
+	b := make([]byte, n)
+	_, err := io.ReadFull(br, b)
+	return b, err
+}
+
+// skip skips the n bytes of the block data.
+func (br *blockReader) skip(n uint64) error {
+	if n > uint64(br.limit) {
+		return errCorruptBlock
+	}
+	if buffered := uint64(br.Buffered()); n > buffered {
+		if s, ok := br.r.(io.Seeker); ok {
+			if _, err := s.Seek(int64(n-buffered), io.SeekCurrent); err != nil {
+				return err
+			}
+			br.Reset(br.r)
+			return nil
+		}
+	}
+	_, err := io.CopyN(io.Discard, br, int64(n))
+	return err
+}
+
+// readComment returns the archive comment of the first rar volume r of size bytes,
+// or "" if it has none or its headers are encrypted.
+func readComment(r io.Reader, size int64) (string, error) {
+	br := newBlockReader(r, size)
+	ver, err := findSignature(br.Reader)
+	if err != nil || ver < 0 {
+		return "", err
+	}
+	if ver == 0 {
+		return readComment15(br)
+	}
+	return readComment50(br)
+}
+
+// findSignature skip the self-extracting stub if any, and the signature of the archive.
+// It returns the format version (0: rar 1.5 - 4.x, 1: rar 5), or -1 if not found.
+func findSignature(br *bufio.Reader) (int, error) {
+	for off := 0; ; off++ {
+		if off > maxSfxSize {
+			return -1, nil
+		}
+		b, err := br.Peek(len(sigPrefix) + 2)
+		if err != nil {
+			return -1, err
+		}
+		if string(b[:len(sigPrefix)]) == sigPrefix {
+			switch {
+			case b[len(sigPrefix)] == 0:
+				_, _ = br.Discard(len(sigPrefix) + 1)
+				return 0, nil
+			case b[len(sigPrefix)] == 1 && b[len(sigPrefix)+1] == 0:
+				_, _ = br.Discard(len(sigPrefix) + 2)
+				return 1, nil
+			}
+		}
+		_, _ = br.Discard(1)
+	}
+}
+
+// ------ rar 1.5 - 4.x ------
+
+func readComment15(br *blockReader) (string, error) {
+	for {
+		var head [7]byte
+		if _, err := io.ReadFull(br, head[:]); err != nil {
+			return "", err
+		}
+		htype := head[2]
+		flags := binary.LittleEndian.Uint16(head[3:])
+		size := int(binary.LittleEndian.Uint16(head[5:]))
+		if size < len(head) {
+			return "", errCorruptComment
+		}
+		data := make([]byte, size-len(head))
+		if _, err := io.ReadFull(br, data); err != nil {
+			return "", err
+		}
+		var dataSize uint64
+		if flags&block15HasData != 0 {
+			if len(data) < 4 {
+				return "", errCorruptComment
+			}
+			dataSize = uint64(binary.LittleEndian.Uint32(data))
+		}
+
+		switch htype {
+		case block15Arc:
+			if flags&arc15Encrypted != 0 {
+				return "", nil
+			}
+			// old style comment, inside the archive header
+			if flags&arc15Comment != 0 && len(data) >= 6+13 && data[6+2] == block15Comment {
+				cmt := data[6:]
+				method := cmt[10]
+				if method != 0x30 {
+					return "", nil // rar 1.5 compression is not supported by rardecode
+				}
+				n := int(binary.LittleEndian.Uint16(cmt[5:]))
+				if n < 13 || n > len(cmt) {
+					return "", errCorruptComment
+				}
+				return string(cmt[13:n]), nil
+			}
+		case block15Service:
+			if len(data) < 25 {
+				return "", errCorruptComment
+			}
+			if flags&file15Large != 0 {
+				if len(data) < 33 {
+					return "", errCorruptComment
+				}
+				dataSize |= uint64(binary.LittleEndian.Uint32(data[25:])) << 32
+			}
+			nameSize := int(binary.LittleEndian.Uint16(data[19:]))
+			name := data[25:]
+			if flags&file15Large != 0 {
+				name = name[8:]
+			}
+			if len(name) < nameSize {
+				return "", errCorruptComment
+			}
+			if string(name[:nameSize]) != "CMT" {
+				break
+			}
+			packed, err := br.next(dataSize)
+			if err != nil {
+				return "", err
+			}
+			if data[18] == 0x30 {
+				return string(packed), nil
+			}
+			return unpackComment15(head, flags, data, packed)
+		case block15File, block15End:
+			return "", nil
+		}
+		if err := br.skip(dataSize); err != nil {
+			return "", err
+		}
+	}
+}
+
+// unpackComment15 decompress the comment by rardecode, as a file of a rar 4 archive.
+func unpackComment15(head [7]byte, flags uint16, data, packed []byte) (string, error) {
+	var arc bytes.Buffer
+	arc.WriteString(sigPrefix + "\x00")
+	writeBlock15(&arc, block15Arc, 0, make([]byte, 6))
+
+	file := append([]byte(nil), data[:25]...)
+	file = append(file, 'C', 'M', 'T')
+	binary.LittleEndian.PutUint32(file, uint32(len(packed)))
+	binary.LittleEndian.PutUint16(file[19:], 3)
+	writeBlock15(&arc, block15File, block15HasData|flags&0x00e0, file)
+	arc.Write(packed)
+	writeBlock15(&arc, block15End, 0, nil)
+	return unpackComment(&arc)
+}
+
+func writeBlock15(w *bytes.Buffer, htype byte, flags uint16, data []byte) {
+	b := make([]byte, 7, 7+len(data))
+	b[2] = htype
+	binary.LittleEndian.PutUint16(b[3:], flags)
+	binary.LittleEndian.PutUint16(b[5:], uint16(7+len(data)))
+	b = append(b, data...)
+	binary.LittleEndian.PutUint16(b, uint16(crc32.ChecksumIEEE(b[2:])))
+	w.Write(b)
+}
+
+// ------ rar 5 ------
+
+func readComment50(br *blockReader) (string, error) {
+	for {
+		var crc [4]byte
+		if _, err := io.ReadFull(br, crc[:]); err != nil {
+			return "", err
+		}
+		size, err := binary.ReadUvarint(br)
+		if err != nil {
+			return "", err
+		}
+		data, err := br.next(size)
+		if err != nil {
+			return "", err
+		}
+		b := bytes.NewReader(data)
+		htype, _ := binary.ReadUvarint(b)
+		flags, _ := binary.ReadUvarint(b)
+		var extraSize, dataSize uint64
+		if flags&block5HasExtra != 0 {
+			extraSize, _ = binary.ReadUvarint(b)
+		}
+		if flags&block5HasData != 0 {
+			dataSize, _ = binary.ReadUvarint(b)
+		}
+
+		switch htype {
+		case block5Service:
+			fields := data[len(data)-b.Len():]
+			fileFlags, _ := binary.ReadUvarint(b)
+			_, _ = binary.ReadUvarint(b) // unpacked size
+			_, _ = binary.ReadUvarint(b) // attributes
+			if fileFlags&file5HasMtime != 0 {
+				_, _ = b.Seek(4, io.SeekCurrent)
+			}
+			if fileFlags&file5HasCRC32 != 0 {
+				_, _ = b.Seek(4, io.SeekCurrent)
+			}
+			method, _ := binary.ReadUvarint(b)
+			_, _ = binary.ReadUvarint(b) // host os
+			nameSize, _ := binary.ReadUvarint(b)
+			if nameSize > uint64(b.Len()) || extraSize > uint64(b.Len())-nameSize {
+				return "", errCorruptComment
+			}
+			name := make([]byte, nameSize)
+			_, _ = b.Read(name)
+			if string(name) != "CMT" {
+				break
+			}
+			if extraSize > 0 {
+				return "", nil // encrypted comment
+			}
+			packed, err := br.next(dataSize)
+			if err != nil {
+				return "", err
+			}
+			if (method>>7)&7 == 0 {
+				return string(packed), nil
+			}
+			return unpackComment50(fields[:len(fields)-int(extraSize)], packed)
+		case block5Encrypt, block5File, block5End:
+			return "", nil
+		}
+		if err = br.skip(dataSize); err != nil {
+			return "", err
+		}
+	}
+}
+
+// unpackComment50 decompress the comment by rardecode, as a file of a rar 5 archive.
+func unpackComment50(fields, packed []byte) (string, error) {
+	var arc bytes.Buffer
+	arc.WriteString(sigPrefix + "\x01\x00")
+	writeBlock50(&arc, block5Arc, 0, 0, []byte{0})
+	writeBlock50(&arc, block5File, block5HasData, uint64(len(packed)), fields)
+	arc.Write(packed)
+	writeBlock50(&arc, block5End, 0, 0, []byte{0})
+	return unpackComment(&arc)
+}
+
+func writeBlock50(w *bytes.Buffer, htype, flags, dataSize uint64, fields []byte) {
+	var head []byte
+	head = appendUvarint(head, htype)
+	head = appendUvarint(head, flags)
+	if flags&block5HasData != 0 {
+		head = appendUvarint(head, dataSize)
+	}
+	head = append(head, fields...)
+	b := appendUvarint(make([]byte, 4), uint64(len(head)))
+	b = append(b, head...)
+	binary.LittleEndian.PutUint32(b, crc32.ChecksumIEEE(b[4:]))
+	w.Write(b)
+}
+
+func appendUvarint(b []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], v)
+	return append(b, buf[:n]...)
+}
+
+func unpackComment(arc io.Reader) (string, error) {
+	r, err := rardecode.NewReader(arc)
+	if err != nil {
+		return "", err
+	}
+	if _, err = r.Next(); err != nil {
+		return "", err
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
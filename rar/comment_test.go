@@ -0,0 +1,73 @@
+// Package rar
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package rar
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"testing"
+)
+
+const comment = "archive comment\r\nsecond line\r\n"
+
+var commentFixtures = []string{
+	"testdata/rar4-stored.rar", // the CMT service header, stored
+	"testdata/rar4-packed.rar", // the CMT service header, compressed
+	"testdata/rar4-old.rar",    // the comment of RAR 2.x, inside the archive header
+	"testdata/rar5-stored.rar",
+	"testdata/rar5-packed.rar",
+}
+
+func TestArchiveComment(t *testing.T) {
+	for _, fixture := range commentFixtures {
+		rc := &ReadCloser{}
+		fsys, err := rc.OpenReader(fixture)
+		if err != nil {
+			t.Fatalf("%s: %v", fixture, err)
+		}
+		if got := fsys.(*ReadCloser).ArchiveComment(); got != comment {
+			t.Errorf("%s: comment %q", fixture, got)
+		}
+		if data, err := fs.ReadFile(fsys, "hello.txt"); err != nil || string(data) != "hello, world\n" {
+			t.Errorf("%s: hello.txt %q, %v", fixture, data, err)
+		}
+		_ = fsys.(*ReadCloser).Close()
+	}
+}
+
+// TestCommentTruncated reads the comments of the truncated and corrupt archives,
+// which fail or have no comment, but do not panic.
+func TestCommentTruncated(t *testing.T) {
+	for _, fixture := range commentFixtures {
+		data, err := os.ReadFile(fixture)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for n := 0; n < len(data); n++ {
+			if got, err := readComment(bytes.NewReader(data[:n]), int64(n)); err == nil && got != "" && got != comment {
+				t.Errorf("%s truncated at %d: comment %q", fixture, n, got)
+			}
+		}
+		for off := 0; off < len(data); off++ {
+			b := append([]byte(nil), data...)
+			b[off] ^= 0xff
+			_, _ = readComment(bytes.NewReader(b), int64(len(b)))
+		}
+	}
+}
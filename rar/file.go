@@ -34,6 +34,7 @@ type File struct {
 	isDir  bool
 	size   int64
 	mode   fs.FileMode
+	link   string
 
 	dirReadAt  int
 	dirEntries func(path string, n int) ([]fs.DirEntry, error)
@@ -62,22 +63,33 @@ func (f *File) OpenFile() error {
 		err := rc.Close()
 		f.rcRead = nil
 		f.close = nil
-		f.fileOpen = nil
-		f.header = nil
 		return err
 	}
 	return nil
 }
 
-// ------ to fs.FileInfo ------
-
-func (f *File) Mode() fs.FileMode {
-	if f.header == nil {
-		return f.mode
+// readLink returns the symbolic link target, stored in the rar 5 header or as the file data.
+func (f *File) readLink() (string, error) {
+	if f.link != "" {
+		return f.link, nil
+	}
+	rc, err := f.fileOpen()
+	if err != nil {
+		return "", err
 	}
-	return f.header.Mode()
+	//goland:noinspection ALL
+	defer rc.Close()
+	target, err := io.ReadAll(rc)
+	if err != nil {
+		return "", err
+	}
+	return string(target), nil
 }
 
+// ------ to fs.FileInfo ------
+
+func (f *File) Mode() fs.FileMode { return f.mode }
+
 func (f *File) ModTime() time.Time {
 	if f.header == nil {
 		return time.Time{}
@@ -114,7 +126,7 @@ func (f *File) Name() string {
 	return path.Base(f.name)
 }
 
-func (f *File) Type() fs.FileMode { return f.mode }
+func (f *File) Type() fs.FileMode { return f.mode.Type() }
 
 func (f *File) Info() (fs.FileInfo, error) { return f, nil }
 
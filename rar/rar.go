@@ -35,8 +35,16 @@ type ReadCloser struct {
 	dirs    map[string]int
 	files   map[string]int
 	index   []*File
+	comment string
+	links   map[string]string
 
 	root fs.FileInfo
+	fsys fs.FS
+	path string
+	opts []rardecode.Option
+
+	match compress.LookupOption
+	names *compress.NameIndex
 }
 
 func (rc *ReadCloser) Name() string { return "rar" }
@@ -45,6 +53,14 @@ func (rc *ReadCloser) SetRootInfo(info os.FileInfo) { rc.root = info }
 
 func (rc *ReadCloser) SetCharset(_ []encoding.Encoding, _ bool) {}
 
+// SetLookup set the matching of the names which are not found as is.
+func (rc *ReadCloser) SetLookup(opt compress.LookupOption) { rc.match = opt }
+
+// SetFileSystem set the fs.FS to open the archive and its volumes from
+// (embedded, in-memory or inside another archive), the path of OpenReader is
+// then a name of fsys. nil is the OS file system.
+func (rc *ReadCloser) SetFileSystem(fsys fs.FS) { rc.fsys = fsys }
+
 // OpenReader will open the 7-zip file specified by name and return a
 // ReadCloser. If name has a ".001" suffix it is assumed there are multiple
 // volumes and each sequential volume will be opened.
@@ -57,16 +73,21 @@ func (rc *ReadCloser) OpenReader(path string) (fs.FS, error) {
 // name has a ".001" suffix it is assumed there are multiple volumes and each
 // sequential volume will be opened.
 func (rc *ReadCloser) OpenReaderWithPassword(path, pwd string) (fs.FS, error) {
-	var vfs fs.FS
 	opts := []rardecode.Option{
-		rardecode.FileSystem(vfs),
+		rardecode.FileSystem(rc.fsys),
 	}
 	if pwd != "" {
 		opts = append(opts, rardecode.Password(pwd))
 	}
 	files, err := rardecode.List(path, opts...)
 	if err != nil {
-		return nil, err
+		return nil, volumeError(path, err)
+	}
+	root := rc.root
+	if root == nil {
+		if root, err = rc.stat(path); err != nil {
+			return nil, err
+		}
 	}
 
 	maxIdx := 0
@@ -77,12 +98,31 @@ func (rc *ReadCloser) OpenReaderWithPassword(path, pwd string) (fs.FS, error) {
 		dirs:  map[string]int{},
 		files: map[string]int{},
 		index: []*File{},
-		root:  rc.root,
+		root:  root,
+		fsys:  rc.fsys,
+		path:  path,
+		opts:  opts,
+		match: rc.match,
+	}
+	// the comment and link targets are optional, ignore their errors
+	if f, err := res.open(path); err == nil {
+		res.comment, _ = readComment(f, root.Size())
+		_ = f.Close()
+	}
+	if hasLinks(files) {
+		if f, err := res.open(path); err == nil {
+			res.links, _ = readLinks(f, root.Size())
+			_ = f.Close()
+		}
 	}
 	for _, file := range files {
 		mode := file.Mode()
 		header := file.FileHeader
 		entry := &File{header: &header, size: 0, mode: mode}
+		if target, ok := res.links[header.Name]; ok {
+			entry.link = target
+			entry.mode |= os.ModeSymlink
+		}
 		if mode.IsDir() {
 			entry.isDir = true
 			entry.name = strings.TrimRight(header.Name, "/")
@@ -92,7 +132,7 @@ func (rc *ReadCloser) OpenReaderWithPassword(path, pwd string) (fs.FS, error) {
 		} else {
 			entry.name = header.Name
 			entry.size = header.UnPackedSize
-			entry.fileOpen = file.Open
+			entry.fileOpen = volumeOpen(path, file.Open)
 			res.files[entry.name] = maxIdx
 			// Add index to dir entries
 			dir := filepath.Dir(entry.name)
@@ -112,10 +152,17 @@ func (rc *ReadCloser) OpenReaderWithPassword(path, pwd string) (fs.FS, error) {
 		isDir:      true,
 		dirEntries: res.GetDirEntries,
 	})
+	res.indexNames()
 
 	return res, nil
 }
 
+// ArchiveComment returns the archive comment.
+//
+// * The entries have no comment (compress.EntryCommenter), they are only stored
+// by RAR 2.x which rardecode does not report.
+func (rc *ReadCloser) ArchiveComment() string { return rc.comment }
+
 // Open opens the named file in the 7-zip file, using the semantics of fs.FS.Open:
 // paths are always slash separated, with no leading / or ../ elements.
 func (rc *ReadCloser) Open(name string) (fs.File, error) {
@@ -131,7 +178,88 @@ func (rc *ReadCloser) Open(name string) (fs.File, error) {
 	if ok {
 		return rc.getFile(idx)
 	}
-	return nil, &fs.PathError{Op: "info", Path: name, Err: fs.ErrNotExist}
+	found, err := rc.resolve("info", name)
+	if err != nil {
+		return nil, err
+	}
+	return rc.Open(found)
+}
+
+// Lstat returns the fs.FileInfo of the named file, the symbolic link is not followed.
+func (rc *ReadCloser) Lstat(name string) (fs.FileInfo, error) {
+	file, err := rc.lookup("lstat", name)
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// ReadLink returns the destination of the named symbolic link.
+func (rc *ReadCloser) ReadLink(name string) (string, error) {
+	file, err := rc.lookup("readlink", name)
+	if err != nil {
+		return "", err
+	}
+	if file.mode&os.ModeSymlink == 0 {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+	target, err := file.readLink()
+	if err != nil {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: err}
+	}
+	return target, nil
+}
+
+// lookup returns the named file without opening it.
+func (rc *ReadCloser) lookup(op, name string) (*File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	if idx, ok := rc.dirs[name]; ok {
+		return rc.index[idx], nil
+	}
+	if idx, ok := rc.files[name]; ok {
+		return rc.index[idx], nil
+	}
+	found, err := rc.resolve(op, name)
+	if err != nil {
+		return nil, err
+	}
+	return rc.lookup(op, found)
+}
+
+// indexNames builds the index of the names matched by the lookup options.
+func (rc *ReadCloser) indexNames() {
+	if rc.match == 0 {
+		return
+	}
+	rc.names = compress.NewNameIndex(rc.match)
+	for name := range rc.dirs {
+		rc.names.Add(name)
+	}
+	for name := range rc.files {
+		rc.names.Add(name)
+	}
+}
+
+// resolve returns the name of the archive matching name by the lookup options.
+func (rc *ReadCloser) resolve(op, name string) (string, error) {
+	if rc.names == nil {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	found, err := rc.names.Find(name)
+	if err != nil {
+		return "", &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	return found, nil
+}
+
+// Collisions returns the names of the archive which match the same name by the lookup options.
+func (rc *ReadCloser) Collisions() [][]string {
+	if rc.names == nil {
+		return nil
+	}
+	return rc.names.Collisions()
 }
 
 func (rc *ReadCloser) GetDirEntries(path string, n int) ([]fs.DirEntry, error) {
@@ -178,7 +306,7 @@ func (rc *ReadCloser) GetDirEntries(path string, n int) ([]fs.DirEntry, error) {
 }
 
 func (rc *ReadCloser) getFile(idx int) (*File, error) {
-	if idx > len(rc.index) || idx < 0 {
+	if idx >= len(rc.index) || idx < 0 {
 		return nil, fs.ErrInvalid
 	}
 	file := rc.index[idx]
@@ -0,0 +1,79 @@
+// Package rar
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package rar
+
+import (
+	"io"
+	"io/fs"
+	"strings"
+
+	"github.com/nwaples/rardecode/v2"
+
+	"github.com/pashifika/compress"
+)
+
+// Stream calls fn for each entry in the archive order, the archive is decoded
+// in one pass by rardecode.Reader, so a solid archive is not decoded again for each file.
+// The content of a symbolic link is its target.
+func (rc *ReadCloser) Stream(fn compress.StreamFunc) error {
+	r, err := rardecode.OpenReader(rc.path, rc.opts...)
+	if err != nil {
+		return volumeError(rc.path, err)
+	}
+	//goland:noinspection ALL
+	defer r.Close()
+
+	for {
+		header, err := r.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return volumeError(rc.path, err)
+		}
+		name, file, err := rc.streamEntry(header)
+		if err != nil {
+			return err
+		}
+		var body io.Reader = &volumeReader{ReadCloser: io.NopCloser(r), path: rc.path}
+		switch {
+		case file.isDir:
+			body = strings.NewReader("")
+		case file.link != "":
+			body = strings.NewReader(file.link)
+		}
+		if err = fn(name, file, body); err != nil {
+			return err
+		}
+	}
+}
+
+// streamEntry returns the indexed file of the header, by the name OpenReader indexed
+// it with. The name is not checked by fs.ValidPath, so the stream does not fail on
+// a name like "./a" or "a\b" which Open does not accept.
+func (rc *ReadCloser) streamEntry(header *rardecode.FileHeader) (string, *File, error) {
+	if header.Mode().IsDir() {
+		name := strings.TrimRight(header.Name, "/")
+		if idx, ok := rc.dirs[name]; ok {
+			return name, rc.index[idx], nil
+		}
+	} else if idx, ok := rc.files[header.Name]; ok {
+		return header.Name, rc.index[idx], nil
+	}
+	return "", nil, &fs.PathError{Op: "stream", Path: header.Name, Err: fs.ErrNotExist}
+}
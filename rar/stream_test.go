@@ -0,0 +1,56 @@
+// Package rar
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package rar
+
+import (
+	"io"
+	"io/fs"
+	"reflect"
+	"testing"
+)
+
+// TestStreamNames streams the entries whose names are not valid for fs.ValidPath.
+func TestStreamNames(t *testing.T) {
+	rc := &ReadCloser{}
+	fsys, err := rc.OpenReader("testdata/rar5-names.rar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	//goland:noinspection ALL
+	defer fsys.(*ReadCloser).Close()
+
+	var names []string
+	err = fsys.(*ReadCloser).Stream(func(name string, info fs.FileInfo, r io.Reader) error {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		if string(data) != "hello, world\n" {
+			t.Errorf("%s: %q", name, data)
+		}
+		names = append(names, name)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"./dot.txt", `back\slash.txt`, "hello.txt"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("names %q, want %q", names, want)
+	}
+}
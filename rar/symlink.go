@@ -0,0 +1,156 @@
+// Package rar
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package rar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/nwaples/rardecode/v2"
+)
+
+const (
+	extra5Redirection = 5
+	redir5UnixSymlink = 1
+	redir5WinSymlink  = 2
+
+	fileAttributeReparsePoint = 0x400
+)
+
+var errCorruptLink = errors.New("rar: corrupt redirection record")
+
+// readLinks returns the symbolic link targets of the first rar volume r of size bytes
+// by the entry name, rardecode does not report them.
+//
+// * rar 5 stores the target in the redirection record of the file header,
+// rar 1.5 - 4.x stores it as the file data, so nil is returned.
+func readLinks(r io.Reader, size int64) (map[string]string, error) {
+	br := newBlockReader(r, size)
+	ver, err := findSignature(br.Reader)
+	if err != nil || ver != 1 {
+		return nil, err
+	}
+
+	links := map[string]string{}
+	for {
+		var crc [4]byte
+		if _, err = io.ReadFull(br, crc[:]); err != nil {
+			return links, err
+		}
+		size, err := binary.ReadUvarint(br)
+		if err != nil {
+			return links, err
+		}
+		data, err := br.next(size)
+		if err != nil {
+			return links, err
+		}
+		b := bytes.NewReader(data)
+		htype, _ := binary.ReadUvarint(b)
+		flags, _ := binary.ReadUvarint(b)
+		var extraSize, dataSize uint64
+		if flags&block5HasExtra != 0 {
+			extraSize, _ = binary.ReadUvarint(b)
+		}
+		if flags&block5HasData != 0 {
+			dataSize, _ = binary.ReadUvarint(b)
+		}
+
+		switch htype {
+		case block5File:
+			fileFlags, _ := binary.ReadUvarint(b)
+			_, _ = binary.ReadUvarint(b) // unpacked size
+			_, _ = binary.ReadUvarint(b) // attributes
+			if fileFlags&file5HasMtime != 0 {
+				_, _ = b.Seek(4, io.SeekCurrent)
+			}
+			if fileFlags&file5HasCRC32 != 0 {
+				_, _ = b.Seek(4, io.SeekCurrent)
+			}
+			_, _ = binary.ReadUvarint(b) // compression
+			_, _ = binary.ReadUvarint(b) // host os
+			nameSize, _ := binary.ReadUvarint(b)
+			if nameSize > uint64(b.Len()) || extraSize > uint64(b.Len())-nameSize {
+				return links, errCorruptLink
+			}
+			name := make([]byte, nameSize)
+			_, _ = b.Read(name)
+			target, err := readRedirection(data[len(data)-int(extraSize):])
+			if err != nil {
+				return links, err
+			}
+			if target != "" {
+				links[string(name)] = target
+			}
+		case block5Encrypt, block5End:
+			return links, nil
+		}
+		if err = br.skip(dataSize); err != nil {
+			return links, err
+		}
+	}
+}
+
+// hasLinks reports whether files has symbolic links, so their targets must be read.
+func hasLinks(files []*rardecode.File) bool {
+	for _, file := range files {
+		if file.Mode()&os.ModeSymlink != 0 ||
+			file.HostOS == rardecode.HostOSWindows && file.Attributes&fileAttributeReparsePoint != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// readRedirection returns the symbolic link target of the rar 5 extra area,
+// or "" if the entry is not a symbolic link.
+func readRedirection(extra []byte) (string, error) {
+	b := bytes.NewReader(extra)
+	for b.Len() > 0 {
+		size, err := binary.ReadUvarint(b)
+		if err != nil || size > uint64(b.Len()) {
+			return "", errCorruptLink
+		}
+		record := make([]byte, size)
+		_, _ = b.Read(record)
+		rb := bytes.NewReader(record)
+		ftype, _ := binary.ReadUvarint(rb)
+		if ftype != extra5Redirection {
+			continue
+		}
+		rtype, _ := binary.ReadUvarint(rb)
+		_, _ = binary.ReadUvarint(rb) // flags
+		nameSize, _ := binary.ReadUvarint(rb)
+		if nameSize > uint64(rb.Len()) {
+			return "", errCorruptLink
+		}
+		name := make([]byte, nameSize)
+		_, _ = rb.Read(name)
+		switch rtype {
+		case redir5UnixSymlink:
+			return string(name), nil
+		case redir5WinSymlink:
+			return strings.ReplaceAll(string(name), `\`, "/"), nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,97 @@
+// Package rar
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package rar
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/pashifika/compress"
+)
+
+// MissingVolumeError is returned when a volume of a multi-volume archive is absent.
+type MissingVolumeError struct {
+	Volume string // the name of the missing volume
+	Err    error
+}
+
+func (e *MissingVolumeError) Error() string {
+	return "rar: missing volume " + e.Volume + "\n  error: " + e.Err.Error()
+}
+
+func (e *MissingVolumeError) Unwrap() error { return e.Err }
+
+// Is reports the error matches compress.ErrMissingVolume.
+func (e *MissingVolumeError) Is(target error) bool { return target == compress.ErrMissingVolume }
+
+// volumeError returns the MissingVolumeError if err is the not exist error
+// of a volume following the archive path.
+func volumeError(path string, err error) error {
+	var pe *fs.PathError
+	if !errors.As(err, &pe) || !errors.Is(pe.Err, fs.ErrNotExist) {
+		return err
+	}
+	if filepath.Clean(pe.Path) == filepath.Clean(path) {
+		return err
+	}
+	return &MissingVolumeError{Volume: pe.Path, Err: err}
+}
+
+// volumeOpen wraps the file open of rardecode, a file continued in the next
+// volume is opened again when it is read.
+func volumeOpen(path string, open func() (io.ReadCloser, error)) func() (io.ReadCloser, error) {
+	return func() (io.ReadCloser, error) {
+		rc, err := open()
+		if err != nil {
+			return nil, volumeError(path, err)
+		}
+		return &volumeReader{ReadCloser: rc, path: path}, nil
+	}
+}
+
+type volumeReader struct {
+	io.ReadCloser
+	path string
+}
+
+func (r *volumeReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if err != nil && err != io.EOF {
+		err = volumeError(r.path, err)
+	}
+	return n, err
+}
+
+// open opens the named file of the file system.
+func (rc *ReadCloser) open(name string) (io.ReadCloser, error) {
+	if rc.fsys != nil {
+		return rc.fsys.Open(name)
+	}
+	return os.Open(name)
+}
+
+// stat returns the fs.FileInfo of the named file of the file system.
+func (rc *ReadCloser) stat(name string) (fs.FileInfo, error) {
+	if rc.fsys != nil {
+		return fs.Stat(rc.fsys, name)
+	}
+	return os.Stat(name)
+}
@@ -20,6 +20,7 @@ package compress
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 )
 
@@ -45,8 +46,49 @@ func RegisterEncoder(encode Encoder) {
 	encoders[name] = encode
 }
 
+// RegisterExtension registers the file extension ext as an alias of the decoder and
+// the encoder named name, as ".cbz" for "zip". It should be called during init.
+//
+// FileSystem tries the decoder of the extension first, and CreateArchiverFile accepts
+// the extension without the dot as the encoder name.
+func RegisterExtension(ext, name string) {
+	ext = "." + strings.TrimLeft(strings.ToLower(ext), ".")
+	if _, ok := extensions[ext]; ok {
+		fmt.Println("extension " + ext + " is already registered")
+		os.Exit(2)
+	}
+	extensions[ext] = strings.TrimLeft(strings.ToLower(name), ".")
+}
+
+// decodersOf returns the registered decoders, the decoder of the extension of path is the first.
+func decodersOf(path string) []Decoder {
+	list := make([]Decoder, 0, len(decoders))
+	first, ok := decoders[extensions[strings.ToLower(filepath.Ext(path))]]
+	if ok {
+		list = append(list, first)
+	}
+	for _, decoder := range decoders {
+		if !ok || decoder != first {
+			list = append(list, decoder)
+		}
+	}
+	return list
+}
+
+// encoderOf returns the encoder registered by name, or by the extension name.
+func encoderOf(name string) (Encoder, bool) {
+	if encoder, ok := encoders[name]; ok {
+		return encoder, true
+	}
+	encoder, ok := encoders[extensions["."+strings.TrimLeft(strings.ToLower(name), ".")]]
+	return encoder, ok
+}
+
 // Registered Decoder.
 var decoders = make(map[string]Decoder)
 
 // Registered Encoder.
 var encoders = make(map[string]Encoder)
+
+// Registered extension aliases.
+var extensions = make(map[string]string)
@@ -0,0 +1,218 @@
+// Package rpm
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package rpm
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+
+	"golang.org/x/text/encoding"
+
+	"github.com/pashifika/compress"
+	"github.com/pashifika/compress/cpio"
+)
+
+const (
+	leadLen      = 96
+	maxIndex     = 1 << 16
+	maxStoreSize = 256 << 20
+
+	tagName              = 1000
+	tagVersion           = 1001
+	tagRelease           = 1002
+	tagArch              = 1022
+	tagPayloadFormat     = 1124
+	tagPayloadCompressor = 1125
+
+	typeString = 6
+)
+
+var (
+	leadMagic   = []byte{0xed, 0xab, 0xee, 0xdb}
+	headerMagic = []byte{0x8e, 0xad, 0xe8, 0x01}
+
+	errNotRpm  = errors.New("rpm: not a rpm package")
+	errHeader  = errors.New("rpm: invalid header")
+	errPayload = errors.New("rpm: the payload is not cpio")
+)
+
+// Header is the package information of the rpm header.
+type Header struct {
+	Name              string
+	Version           string
+	Release           string
+	Arch              string
+	PayloadFormat     string
+	PayloadCompressor string
+}
+
+// ReadCloser presents the cpio payload of a rpm package, which may be compressed
+// by gzip, bzip2, xz or zstd.
+type ReadCloser struct {
+	file   *os.File
+	header Header
+	cpio   *cpio.ReadCloser
+
+	root fs.FileInfo
+}
+
+func (rc *ReadCloser) Name() string { return "rpm" }
+
+func (rc *ReadCloser) SetRootInfo(info os.FileInfo) { rc.root = info }
+
+func (rc *ReadCloser) SetCharset(_ []encoding.Encoding, _ bool) {}
+
+// Header returns the package information.
+func (rc *ReadCloser) Header() Header { return rc.header }
+
+func (rc *ReadCloser) OpenReader(path string) (fs.FS, error) {
+	return rc.OpenReaderWithPassword(path, "")
+}
+
+// OpenReaderWithPassword is the same as OpenReader, rpm has no password.
+func (rc *ReadCloser) OpenReaderWithPassword(path, _ string) (fs.FS, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	res, err := rc.open(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return res, nil
+}
+
+func (rc *ReadCloser) open(f *os.File) (*ReadCloser, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	res := &ReadCloser{file: f, root: rc.root}
+	if res.root == nil {
+		res.root = info
+	}
+
+	lead := make([]byte, leadLen)
+	if _, err = f.ReadAt(lead, 0); err != nil || string(lead[:4]) != string(leadMagic) {
+		return nil, errNotRpm
+	}
+	// the signature header is padded to 8 bytes
+	sigLen, _, err := readHeader(f, leadLen)
+	if err != nil {
+		return nil, err
+	}
+	offset := leadLen + sigLen + (8-sigLen%8)%8
+	hdrLen, tags, err := readHeader(f, offset)
+	if err != nil {
+		return nil, err
+	}
+	offset += hdrLen
+	res.header = Header{
+		Name:              tags[tagName],
+		Version:           tags[tagVersion],
+		Release:           tags[tagRelease],
+		Arch:              tags[tagArch],
+		PayloadFormat:     tags[tagPayloadFormat],
+		PayloadCompressor: tags[tagPayloadCompressor],
+	}
+	if format := res.header.PayloadFormat; format != "" && format != "cpio" {
+		return nil, errPayload
+	}
+
+	reader := &cpio.ReadCloser{}
+	reader.SetRootInfo(res.root)
+	if res.cpio, err = reader.NewReader(io.NewSectionReader(f, offset, info.Size()-offset), info.Size()-offset); err != nil {
+		return nil, &fs.PathError{Op: "payload", Path: res.root.Name(), Err: err}
+	}
+	return res, nil
+}
+
+// readHeader reads the header structure at offset, it returns the header length
+// and the string tags.
+func readHeader(r io.ReaderAt, offset int64) (int64, map[int]string, error) {
+	intro := make([]byte, 16)
+	if _, err := r.ReadAt(intro, offset); err != nil || string(intro[:4]) != string(headerMagic) {
+		return 0, nil, errHeader
+	}
+	count := binary.BigEndian.Uint32(intro[8:12])
+	size := binary.BigEndian.Uint32(intro[12:16])
+	if count > maxIndex || size > maxStoreSize {
+		return 0, nil, errHeader
+	}
+	index := make([]byte, 16*int(count))
+	if _, err := r.ReadAt(index, offset+16); err != nil {
+		return 0, nil, errHeader
+	}
+	store := make([]byte, size)
+	if _, err := r.ReadAt(store, offset+16+int64(len(index))); err != nil {
+		return 0, nil, errHeader
+	}
+
+	tags := map[int]string{}
+	for i := 0; i < len(index); i += 16 {
+		tag := int(binary.BigEndian.Uint32(index[i:]))
+		typ := binary.BigEndian.Uint32(index[i+4:])
+		off := binary.BigEndian.Uint32(index[i+8:])
+		if typ != typeString || off >= size {
+			continue
+		}
+		value := string(store[off:])
+		if end := strings.IndexByte(value, 0); end >= 0 {
+			value = value[:end]
+		}
+		tags[tag] = value
+	}
+	return 16 + int64(len(index)) + int64(size), tags, nil
+}
+
+// Open opens the named file in the payload, using the semantics of fs.FS.Open:
+// paths are always slash separated, with no leading / or ../ elements.
+func (rc *ReadCloser) Open(name string) (fs.File, error) { return rc.cpio.Open(name) }
+
+// Lstat returns the fs.FileInfo of the named file, the symbolic link is not followed.
+func (rc *ReadCloser) Lstat(name string) (fs.FileInfo, error) { return rc.cpio.Lstat(name) }
+
+// ReadLink returns the destination of the named symbolic link.
+func (rc *ReadCloser) ReadLink(name string) (string, error) { return rc.cpio.ReadLink(name) }
+
+func (rc *ReadCloser) GetDirEntries(path string, n int) ([]fs.DirEntry, error) {
+	return rc.cpio.GetDirEntries(path, n)
+}
+
+// Stream calls fn for each entry of the payload in the archive order, the payload is read in one pass.
+func (rc *ReadCloser) Stream(fn compress.StreamFunc) error { return rc.cpio.Stream(fn) }
+
+func (rc *ReadCloser) Reset() {
+	*rc = ReadCloser{}
+}
+
+// Close closes the rpm file, rendering it unusable for I/O.
+func (rc *ReadCloser) Close() error {
+	if rc == nil || rc.file == nil {
+		return nil
+	}
+	_ = rc.cpio.Close()
+	err := rc.file.Close()
+	rc.Reset()
+	return err
+}
@@ -0,0 +1,61 @@
+// Package rpm
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package rpm
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/pashifika/compress/internal/archivetest"
+)
+
+const fixture = "testdata/test.rpm"
+
+func open(path string) (fs.FS, error) { return (&ReadCloser{}).OpenReader(path) }
+
+// TestReader reads the package of a gzip compressed newc payload.
+func TestReader(t *testing.T) {
+	archivetest.Check(t, open, fixture, map[string]string{
+		"hello.txt":     "hello, world\n",
+		"dir/lorem.txt": strings.Repeat("lorem ipsum dolor sit amet\n", 100),
+	})
+	fsys, err := open(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fsys.(*ReadCloser).Close()
+	want := Header{
+		Name: "test", Version: "1.0", Release: "1", Arch: "noarch",
+		PayloadFormat: "cpio", PayloadCompressor: "gzip",
+	}
+	if got := fsys.(*ReadCloser).Header(); got != want {
+		t.Errorf("header %+v, want %+v", got, want)
+	}
+}
+
+func TestTruncated(t *testing.T) { archivetest.Truncated(t, open, fixture) }
+
+func TestCorrupt(t *testing.T) {
+	archivetest.Corrupt(t, open, fixture, 0, 0)                            // lead magic
+	archivetest.Corrupt(t, open, fixture, leadLen, 0)                      // signature magic
+	archivetest.Corrupt(t, open, fixture, leadLen+16, 0)                   // header magic
+	archivetest.Corrupt(t, open, fixture, leadLen+16+8, 0xff, 0xff, 0xff)  // index count
+	archivetest.Corrupt(t, open, fixture, leadLen+16+12, 0xff, 0xff, 0xff) // store size
+	archivetest.Mangle(t, open, fixture, leadLen, leadLen+16+16+6*16)
+}
@@ -0,0 +1,96 @@
+// Package single
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package single
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/pashifika/compress"
+	"github.com/pashifika/compress/internal/codec"
+)
+
+var errOneFile = errors.New("single: exactly one file is required")
+
+type WriteCloser struct {
+	format codec.Format
+	close  func() error
+}
+
+// Name returns the compression extension without the dot, such as "gz".
+func (wc *WriteCloser) Name() string { return strings.TrimPrefix(wc.format.Ext(), ".") }
+
+// SetCompressedExt has no effect, the file is always compressed.
+func (wc *WriteCloser) SetCompressedExt(_ map[string]struct{}) {}
+
+// Create compress the only file of entries to w, the directories are skipped.
+func (wc *WriteCloser) Create(w io.Writer, entries []compress.ArchiverFile) error {
+	var (
+		file compress.ArchiverFile
+		idx  int
+	)
+	for i, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if file != nil || !entry.Mode().IsRegular() {
+			return errOneFile
+		}
+		file, idx = entry, i
+	}
+	if file == nil {
+		return errOneFile
+	}
+
+	root := file.Root()
+	var (
+		zw  io.WriteCloser
+		err error
+	)
+	if wc.format == codec.Gzip {
+		gw := gzip.NewWriter(w)
+		gw.Name = path.Base(strings.ReplaceAll(root, "\\", "/"))
+		gw.ModTime = file.ModTime()
+		gw.Comment = compress.EntryComment(file)
+		zw = gw
+	} else if zw, err = codec.NewWriter(wc.format, w); err != nil {
+		return err
+	}
+	if _, err = io.Copy(zw, file); err != nil {
+		// release the codec writer, such as the zstd encoder blocks in flight
+		_ = zw.Close()
+		return fmt.Errorf("writing file [%d] %s\n  error: %w", idx, root, err)
+	}
+	return zw.Close()
+}
+
+func (wc *WriteCloser) Close() error {
+	if wc.close != nil {
+		return wc.close()
+	}
+	return nil
+}
+
+// Reset keeps the compression format of the encoder.
+func (wc *WriteCloser) Reset() {
+	*wc = WriteCloser{format: wc.format}
+}
@@ -0,0 +1,127 @@
+// Package single
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package single
+
+import (
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"sync"
+	"time"
+
+	"github.com/pashifika/compress"
+)
+
+type File struct {
+	header  *gzip.Header // nil if the file is not gzip
+	name    string
+	isDir   bool
+	mode    fs.FileMode
+	modTime time.Time
+
+	// the size is counted at the first call of Size
+	size     int64
+	sizeOnce sync.Once
+	sizeOf   func() (int64, error)
+
+	dirReadAt  int
+	dirEntries func(path string, n int) ([]fs.DirEntry, error)
+	fileOpen   func(f *File) (io.ReadCloser, error)
+	rcRead     func(p []byte) (n int, err error)
+	close      func() error
+}
+
+func (f *File) Root() string { return f.name }
+
+func (f *File) IsDir() bool { return f.isDir }
+
+// Size returns the size of the decompressed file, it is decompressed once to be counted.
+func (f *File) Size() int64 {
+	if f.sizeOf != nil {
+		f.sizeOnce.Do(func() { f.size, _ = f.sizeOf() })
+	}
+	return f.size
+}
+
+func (f *File) Write(_ []byte) (n int, err error) {
+	return 0, compress.ErrWriterNotSupport
+}
+
+func (f *File) OpenFile() error {
+	rc, err := f.fileOpen(f)
+	if err != nil {
+		return err
+	}
+	f.rcRead = rc.Read
+	f.close = func() error {
+		err := rc.Close()
+		f.rcRead = nil
+		f.close = nil
+		return err
+	}
+	return nil
+}
+
+// EntryComment returns the gzip header comment.
+func (f *File) EntryComment() string {
+	if f.header == nil {
+		return ""
+	}
+	return f.header.Comment
+}
+
+// ------ to fs.FileInfo ------
+
+func (f *File) Mode() fs.FileMode { return f.mode }
+
+func (f *File) ModTime() time.Time { return f.modTime }
+
+func (f *File) Sys() interface{} {
+	if f.header == nil {
+		return nil
+	}
+	return f.header
+}
+
+// ------ to fs.File ------
+
+func (f *File) Stat() (fs.FileInfo, error) { return f, nil }
+
+func (f *File) Read(b []byte) (int, error) { return f.rcRead(b) }
+
+func (f *File) Close() error {
+	if f.close != nil {
+		return f.close()
+	}
+	return nil
+}
+
+// ------ to fs.DirEntry ------
+
+func (f *File) Name() string { return f.name }
+
+func (f *File) Type() fs.FileMode { return f.mode.Type() }
+
+func (f *File) Info() (fs.FileInfo, error) { return f, nil }
+
+func (f *File) ReadDir(n int) ([]fs.DirEntry, error) {
+	if f.dirEntries != nil {
+		return f.dirEntries(f.name, n)
+	}
+	return nil, fs.ErrNotExist
+}
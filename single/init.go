@@ -0,0 +1,31 @@
+// Package single
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package single
+
+import (
+	"github.com/pashifika/compress"
+	"github.com/pashifika/compress/internal/codec"
+)
+
+func init() {
+	compress.RegisterDecoder(&ReadCloser{})
+	compress.RegisterEncoder(&WriteCloser{format: codec.Gzip})
+	compress.RegisterEncoder(&WriteCloser{format: codec.Bzip2})
+	compress.RegisterEncoder(&WriteCloser{format: codec.Xz})
+	compress.RegisterEncoder(&WriteCloser{format: codec.Zstd})
+}
@@ -0,0 +1,235 @@
+// Package single
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package single
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/text/encoding"
+
+	"github.com/pashifika/compress"
+	"github.com/pashifika/compress/internal/codec"
+)
+
+var errNotCompressed = errors.New("single: not a compressed file")
+
+// ReadCloser presents a gzip, bzip2, xz or zstd compressed file as an fs.FS
+// containing the decompressed file. The compressed tar and cpio archives are not
+// opened, they are left to the tar and cpio packages.
+type ReadCloser struct {
+	file   *os.File
+	size   int64
+	format codec.Format
+	entry  *File
+	dir    *File
+
+	root fs.FileInfo
+}
+
+func (rc *ReadCloser) Name() string { return "single" }
+
+func (rc *ReadCloser) SetRootInfo(info os.FileInfo) { rc.root = info }
+
+func (rc *ReadCloser) SetCharset(_ []encoding.Encoding, _ bool) {}
+
+func (rc *ReadCloser) OpenReader(path string) (fs.FS, error) {
+	return rc.OpenReaderWithPassword(path, "")
+}
+
+// OpenReaderWithPassword is the same as OpenReader, the compressed files have no password.
+func (rc *ReadCloser) OpenReaderWithPassword(path, _ string) (fs.FS, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	res, err := rc.open(f, path)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return res, nil
+}
+
+func (rc *ReadCloser) open(f *os.File, name string) (*ReadCloser, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	res := &ReadCloser{file: f, size: info.Size(), root: rc.root}
+	if res.root == nil {
+		res.root = info
+	}
+	br := bufio.NewReader(io.NewSectionReader(f, 0, res.size))
+	if res.format = codec.Detect(br); res.format == codec.None {
+		return nil, errNotCompressed
+	}
+	zr, err := codec.NewReader(res.format, br)
+	if err != nil {
+		return nil, err
+	}
+	//goland:noinspection ALL
+	defer zr.Close()
+	head := make([]byte, 512)
+	n, err := io.ReadFull(zr, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	if isArchive(head[:n]) || n == len(head) && zeros(bytes.NewReader(head)) && zeros(zr) {
+		// the zero blocks are an empty tar
+		return nil, errNotCompressed
+	}
+
+	res.entry = &File{
+		name:     entryName(filepath.Base(name), res.format),
+		mode:     res.root.Mode().Perm(),
+		modTime:  res.root.ModTime(),
+		fileOpen: res.openEntry,
+		sizeOf:   res.entrySize,
+	}
+	if gz, ok := zr.(*gzip.Reader); ok {
+		if gz.Name != "" {
+			res.entry.name = entryName(gz.Name, codec.None)
+		}
+		if !gz.ModTime.IsZero() {
+			res.entry.modTime = gz.ModTime
+		}
+		res.entry.header = &gz.Header
+	}
+	res.dir = &File{
+		name:       compress.DefaultArchiverRoot,
+		mode:       res.root.Mode() + os.ModeDir,
+		modTime:    res.root.ModTime(),
+		isDir:      true,
+		dirEntries: res.GetDirEntries,
+	}
+	return res, nil
+}
+
+// entryName returns the name of the decompressed file, the extension of format is removed.
+func entryName(name string, format codec.Format) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	if ext := format.Ext(); ext != "" && len(name) > len(ext) && strings.EqualFold(name[len(name)-len(ext):], ext) {
+		name = name[:len(name)-len(ext)]
+	}
+	if name == "" || name == "." || name == ".." || name == "/" {
+		return "data"
+	}
+	return name
+}
+
+// isArchive reports whether head is the start of a cpio or tar archive.
+func isArchive(head []byte) bool {
+	for _, magic := range []string{"070701", "070702", "070707"} {
+		if bytes.HasPrefix(head, []byte(magic)) {
+			return true
+		}
+	}
+	if len(head) < 512 {
+		return false
+	}
+	_, err := tar.NewReader(bytes.NewReader(head)).Next()
+	return err == nil || err == io.ErrUnexpectedEOF
+}
+
+// zeros reports whether the rest of r is only zero bytes.
+func zeros(r io.Reader) bool {
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := r.Read(buf)
+		if len(bytes.Trim(buf[:n], "\x00")) > 0 {
+			return false
+		}
+		if err == io.EOF {
+			return true
+		}
+		if err != nil {
+			return false
+		}
+	}
+}
+
+// openEntry returns the decompressor of the file.
+func (rc *ReadCloser) openEntry(_ *File) (io.ReadCloser, error) {
+	return codec.NewReader(rc.format, bufio.NewReader(io.NewSectionReader(rc.file, 0, rc.size)))
+}
+
+// Open opens the named file, using the semantics of fs.FS.Open:
+// paths are always slash separated, with no leading / or ../ elements.
+func (rc *ReadCloser) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	switch name {
+	case compress.DefaultArchiverRoot:
+		return rc.dir, nil
+	case rc.entry.name:
+		if err := rc.entry.OpenFile(); err != nil {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+		return rc.entry, nil
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+func (rc *ReadCloser) GetDirEntries(path string, n int) ([]fs.DirEntry, error) {
+	if path != compress.DefaultArchiverRoot {
+		return nil, fs.ErrNotExist
+	}
+	if n > 0 {
+		if rc.dir.dirReadAt > 0 {
+			return nil, io.EOF
+		}
+		rc.dir.dirReadAt = 1
+	}
+	return []fs.DirEntry{rc.entry}, nil
+}
+
+func (rc *ReadCloser) Reset() {
+	*rc = ReadCloser{}
+}
+
+// Close closes the compressed file, rendering it unusable for I/O.
+func (rc *ReadCloser) Close() error {
+	if rc == nil || rc.file == nil {
+		return nil
+	}
+	err := rc.file.Close()
+	rc.Reset()
+	return err
+}
+
+// entrySize decompress the file to count its size, which is not stored by the formats.
+func (rc *ReadCloser) entrySize() (int64, error) {
+	r, err := rc.openEntry(rc.entry)
+	if err != nil {
+		return 0, err
+	}
+	//goland:noinspection ALL
+	defer r.Close()
+	return io.Copy(io.Discard, r)
+}
@@ -0,0 +1,69 @@
+// Package compress
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package compress
+
+import (
+	"io"
+	"io/fs"
+	"strings"
+)
+
+// StreamFunc is called by Stream for each entry of the archive,
+// r is the entry content (empty for directories) which is valid until it returns.
+type StreamFunc func(name string, info fs.FileInfo, r io.Reader) error
+
+// Streamer is implemented by the archive fs.FS which can read all the entries
+// in one pass, in the archive order, decoding each solid block once.
+type Streamer interface {
+	Stream(fn StreamFunc) error
+}
+
+// Stream calls fn for each entry of fsys, in the archive order if fsys implements
+// Streamer, or in the fs.WalkDir order otherwise. The root is not included.
+func Stream(fsys fs.FS, fn StreamFunc) error {
+	if s, ok := fsys.(Streamer); ok {
+		return s.Stream(fn)
+	}
+	return walkStream(fsys, fn)
+}
+
+// walkStream calls fn for each entry of fsys in the fs.WalkDir order.
+func walkStream(fsys fs.FS, fn StreamFunc) error {
+	return fs.WalkDir(fsys, DefaultArchiverRoot, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if name == DefaultArchiverRoot {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return fn(name, info, strings.NewReader(""))
+		}
+		f, err := fsys.Open(name)
+		if err != nil {
+			return err
+		}
+		//goland:noinspection ALL
+		defer f.Close()
+		return fn(name, info, f)
+	})
+}
@@ -0,0 +1,192 @@
+// Package compress
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package compress
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// MaxSymlinks is the maximum number of symbolic links followed to resolve a name.
+const MaxSymlinks = 40
+
+// ReadLinkFS is implemented by the archive fs.FS which support symbolic links,
+// it is the same as fs.ReadLinkFS of Go 1.25.
+type ReadLinkFS interface {
+	fs.FS
+
+	// ReadLink returns the destination of the named symbolic link,
+	// the name itself is not resolved.
+	ReadLink(name string) (string, error)
+
+	// Lstat returns a FileInfo describing the named file, if the file is
+	// a symbolic link, the returned FileInfo describes the link.
+	Lstat(name string) (fs.FileInfo, error)
+}
+
+// ReadLink returns the destination of the named symbolic link of fsys.
+func ReadLink(fsys fs.FS, name string) (string, error) {
+	if l, ok := fsys.(ReadLinkFS); ok {
+		return l.ReadLink(name)
+	}
+	return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+}
+
+// Lstat returns a FileInfo describing the named file of fsys without following
+// the symbolic link, fs.Stat is used if fsys does not support symbolic links.
+func Lstat(fsys fs.FS, name string) (fs.FileInfo, error) {
+	if l, ok := fsys.(ReadLinkFS); ok {
+		return l.Lstat(name)
+	}
+	return fs.Stat(fsys, name)
+}
+
+// linkFS follow the symbolic links of the archive when opening a name.
+type linkFS struct {
+	ReadLinkFS
+}
+
+func (l *linkFS) Open(name string) (fs.File, error) {
+	resolved, err := resolveLink(l.ReadLinkFS, "open", name, true)
+	if err != nil {
+		return nil, err
+	}
+	f, err := l.ReadLinkFS.Open(resolved)
+	return f, linkError(err, name)
+}
+
+func (l *linkFS) Stat(name string) (fs.FileInfo, error) {
+	resolved, err := resolveLink(l.ReadLinkFS, "stat", name, true)
+	if err != nil {
+		return nil, err
+	}
+	info, err := l.ReadLinkFS.Lstat(resolved)
+	return info, linkError(err, name)
+}
+
+func (l *linkFS) ReadLink(name string) (string, error) {
+	resolved, err := resolveLink(l.ReadLinkFS, "readlink", name, false)
+	if err != nil {
+		return "", err
+	}
+	target, err := l.ReadLinkFS.ReadLink(resolved)
+	return target, linkError(err, name)
+}
+
+func (l *linkFS) Lstat(name string) (fs.FileInfo, error) {
+	resolved, err := resolveLink(l.ReadLinkFS, "lstat", name, false)
+	if err != nil {
+		return nil, err
+	}
+	info, err := l.ReadLinkFS.Lstat(resolved)
+	return info, linkError(err, name)
+}
+
+func (l *linkFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	resolved, err := resolveLink(l.ReadLinkFS, "readdir", name, true)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := fs.ReadDir(l.ReadLinkFS, resolved)
+	return entries, linkError(err, name)
+}
+
+func (l *linkFS) ArchiveComment() string { return ArchiveComment(l.ReadLinkFS) }
+
+func (l *linkFS) Collisions() [][]string { return Collisions(l.ReadLinkFS) }
+
+func (l *linkFS) StrippedPrefix() string { return StrippedPrefix(l.ReadLinkFS) }
+
+func (l *linkFS) Stream(fn StreamFunc) error { return Stream(l.ReadLinkFS, fn) }
+
+// linkError report the fs.PathError by the name before resolving.
+func linkError(err error, name string) error {
+	if pe, ok := err.(*fs.PathError); ok {
+		return &fs.PathError{Op: pe.Op, Path: name, Err: pe.Err}
+	}
+	return err
+}
+
+// resolveLink returns the name of fsys with all symbolic links of its directories
+// resolved, the last element is also resolved if last is true.
+//
+// * The link targets are relative to the directory of the link, an absolute target
+// or one leading outside the archive root returns ErrSymlinkEscape.
+func resolveLink(fsys ReadLinkFS, op, name string, last bool) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	if name == DefaultArchiverRoot {
+		return name, nil
+	}
+
+	var (
+		resolved string
+		links    int
+		elems    = strings.Split(name, "/")
+	)
+	for len(elems) > 0 {
+		elem := elems[0]
+		elems = elems[1:]
+		switch elem {
+		case "", ".":
+			continue
+		case "..":
+			if resolved == "" {
+				return "", &fs.PathError{Op: op, Path: name, Err: ErrSymlinkEscape}
+			}
+			if resolved = path.Dir(resolved); resolved == "." {
+				resolved = ""
+			}
+			continue
+		}
+
+		next := path.Join(resolved, elem)
+		if len(elems) == 0 && !last {
+			resolved = next
+			continue
+		}
+		info, err := fsys.Lstat(next)
+		if err != nil {
+			// let the fs.FS report the missing name
+			return path.Join(append([]string{next}, elems...)...), nil
+		}
+		if info.Mode()&fs.ModeSymlink == 0 {
+			resolved = next
+			continue
+		}
+
+		if links++; links > MaxSymlinks {
+			return "", &fs.PathError{Op: op, Path: name, Err: ErrSymlinkLoop}
+		}
+		target, err := fsys.ReadLink(next)
+		if err != nil {
+			return "", err
+		}
+		if strings.HasPrefix(target, "/") || len(target) > 1 && target[1] == ':' {
+			return "", &fs.PathError{Op: op, Path: name, Err: ErrSymlinkEscape}
+		}
+		elems = append(strings.Split(target, "/"), elems...)
+	}
+
+	if resolved == "" {
+		return DefaultArchiverRoot, nil
+	}
+	return resolved, nil
+}
@@ -0,0 +1,80 @@
+// Package compress
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package compress_test
+
+import (
+	"archive/tar"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pashifika/compress"
+	_ "github.com/pashifika/compress/tar"
+)
+
+// createTar writes the archive of the top directory with a file and a link to it.
+func createTar(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.tar")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	tw := tar.NewWriter(f)
+	for _, h := range []*tar.Header{
+		{Name: "top/", Typeflag: tar.TypeDir, Mode: 0755},
+		{Name: "top/a.txt", Typeflag: tar.TypeReg, Mode: 0644, Size: 5},
+		{Name: "top/link", Typeflag: tar.TypeSymlink, Linkname: "a.txt", Mode: 0777},
+	} {
+		if err = tw.WriteHeader(h); err != nil {
+			t.Fatal(err)
+		}
+		if h.Size > 0 {
+			if _, err = tw.Write([]byte("hello")); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err = tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestFlattenSymlinks checks the flattened archive which follows the symbolic links.
+func TestFlattenSymlinks(t *testing.T) {
+	cfs := &compress.FileSystem{Flatten: true, FollowSymlinks: true}
+	fsys, err := cfs.Open(createTar(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	//goland:noinspection ALL
+	defer cfs.Close()
+
+	if prefix := compress.StrippedPrefix(fsys); prefix != "top" {
+		t.Errorf("stripped prefix %q", prefix)
+	}
+	if data, err := fs.ReadFile(fsys, "link"); err != nil || string(data) != "hello" {
+		t.Errorf("link %q, %v", data, err)
+	}
+	if target, err := compress.ReadLink(fsys, "link"); err != nil || target != "a.txt" {
+		t.Errorf("link target %q, %v", target, err)
+	}
+}
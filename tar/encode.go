@@ -0,0 +1,129 @@
+// Package tar
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package tar
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+
+	"github.com/pashifika/compress"
+	"github.com/pashifika/compress/internal/codec"
+)
+
+type WriteCloser struct {
+	format codec.Format
+	close  func() error
+}
+
+// Name returns "tar", or the name with the compression extension such as "tar.gz".
+func (wc *WriteCloser) Name() string { return "tar" + wc.format.Ext() }
+
+// SetCompressedExt has no effect, the tar is compressed as a whole.
+func (wc *WriteCloser) SetCompressedExt(_ map[string]struct{}) {}
+
+func (wc *WriteCloser) Create(w io.Writer, entries []compress.ArchiverFile) error {
+	zw, err := codec.NewWriter(wc.format, w)
+	if err != nil {
+		return err
+	}
+	tw := tar.NewWriter(zw)
+	for i, entry := range entries {
+		if err = writeEntry(tw, i, entry); err != nil {
+			// release the codec writer, such as the zstd encoder blocks in flight
+			_ = zw.Close()
+			return err
+		}
+	}
+	if err = tw.Close(); err != nil {
+		_ = zw.Close()
+		return err
+	}
+	return zw.Close()
+}
+
+func writeEntry(tw *tar.Writer, i int, entry compress.ArchiverFile) error {
+	root := strings.TrimLeft(entry.Root(), "/")
+	var (
+		body   io.Reader = entry
+		target string
+	)
+	if entry.Mode()&fs.ModeSymlink != 0 {
+		// symbolic links store the link target in the header
+		if l, ok := entry.(compress.SymlinkFile); ok {
+			t, err := l.LinkTarget()
+			if err != nil {
+				return fmt.Errorf("reading link [%d] %s\n  error: %w", i, root, err)
+			}
+			target = t
+		} else {
+			t, err := io.ReadAll(entry)
+			if err != nil {
+				return fmt.Errorf("reading link [%d] %s\n  error: %w", i, root, err)
+			}
+			target = string(t)
+		}
+		body = nil
+	}
+	header, err := tar.FileInfoHeader(entry, target)
+	if err != nil {
+		return fmt.Errorf("writing header [%d] %s\n  error: %w", i, root, err)
+	}
+	header.Name = root
+	if entry.IsDir() {
+		if !strings.HasSuffix(header.Name, "/") {
+			header.Name += "/"
+		}
+		body = nil
+	}
+	if h, ok := entry.Sys().(*Header); ok {
+		// copied from a tar archive
+		header.Uname, header.Gname = h.Uname, h.Gname
+		if h.Typeflag == tar.TypeLink {
+			header.Typeflag, header.Linkname, header.Size = tar.TypeLink, h.Linkname, 0
+		}
+	}
+	if o, ok := entry.Sys().(compress.Owner); ok {
+		if uid, gid, ok := o.Owner(); ok {
+			header.Uid, header.Gid = uid, gid
+		}
+	}
+	if err = tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("writing header [%d] %s\n  error: %w", i, root, err)
+	}
+	if body != nil && header.Typeflag == tar.TypeReg {
+		if _, err = io.Copy(tw, body); err != nil {
+			return fmt.Errorf("writing file [%d] %s\n  error: %w", i, root, err)
+		}
+	}
+	return nil
+}
+
+func (wc *WriteCloser) Close() error {
+	if wc.close != nil {
+		return wc.close()
+	}
+	return nil
+}
+
+// Reset keeps the compression format of the encoder.
+func (wc *WriteCloser) Reset() {
+	*wc = WriteCloser{format: wc.format}
+}
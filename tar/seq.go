@@ -0,0 +1,171 @@
+// Package tar
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package tar
+
+import (
+	"archive/tar"
+	"bufio"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/pashifika/compress"
+	"github.com/pashifika/compress/internal/codec"
+)
+
+// cursor is the tar reader of the archive at the entry seq.
+type cursor struct {
+	tr  *tar.Reader
+	zr  io.ReadCloser
+	seq int // the entry read by the last Next, -1 before the first
+}
+
+// newCursor returns the cursor at the start of the archive.
+func (rc *ReadCloser) newCursor() (*cursor, error) {
+	br := bufio.NewReader(io.NewSectionReader(rc.r, 0, rc.size))
+	zr, err := codec.NewReader(rc.format, br)
+	if err != nil {
+		return nil, err
+	}
+	return &cursor{tr: tar.NewReader(zr), zr: zr, seq: -1}, nil
+}
+
+// next reads the tar headers up to the entry seq.
+func (c *cursor) next(seq int) error {
+	for c.seq < seq {
+		if _, err := c.tr.Next(); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return err
+		}
+		c.seq++
+	}
+	return nil
+}
+
+func (c *cursor) close() error { return c.zr.Close() }
+
+// openEntry returns the reader of f, the data is read at random if the tar is not
+// compressed, otherwise the reader of the previous entry is reused if it is
+// before f, instead of decoding the archive from the start.
+func (rc *ReadCloser) openEntry(f *File) (io.ReadCloser, error) {
+	if f.link != "" {
+		return ioutil.NopCloser(strings.NewReader(f.link)), nil
+	}
+	if f.hard != nil {
+		f = f.hard
+	}
+	if f.offset >= 0 {
+		return ioutil.NopCloser(io.NewSectionReader(rc.r, f.offset, f.size)), nil
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	c := rc.cursor
+	rc.cursor = nil
+	if c != nil && c.seq >= f.seq {
+		_ = c.close()
+		c = nil
+	}
+	if c == nil {
+		var err error
+		if c, err = rc.newCursor(); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.next(f.seq); err != nil {
+		_ = c.close()
+		return nil, err
+	}
+	return &entryReader{rc: rc, c: c}, nil
+}
+
+// entryReader reads an entry from the cursor, and gives back the cursor when it is closed.
+type entryReader struct {
+	rc *ReadCloser
+	c  *cursor
+}
+
+func (r *entryReader) Read(p []byte) (int, error) {
+	if r.c == nil {
+		return 0, io.ErrClosedPipe
+	}
+	return r.c.tr.Read(p)
+}
+
+func (r *entryReader) Close() error {
+	if r.c == nil {
+		return nil
+	}
+	r.rc.mu.Lock()
+	defer r.rc.mu.Unlock()
+	c := r.c
+	r.c = nil
+	if r.rc.cursor != nil {
+		if r.rc.cursor.seq >= c.seq {
+			return c.close()
+		}
+		_ = r.rc.cursor.close()
+	}
+	r.rc.cursor = c
+	return nil
+}
+
+// Stream calls fn for each entry in the archive order, the archive is read in one pass.
+// The content of a symbolic link is its target, and the content of a hard link is
+// the content of its target.
+func (rc *ReadCloser) Stream(fn compress.StreamFunc) error {
+	c, err := rc.newCursor()
+	if err != nil {
+		return err
+	}
+	//goland:noinspection ALL
+	defer c.close()
+
+	for seq, file := range rc.seq {
+		if file == nil {
+			continue
+		}
+		if err = c.next(seq); err != nil {
+			return err
+		}
+		var body io.Reader = c.tr
+		switch {
+		case file.isDir:
+			body = strings.NewReader("")
+		case file.link != "":
+			body = strings.NewReader(file.link)
+		case file.hard != nil:
+			r, err := rc.openEntry(file)
+			if err != nil {
+				return err
+			}
+			err = fn(file.name, file, r)
+			_ = r.Close()
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if err = fn(file.name, file, body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
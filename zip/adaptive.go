@@ -0,0 +1,90 @@
+// Package zip
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package zip
+
+import (
+	"bytes"
+	"compress/flate"
+	"io"
+
+	"github.com/pashifika/compress/internal/std_zip"
+)
+
+const (
+	// DefaultSampleSize is the number of bytes trial-compressed per entry in adaptive mode.
+	DefaultSampleSize = 64 * 1024
+	// DefaultThreshold is the minimal saving ratio that Deflate must reach in adaptive mode.
+	DefaultThreshold = 0.05
+)
+
+// adaptive chooses Store or Deflate from a compressed sample of each entry.
+type adaptive struct {
+	sampleSize int
+	threshold  float64
+	sample     bytes.Buffer
+	fw         *flate.Writer
+}
+
+// choose reads a sample from r and trial-compresses it.
+// It returns the compression method and a reader that yields the whole content of r.
+func (a *adaptive) choose(r io.Reader) (uint16, io.Reader, error) {
+	a.sample.Reset()
+	n, err := io.CopyN(&a.sample, r, int64(a.sampleSize))
+	if err != nil && err != io.EOF {
+		return 0, nil, err
+	}
+	body := io.MultiReader(bytes.NewReader(a.sample.Bytes()), r)
+	if n == 0 {
+		return std_zip.Store, body, nil
+	}
+
+	cw := &countWriter{w: io.Discard}
+	if a.fw == nil {
+		a.fw, _ = flate.NewWriter(cw, 5)
+	} else {
+		a.fw.Reset(cw)
+	}
+	if _, err = a.fw.Write(a.sample.Bytes()); err != nil {
+		return 0, nil, err
+	}
+	if err = a.fw.Close(); err != nil {
+		return 0, nil, err
+	}
+
+	saving := 1 - float64(cw.count)/float64(n)
+	if saving < a.threshold {
+		return std_zip.Store, body, nil
+	}
+	return std_zip.Deflate, body, nil
+}
+
+// clone returns a copy of a with its own buffers, for use by another goroutine.
+func (a *adaptive) clone() *adaptive {
+	return &adaptive{sampleSize: a.sampleSize, threshold: a.threshold}
+}
+
+type countWriter struct {
+	w     io.Writer
+	count int64
+}
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.count += int64(n)
+	return n, err
+}
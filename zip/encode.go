@@ -18,8 +18,10 @@
 package zip
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"path"
 	"strings"
 
@@ -29,6 +31,12 @@ import (
 
 type WriteCloser struct {
 	extensions map[string]struct{}
+	adaptive   *adaptive
+	parallel   *parallel
+	split      *split
+	stub       io.Reader
+	comment    string
+	owner      bool
 	close      func() error
 }
 
@@ -36,50 +44,122 @@ func (wc *WriteCloser) Name() string { return _zipName }
 
 func (wc *WriteCloser) SetCompressedExt(ext map[string]struct{}) { wc.extensions = ext }
 
+// SetAdaptive enable trial-compress the first sampleSize bytes of each entry,
+// and Store the entry when Deflate saves less than threshold (0.05 = 5%) of the sample.
+//
+// * sampleSize <= 0 is disable adaptive mode.
+func (wc *WriteCloser) SetAdaptive(sampleSize int, threshold float64) {
+	if sampleSize <= 0 {
+		wc.adaptive = nil
+		return
+	}
+	wc.adaptive = &adaptive{sampleSize: sampleSize, threshold: threshold}
+}
+
+// SetComment set the archive comment, the entry comments are set by the
+// ArchiverFile implementing compress.EntryCommenter.
+func (wc *WriteCloser) SetComment(comment string) { wc.comment = comment }
+
+// SetOwner enable storing the Unix owner (uid/gid) of the entries in the
+// Info-ZIP Unix extra fields, it is disabled by default.
+func (wc *WriteCloser) SetOwner(enable bool) { wc.owner = enable }
+
+// SetStub set the data written before the archive by the next Create,
+// such as a self-extracting executable. The offsets of the archive include the stub,
+// so it is readable without adjustment.
+func (wc *WriteCloser) SetStub(stub io.Reader) { wc.stub = stub }
+
 func (wc *WriteCloser) Create(w io.Writer, entries []compress.ArchiverFile) error {
+	var offset int64
+	if wc.stub != nil {
+		if wc.split != nil {
+			return errors.New("zip: stub is not supported by split archive")
+		}
+		n, err := io.Copy(w, wc.stub)
+		if err != nil {
+			return fmt.Errorf("writing stub\n  error: %w", err)
+		}
+		offset = n
+		wc.stub = nil
+	}
+
 	zip := std_zip.NewWriter(w)
-	//goland:noinspection ALL
-	defer zip.Close()
+	zip.SetOffset(offset)
+	if err := wc.create(zip, entries); err != nil {
+		_ = zip.Close()
+		return err
+	}
+	// Close writes the central directory, and the last volumes of the split archive
+	return zip.Close()
+}
 
+// create writes the entries to zip, which is not closed.
+func (wc *WriteCloser) create(zip *std_zip.Writer, entries []compress.ArchiverFile) error {
+	if err := zip.SetComment(wc.comment); err != nil {
+		return err
+	}
+	if wc.split != nil {
+		if err := zip.SetSplit(wc.split.size, wc.split.next); err != nil {
+			return err
+		}
+	}
+
+	if wc.parallel != nil && wc.parallel.workers > 1 {
+		return wc.createParallel(zip, entries)
+	}
 	for i, entry := range entries {
-		header, err := std_zip.FileInfoHeader(entry)
-		if err != nil {
+		if err := wc.writeEntry(zip, i, entry, ""); err != nil {
 			return err
 		}
-		root := entry.Root()
-		if entry.IsDir() {
-			if !strings.HasSuffix(root, "/") {
-				header.Name += "/" // required
+	}
+	return nil
+}
+
+// entryHeader create the zip header of entry, and returns the reader of entry body.
+func (wc *WriteCloser) entryHeader(i int, entry compress.ArchiverFile, a *adaptive) (*std_zip.FileHeader, io.Reader, error) {
+	header, err := std_zip.FileInfoHeader(entry)
+	if err != nil {
+		return nil, nil, err
+	}
+	if wc.owner {
+		header.Uid, header.Gid, header.HasOwner = std_zip.FileInfoOwner(entry)
+	}
+	if c, ok := entry.(compress.EntryCommenter); ok {
+		header.Comment = c.EntryComment()
+	}
+	var body io.Reader = entry
+	root := entry.Root()
+	if entry.IsDir() {
+		if !strings.HasSuffix(root, "/") {
+			header.Name += "/" // required
+		}
+		header.Method = std_zip.Store
+	} else if entry.Mode()&fs.ModeSymlink != 0 {
+		// symbolic links store the link target as body
+		if l, ok := entry.(compress.SymlinkFile); ok {
+			target, err := l.LinkTarget()
+			if err != nil {
+				return nil, nil, fmt.Errorf("reading link [%d] %s\n  error: %w", i, root, err)
 			}
+			body = strings.NewReader(target)
+		}
+		header.Method = std_zip.Store
+		header.Name = root
+	} else {
+		ext := strings.ToLower(path.Ext(root))
+		if _, ok := wc.extensions[ext]; ok {
 			header.Method = std_zip.Store
-		} else {
-			ext := strings.ToLower(path.Ext(root))
-			if _, ok := wc.extensions[ext]; ok {
-				header.Method = std_zip.Store
-			} else {
-				header.Method = std_zip.Deflate
+		} else if a != nil {
+			header.Method, body, err = a.choose(entry)
+			if err != nil {
+				return nil, nil, fmt.Errorf("sampling file [%d] %s\n  error: %w", i, root, err)
 			}
-			header.Name = root
-		}
-		zw, err := zip.CreateHeader(header)
-		if err != nil {
-			return fmt.Errorf("zip creating header for file [%d] %s\n  error: %w", i, header.Name, err)
-		}
-
-		// directories have no file body
-		if entry.IsDir() {
-			continue
-		}
-		_, err = io.Copy(zw, entry)
-		if err != nil {
-			return fmt.Errorf("writing file [%d] %s\n  error: %w", i, header.Name, err)
+		} else {
+			header.Method = std_zip.Deflate
 		}
-		//if header.Method == std_zip.Store && n != entry.Size() {
-		//	return fmt.Errorf("writing file [%d] %s\n  size error: (%d/%d)", i, header.Name, n, entry.Size())
-		//}
+		header.Name = root
 	}
-
-	return zip.Flush()
+	return header, body, nil
 }
 
 func (wc *WriteCloser) Close() error {
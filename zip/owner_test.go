@@ -0,0 +1,55 @@
+// Package zip
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package zip
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pashifika/compress"
+	"github.com/pashifika/compress/internal/std_zip"
+)
+
+// ownedFile is an entry whose Sys value has an owner (see compress.Owner).
+type ownedFile struct{ memFile }
+
+func (f *ownedFile) Sys() interface{} { return f }
+func (f *ownedFile) Owner() (uid, gid int, ok bool) {
+	return 1000, 100, true
+}
+
+// TestOwner checks that the owner is only stored when SetOwner is enabled.
+func TestOwner(t *testing.T) {
+	for _, enable := range []bool{false, true} {
+		wc := &WriteCloser{}
+		wc.SetOwner(enable)
+		var buf bytes.Buffer
+		entry := &ownedFile{*newFile("a.txt", "a")}
+		if err := wc.Create(&buf, []compress.ArchiverFile{entry}); err != nil {
+			t.Fatal(err)
+		}
+		r, err := std_zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		uid, gid, ok := r.File[0].Owner()
+		if ok != enable || enable && (uid != 1000 || gid != 100) {
+			t.Errorf("enable %v: owner %d:%d %v", enable, uid, gid, ok)
+		}
+	}
+}
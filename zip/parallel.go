@@ -0,0 +1,220 @@
+// Package zip
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package zip
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/pashifika/compress"
+	"github.com/pashifika/compress/internal/std_zip"
+)
+
+// DefaultMemLimit is the largest entry size compressed into memory in parallel mode,
+// bigger entries are spilled to temp files.
+const DefaultMemLimit = 4 << 20
+
+type parallel struct {
+	workers  int
+	memLimit int64
+	tempDir  string
+}
+
+// SetParallel enable compress entries by workers goroutines, the archive is still
+// written in entries order and is byte-identical to the serial output.
+// Entries up to memLimit bytes are buffered in memory, the others in temp files of tempDir
+// (os.TempDir if empty).
+//
+// * workers <= 1 is disable parallel mode.
+func (wc *WriteCloser) SetParallel(workers int, memLimit int64, tempDir string) {
+	if workers <= 1 {
+		wc.parallel = nil
+		return
+	}
+	wc.parallel = &parallel{workers: workers, memLimit: memLimit, tempDir: tempDir}
+}
+
+// spooled is a compressed entry waiting to be written.
+type spooled struct {
+	header *std_zip.FileHeader
+	isDir  bool
+	buf    *bytes.Buffer
+	file   *os.File
+	err    error
+}
+
+func (s *spooled) reader() (io.Reader, error) {
+	if s.file != nil {
+		if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		return s.file, nil
+	}
+	return s.buf, nil
+}
+
+func (s *spooled) release() {
+	s.buf = nil
+	if s.file != nil {
+		_ = s.file.Close()
+		_ = os.Remove(s.file.Name())
+		s.file = nil
+	}
+}
+
+func (wc *WriteCloser) createParallel(zip *std_zip.Writer, entries []compress.ArchiverFile) error {
+	workers := wc.parallel.workers
+	results := make([]chan *spooled, len(entries))
+	for i := range results {
+		results[i] = make(chan *spooled, 1)
+	}
+	jobs := make(chan int)
+	done := make(chan struct{})
+	// window limits the entries spooled ahead of the writer.
+	window := make(chan struct{}, 2*workers)
+
+	var wg sync.WaitGroup
+	for n := 0; n < workers; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var a *adaptive
+			if wc.adaptive != nil {
+				a = wc.adaptive.clone()
+			}
+			for i := range jobs {
+				results[i] <- wc.spool(zip, i, entries[i], a)
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for i := range entries {
+			select {
+			case window <- struct{}{}:
+			case <-done:
+				return
+			}
+			select {
+			case jobs <- i:
+			case <-done:
+				return
+			}
+		}
+	}()
+	defer func() {
+		close(done)
+		wg.Wait()
+		for _, res := range results {
+			select {
+			case s := <-res:
+				s.release()
+			default:
+			}
+		}
+	}()
+
+	for i := range entries {
+		s := <-results[i]
+		err := writeSpooled(zip, i, s)
+		s.release()
+		<-window
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// spool compress the body of entry to memory or temp file.
+func (wc *WriteCloser) spool(zip *std_zip.Writer, i int, entry compress.ArchiverFile, a *adaptive) *spooled {
+	header, body, err := wc.entryHeader(i, entry, a)
+	s := &spooled{header: header, isDir: entry.IsDir(), err: err}
+	if err != nil || s.isDir {
+		return s
+	}
+
+	var dst io.Writer
+	var bw *bufio.Writer
+	if entry.Size() <= wc.parallel.memLimit {
+		s.buf = &bytes.Buffer{}
+		dst = s.buf
+	} else {
+		s.file, err = os.CreateTemp(wc.parallel.tempDir, "compress-zip-*")
+		if err != nil {
+			s.err = fmt.Errorf("spooling file [%d] %s\n  error: %w", i, header.Name, err)
+			return s
+		}
+		bw = bufio.NewWriter(s.file)
+		dst = bw
+	}
+
+	comp := zip.Compressor(header.Method)
+	if comp == nil {
+		s.err = std_zip.ErrAlgorithm
+		return s
+	}
+	cw := &countWriter{w: dst}
+	zw, err := comp(cw)
+	if err != nil {
+		s.err = err
+		return s
+	}
+	crc := crc32.NewIEEE()
+	n, err := io.Copy(zw, io.TeeReader(body, crc))
+	if err == nil {
+		err = zw.Close()
+	}
+	if err == nil && bw != nil {
+		err = bw.Flush()
+	}
+	if err != nil {
+		s.err = fmt.Errorf("writing file [%d] %s\n  error: %w", i, header.Name, err)
+		return s
+	}
+	header.CRC32 = crc.Sum32()
+	header.CompressedSize64 = uint64(cw.count)
+	header.UncompressedSize64 = uint64(n)
+	return s
+}
+
+func writeSpooled(zip *std_zip.Writer, i int, s *spooled) error {
+	if s.err != nil {
+		return s.err
+	}
+	zw, err := zip.CreateCompressed(s.header)
+	if err != nil {
+		return fmt.Errorf("zip creating header for file [%d] %s\n  error: %w", i, s.header.Name, err)
+	}
+	if s.isDir {
+		return nil
+	}
+	r, err := s.reader()
+	if err == nil {
+		_, err = io.Copy(zw, r)
+	}
+	if err != nil {
+		return fmt.Errorf("writing file [%d] %s\n  error: %w", i, s.header.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+// Package zip
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package zip
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"math/rand"
+	"path"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/pashifika/compress"
+	"github.com/pashifika/compress/internal/std_zip"
+)
+
+// memFile is an entry to write, its data are read from memory.
+type memFile struct {
+	name string
+	dir  bool
+	r    *bytes.Reader
+}
+
+func (f *memFile) Root() string { return f.name }
+func (f *memFile) Name() string { return path.Base(f.name) }
+func (f *memFile) Size() int64  { return f.r.Size() }
+func (f *memFile) Mode() fs.FileMode {
+	if f.dir {
+		return fs.ModeDir | 0755
+	}
+	return 0644
+}
+func (f *memFile) ModTime() time.Time                   { return time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC) }
+func (f *memFile) IsDir() bool                          { return f.dir }
+func (f *memFile) Sys() interface{}                     { return nil }
+func (f *memFile) Type() fs.FileMode                    { return f.Mode().Type() }
+func (f *memFile) Info() (fs.FileInfo, error)           { return f, nil }
+func (f *memFile) Stat() (fs.FileInfo, error)           { return f, nil }
+func (f *memFile) ReadDir(_ int) ([]fs.DirEntry, error) { return nil, fs.ErrInvalid }
+func (f *memFile) Write(_ []byte) (int, error)          { return 0, compress.ErrWriterNotSupport }
+func (f *memFile) Close() error                         { return nil }
+func (f *memFile) Read(p []byte) (int, error)           { return f.r.Read(p) }
+
+// testEntries returns new entries of the same data on each call, with compressible,
+// random, stored and empty files, the big ones are spooled to temp files.
+func testEntries() []compress.ArchiverFile {
+	rnd := rand.New(rand.NewSource(1))
+	entries := []compress.ArchiverFile{&memFile{name: "dir", dir: true, r: bytes.NewReader(nil)}}
+	for i := 0; i < 24; i++ {
+		var data []byte
+		switch i % 4 {
+		case 0:
+			data = bytes.Repeat([]byte(fmt.Sprintf("line %d of the text\n", i)), 100*i)
+		case 1:
+			data = make([]byte, 1000*i)
+			rnd.Read(data)
+		case 2:
+			data = bytes.Repeat([]byte{byte(i)}, 10*i)
+		}
+		name := fmt.Sprintf("dir/file%02d.txt", i)
+		if i%6 == 5 {
+			name = fmt.Sprintf("dir/image%02d.jpg", i)
+		}
+		entries = append(entries, &memFile{name: name, r: bytes.NewReader(data)})
+	}
+	return entries
+}
+
+func create(t *testing.T, setup func(wc *WriteCloser)) []byte {
+	t.Helper()
+	wc := &WriteCloser{}
+	wc.SetCompressedExt(map[string]struct{}{".jpg": {}})
+	setup(wc)
+	var buf bytes.Buffer
+	if err := wc.Create(&buf, testEntries()); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// TestParallel checks that the parallel archive is byte-identical to the serial one,
+// the entries are spooled to memory and to temp files.
+func TestParallel(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	for _, adaptive := range []bool{false, true} {
+		setup := func(wc *WriteCloser) {
+			if adaptive {
+				wc.SetAdaptive(512, 0.05)
+			}
+		}
+		serial := create(t, setup)
+		parallel := create(t, func(wc *WriteCloser) {
+			setup(wc)
+			wc.SetParallel(4, 8<<10, t.TempDir())
+		})
+		if !bytes.Equal(serial, parallel) {
+			t.Fatalf("adaptive %v: the parallel archive of %d bytes differs from the serial one of %d bytes",
+				adaptive, len(parallel), len(serial))
+		}
+
+		r, err := std_zip.NewReader(bytes.NewReader(parallel), int64(len(parallel)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries := testEntries()
+		if len(r.File) != len(entries) {
+			t.Fatalf("%d files in the central directory, want %d", len(r.File), len(entries))
+		}
+		for i, f := range r.File {
+			want := entries[i].Root()
+			if entries[i].IsDir() {
+				want += "/"
+			}
+			if f.Name != want {
+				t.Errorf("file [%d] %s, want %s", i, f.Name, want)
+			}
+		}
+	}
+}
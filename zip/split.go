@@ -0,0 +1,113 @@
+// Package zip
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package zip
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type split struct {
+	size int64
+	next func(disk int) (io.Writer, error)
+}
+
+// SetSplit enable write a split archive of volumes up to size bytes,
+// the writer of Create receives the first volume and next is called to get
+// the writer of the following volumes (disk is 1 for the second volume).
+//
+// * size <= 0 is disable split mode, see SplitFile to write the volumes to disk.
+func (wc *WriteCloser) SetSplit(size int64, next func(disk int) (io.Writer, error)) {
+	if size <= 0 {
+		wc.split = nil
+		return
+	}
+	wc.split = &split{size: size, next: next}
+}
+
+// SplitFile writes the volumes of a split archive to name.z01, name.z02, ...
+// and renames the last volume to name.zip on Close.
+//
+// Example:
+//
+//	sf, err := zip.CreateSplitFile("out.zip")
+//	wc.SetSplit(2<<30, sf.Next)
+//	err = wc.Create(sf, entries)
+//	err = sf.Close()
+type SplitFile struct {
+	base  string
+	ext   string
+	files []*os.File
+}
+
+// CreateSplitFile creates the first volume of the split archive name.
+func CreateSplitFile(name string) (*SplitFile, error) {
+	sf := &SplitFile{base: name, ext: ".zip"}
+	if ext := filepath.Ext(name); strings.EqualFold(ext, ".zip") {
+		sf.base = strings.TrimSuffix(name, ext)
+		sf.ext = ext
+	}
+	if _, err := sf.Next(0); err != nil {
+		return nil, err
+	}
+	return sf, nil
+}
+
+// volume returns the file name of the volume disk (first is 0).
+func (sf *SplitFile) volume(disk int) string {
+	return fmt.Sprintf("%s.%c%02d", sf.base, sf.ext[1], disk+1)
+}
+
+// Next creates the volume disk, the following writes go to it.
+func (sf *SplitFile) Next(disk int) (io.Writer, error) {
+	if disk != len(sf.files) {
+		return nil, fmt.Errorf("zip: volume %d created out of order", disk)
+	}
+	f, err := os.Create(sf.volume(disk))
+	if err != nil {
+		return nil, err
+	}
+	sf.files = append(sf.files, f)
+	return sf, nil
+}
+
+// Write writes to the current volume.
+func (sf *SplitFile) Write(p []byte) (int, error) {
+	return sf.files[len(sf.files)-1].Write(p)
+}
+
+// Volumes returns the number of volumes created.
+func (sf *SplitFile) Volumes() int { return len(sf.files) }
+
+// Close closes all the volumes, and renames the last one to name.zip.
+func (sf *SplitFile) Close() error {
+	var err error
+	for _, f := range sf.files {
+		if e := f.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	if err != nil || len(sf.files) == 0 {
+		return err
+	}
+	last := len(sf.files) - 1
+	return os.Rename(sf.volume(last), sf.base+sf.ext)
+}
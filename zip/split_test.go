@@ -0,0 +1,99 @@
+// Package zip
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package zip
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"math/rand"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pashifika/compress"
+)
+
+// splitFS returns the file system of the volumes arc/test.z01, ..., arc/test.zip
+// of a split archive, and the data of its files.
+func splitFS(t *testing.T) (fstest.MapFS, map[string][]byte) {
+	rnd := rand.New(rand.NewSource(1))
+	files := map[string][]byte{}
+	var entries []compress.ArchiverFile
+	for _, name := range []string{"a.bin", "b.bin", "c.bin"} {
+		data := make([]byte, 60<<10)
+		rnd.Read(data)
+		files[name] = data
+		entries = append(entries, &memFile{name: name, r: bytes.NewReader(data)})
+	}
+
+	volumes := []*bytes.Buffer{{}}
+	wc := &WriteCloser{}
+	wc.SetSplit(64<<10, func(disk int) (io.Writer, error) {
+		volumes = append(volumes, &bytes.Buffer{})
+		return volumes[disk], nil
+	})
+	if err := wc.Create(volumes[0], entries); err != nil {
+		t.Fatal(err)
+	}
+	fsys := fstest.MapFS{}
+	for i, v := range volumes {
+		name := "arc/test.zip"
+		if i < len(volumes)-1 {
+			name = "arc/test.z0" + string(rune('1'+i))
+		}
+		fsys[name] = &fstest.MapFile{Data: v.Bytes()}
+	}
+	if len(fsys) < 3 {
+		t.Fatalf("%d volumes", len(fsys))
+	}
+	return fsys, files
+}
+
+// TestSplitFileSystem reads the split archive from a fs.FS.
+func TestSplitFileSystem(t *testing.T) {
+	fsys, files := splitFS(t)
+	rc := &ReadCloser{}
+	rc.SetFileSystem(fsys)
+	zfs, err := rc.OpenReader("arc/test.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	for name, data := range files {
+		got, err := fs.ReadFile(zfs, name)
+		if err != nil || !bytes.Equal(got, data) {
+			t.Errorf("%s: %d bytes, %v", name, len(got), err)
+		}
+	}
+}
+
+// TestMissingVolume checks that the missing volumes match compress.ErrMissingVolume.
+func TestMissingVolume(t *testing.T) {
+	for _, missing := range []string{"arc/test.z01", "arc/test.z02"} {
+		fsys, _ := splitFS(t)
+		delete(fsys, missing)
+		rc := &ReadCloser{}
+		rc.SetFileSystem(fsys)
+		_, err := rc.OpenReader("arc/test.zip")
+		if !errors.Is(err, compress.ErrMissingVolume) {
+			t.Errorf("without %s: %v", missing, err)
+		}
+		_ = rc.Close()
+	}
+}
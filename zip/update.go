@@ -0,0 +1,362 @@
+// Package zip
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package zip
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pashifika/compress"
+	"github.com/pashifika/compress/internal/std_zip"
+)
+
+// UpdateOp is the operation of an Update.
+type UpdateOp int
+
+const (
+	OpAdd     UpdateOp = iota // add File as Name (File.Root() if empty)
+	OpReplace                 // replace the content of Name by File
+	OpDelete                  // delete Name
+	OpRename                  // rename Name to NewName
+)
+
+// Update is a change of an archive entry applied by UpdateZip.
+type Update struct {
+	Op      UpdateOp
+	Name    string
+	NewName string
+	File    compress.ArchiverFile
+}
+
+// validate returns the error of the update i, it is checked before the archive is read.
+func (u *Update) validate(i int) error {
+	switch u.Op {
+	case OpAdd:
+		if u.File == nil {
+			return fmt.Errorf("zip: update [%d] adds no file", i)
+		}
+	case OpReplace:
+		if u.File == nil {
+			return fmt.Errorf("zip: update [%d] replaces %s by no file", i, u.Name)
+		}
+	case OpDelete:
+	case OpRename:
+		if u.NewName == "" {
+			return fmt.Errorf("zip: update [%d] renames %s to no name", i, u.Name)
+		}
+	default:
+		return fmt.Errorf("zip: unknown update operation %d", u.Op)
+	}
+	return nil
+}
+
+// updateEntry is an entry of the archive written by UpdateZip.
+type updateEntry struct {
+	file    *std_zip.File         // nil for the added entries
+	name    string                // the name to write, with the trailing slash of directories
+	src     compress.ArchiverFile // the added or replaced content
+	deleted bool
+}
+
+// UpdateZip applies updates to the zip archive path. Untouched entries are copied
+// without decompression, and the archive is written to a temp file which
+// replaces path once complete. The updates apply in order, to the added entries too,
+// and renaming a directory moves its entries. Split archives are not supported.
+func (wc *WriteCloser) UpdateZip(path string, updates []Update) error {
+	for i := range updates {
+		if err := updates[i].validate(i); err != nil {
+			return err
+		}
+	}
+	zr, err := std_zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	// zr is closed before the rename, and set to nil
+	defer func() {
+		if zr != nil {
+			_ = zr.Close()
+		}
+	}()
+
+	if zr.IsSplit() {
+		return fmt.Errorf("zip: cannot update split archive %s", path)
+	}
+
+	// entries are the archive entries followed by the added ones,
+	// index is the entries by their name without the trailing slash.
+	entries := make([]*updateEntry, 0, len(zr.File)+len(updates))
+	index := make(map[string]*updateEntry, len(zr.File))
+	for _, f := range zr.File {
+		e := &updateEntry{file: f, name: f.Name}
+		entries = append(entries, e)
+		index[strings.TrimSuffix(f.Name, "/")] = e
+	}
+	lookup := func(name string) (*updateEntry, error) {
+		if e, ok := index[strings.TrimSuffix(name, "/")]; ok {
+			return e, nil
+		}
+		return nil, &fs.PathError{Op: "update", Path: name, Err: fs.ErrNotExist}
+	}
+	exists := func(name string) error {
+		if _, ok := index[strings.TrimSuffix(name, "/")]; ok {
+			return &fs.PathError{Op: "update", Path: name, Err: fs.ErrExist}
+		}
+		return nil
+	}
+
+	for _, u := range updates {
+		switch u.Op {
+		case OpAdd:
+			name := u.Name
+			if name == "" {
+				name = u.File.Root()
+			}
+			if err = exists(name); err != nil {
+				return err
+			}
+			e := &updateEntry{name: name, src: u.File}
+			entries = append(entries, e)
+			index[strings.TrimSuffix(name, "/")] = e
+		case OpReplace:
+			e, err := lookup(u.Name)
+			if err != nil {
+				return err
+			}
+			e.src = u.File
+		case OpDelete:
+			e, err := lookup(u.Name)
+			if err != nil {
+				return err
+			}
+			e.deleted = true
+			delete(index, strings.TrimSuffix(u.Name, "/"))
+		case OpRename:
+			if err = exists(u.NewName); err != nil {
+				return err
+			}
+			oldName, newName := strings.TrimSuffix(u.Name, "/"), strings.TrimSuffix(u.NewName, "/")
+			// the entries inside a directory, it may have no entry of its own
+			var moved []*updateEntry
+			for _, e := range entries {
+				if !e.deleted && strings.HasPrefix(e.name, oldName+"/") && e.name != oldName+"/" {
+					moved = append(moved, e)
+				}
+			}
+			e, err := lookup(u.Name)
+			if err != nil && len(moved) == 0 {
+				return err
+			}
+			if e != nil {
+				moved = append(moved, e)
+			}
+			for _, e := range moved {
+				delete(index, strings.TrimSuffix(e.name, "/"))
+			}
+			for _, e := range moved {
+				e.name = newName + strings.TrimPrefix(e.name, oldName)
+				if err = exists(e.name); err != nil {
+					return err
+				}
+				index[strings.TrimSuffix(e.name, "/")] = e
+			}
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	done := false
+	defer func() {
+		if !done {
+			_ = tmp.Close()
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+
+	// keep the data found before the archive (e.g. a self-extracting stub)
+	prefix := zr.DirectoryOffset()
+	for _, f := range zr.File {
+		if o := f.HeaderOffset(); o < prefix {
+			prefix = o
+		}
+	}
+	if _, err = io.Copy(tmp, io.NewSectionReader(zr.ReaderAt(), 0, prefix)); err != nil {
+		return err
+	}
+
+	zip := std_zip.NewWriter(tmp)
+	zip.SetOffset(prefix)
+	if err = zip.SetComment(zr.Comment); err != nil {
+		return err
+	}
+	for i, e := range entries {
+		switch {
+		case e.deleted:
+			continue
+		case e.src != nil:
+			err = wc.writeEntry(zip, i, e.src, e.name)
+		case e.name != e.file.Name:
+			err = zip.CopyAs(e.file, e.name)
+		default:
+			err = zip.Copy(e.file)
+		}
+		if err != nil {
+			return fmt.Errorf("updating file [%d] %s\n  error: %w", i, e.name, err)
+		}
+	}
+	if err = zip.Close(); err != nil {
+		return err
+	}
+	if err = tmp.Chmod(info.Mode()); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	err = zr.Close()
+	zr = nil
+	if err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	done = true
+	return nil
+}
+
+// AppendZip appends entries to the zip archive path in place, the existing entries
+// are not rewritten. The central directory is overwritten by the new entries, it is
+// written back if the append fails, so the archive is unchanged unless that write
+// fails too (e.g. the disk is gone), use UpdateZip to never change the archive in place.
+func (wc *WriteCloser) AppendZip(path string, entries []compress.ArchiverFile) (err error) {
+	zr, err := std_zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	//goland:noinspection ALL
+	defer zr.Close()
+	if zr.IsSplit() {
+		return fmt.Errorf("zip: cannot append to split archive %s", path)
+	}
+
+	names := make(map[string]bool, len(zr.File))
+	for _, f := range zr.File {
+		names[strings.TrimSuffix(f.Name, "/")] = true
+	}
+	for i, entry := range entries {
+		if entry == nil {
+			return fmt.Errorf("zip: append [%d] of no file", i)
+		}
+		if names[strings.TrimSuffix(entry.Root(), "/")] {
+			return &fs.PathError{Op: "append", Path: entry.Root(), Err: fs.ErrExist}
+		}
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	offset := zr.DirectoryOffset()
+	tail := make([]byte, info.Size()-offset)
+	if _, err = f.ReadAt(tail, offset); err != nil {
+		_ = f.Close()
+		return err
+	}
+	defer func() {
+		if err != nil {
+			// the appended data is dropped by the central directory written back
+			if _, e := f.WriteAt(tail, offset); e == nil {
+				_ = f.Truncate(offset + int64(len(tail)))
+			}
+		}
+		if e := f.Close(); err == nil {
+			err = e
+		}
+	}()
+	if _, err = f.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	zip := std_zip.NewWriter(f)
+	zip.SetOffset(offset)
+	if err = zip.SetComment(zr.Comment); err != nil {
+		return err
+	}
+	for _, file := range zr.File {
+		if err = zip.Keep(file); err != nil {
+			return err
+		}
+	}
+	for i, entry := range entries {
+		if err = wc.writeEntry(zip, len(zr.File)+i, entry, ""); err != nil {
+			return err
+		}
+	}
+	if err = zip.Close(); err != nil {
+		return err
+	}
+	end, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	return f.Truncate(end)
+}
+
+// writeEntry compress entry to zip as name (entry.Root() if empty).
+func (wc *WriteCloser) writeEntry(zip *std_zip.Writer, i int, entry compress.ArchiverFile, name string) error {
+	header, body, err := wc.entryHeader(i, entry, wc.adaptive)
+	if err != nil {
+		return err
+	}
+	if name != "" {
+		header.Name = name
+		if entry.IsDir() && !strings.HasSuffix(name, "/") {
+			header.Name += "/"
+		}
+	}
+	zw, err := zip.CreateHeader(header)
+	if err != nil {
+		return fmt.Errorf("zip creating header for file [%d] %s\n  error: %w", i, header.Name, err)
+	}
+	if entry.IsDir() {
+		return nil
+	}
+	if _, err = io.Copy(zw, body); err != nil {
+		return fmt.Errorf("writing file [%d] %s\n  error: %w", i, header.Name, err)
+	}
+	//if header.Method == std_zip.Store && n != entry.Size() {
+	//	return fmt.Errorf("writing file [%d] %s\n  size error: (%d/%d)", i, header.Name, n, entry.Size())
+	//}
+	return nil
+}
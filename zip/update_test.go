@@ -0,0 +1,167 @@
+// Package zip
+/*
+ * Version: 1.0.0
+ * Copyright (c) 2022. Pashifika
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package zip
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pashifika/compress"
+	"github.com/pashifika/compress/internal/std_zip"
+)
+
+var errBroken = errors.New("broken file")
+
+// brokenFile is an entry which fails after its first bytes are read.
+type brokenFile struct{ memFile }
+
+func (f *brokenFile) Read(p []byte) (int, error) {
+	if int64(f.r.Len()) < f.r.Size() {
+		return 0, errBroken
+	}
+	return f.r.Read(p[:1])
+}
+
+func newFile(name, data string) *memFile {
+	return &memFile{name: name, r: bytes.NewReader([]byte(data))}
+}
+
+// createZip writes the archive of a.txt and dir/b.txt to a temp file.
+func createZip(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.zip")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	wc := &WriteCloser{}
+	if err = wc.Create(f, []compress.ArchiverFile{newFile("a.txt", "a"), newFile("dir/b.txt", "b")}); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// names returns the names and data of the entries of the archive path.
+func names(t *testing.T, path string) map[string]string {
+	t.Helper()
+	zr, err := std_zip.OpenReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	files := map[string]string{}
+	for _, f := range zr.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		_, err = buf.ReadFrom(r)
+		_ = r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name] = buf.String()
+	}
+	return files
+}
+
+func TestUpdateZip(t *testing.T) {
+	path := createZip(t)
+	wc := &WriteCloser{}
+	err := wc.UpdateZip(path, []Update{
+		{Op: OpAdd, File: newFile("c.txt", "c")},
+		{Op: OpReplace, Name: "a.txt", File: newFile("a.txt", "A")},
+		{Op: OpRename, Name: "dir", NewName: "moved"},
+		{Op: OpDelete, Name: "c.txt"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := names(t, path)
+	if len(files) != 2 || files["a.txt"] != "A" || files["moved/b.txt"] != "b" {
+		t.Errorf("files %v", files)
+	}
+}
+
+// TestUpdateInvalid checks that the invalid updates fail before the archive is changed.
+func TestUpdateInvalid(t *testing.T) {
+	path := createZip(t)
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wc := &WriteCloser{}
+	for _, u := range []Update{
+		{Op: OpAdd},
+		{Op: OpAdd, Name: "c.txt"},
+		{Op: OpReplace, Name: "a.txt"},
+		{Op: OpRename, Name: "a.txt"},
+		{Op: OpDelete, Name: "missing"},
+		{Op: UpdateOp(99)},
+	} {
+		if err = wc.UpdateZip(path, []Update{{Op: OpAdd, File: newFile("new.txt", "n")}, u}); err == nil {
+			t.Errorf("%+v: no error", u)
+		}
+	}
+	after, err := os.ReadFile(path)
+	if err != nil || !bytes.Equal(before, after) {
+		t.Errorf("the archive is changed, %v", err)
+	}
+}
+
+func TestAppendZip(t *testing.T) {
+	path := createZip(t)
+	wc := &WriteCloser{}
+	if err := wc.AppendZip(path, []compress.ArchiverFile{newFile("c.txt", "c")}); err != nil {
+		t.Fatal(err)
+	}
+	files := names(t, path)
+	if len(files) != 3 || files["a.txt"] != "a" || files["dir/b.txt"] != "b" || files["c.txt"] != "c" {
+		t.Errorf("files %v", files)
+	}
+}
+
+// TestAppendFailed checks that the archive is unchanged when an entry fails.
+func TestAppendFailed(t *testing.T) {
+	path := createZip(t)
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wc := &WriteCloser{}
+	broken := &brokenFile{*newFile("broken.txt", "broken data")}
+	err = wc.AppendZip(path, []compress.ArchiverFile{newFile("c.txt", "c"), broken})
+	if !errors.Is(err, errBroken) {
+		t.Fatalf("error %v, want %v", err, errBroken)
+	}
+	after, err := os.ReadFile(path)
+	if err != nil || !bytes.Equal(before, after) {
+		t.Errorf("the archive is changed, %v", err)
+	}
+	if files := names(t, path); len(files) != 2 {
+		t.Errorf("files %v", files)
+	}
+	if err = wc.AppendZip(path, []compress.ArchiverFile{nil}); err == nil {
+		t.Error("nil entry is appended")
+	}
+}
@@ -20,6 +20,7 @@ package zip
 import (
 	"io/fs"
 	"os"
+	"time"
 
 	"golang.org/x/text/encoding"
 
@@ -29,7 +30,10 @@ import (
 )
 
 type ReadCloser struct {
-	zip *std_zip.ReadCloser
+	zip   *std_zip.ReadCloser
+	match compress.LookupOption
+	loc   *time.Location
+	fsys  fs.FS
 }
 
 const _zipName = "zip"
@@ -42,19 +46,40 @@ func (rc *ReadCloser) SetCharset(charset []encoding.Encoding, skipErr bool) {
 	std_zip.SetCharset(charset, skipErr)
 }
 
+// SetLookup set the matching of the names which are not found as is.
+func (rc *ReadCloser) SetLookup(opt compress.LookupOption) { rc.match = opt }
+
+// SetLocation set the time zone of the MS-DOS times of the entries without extended timestamps.
+func (rc *ReadCloser) SetLocation(loc *time.Location) { rc.loc = loc }
+
+// SetFileSystem set the fs.FS to open the archive and the volumes of a split archive
+// from (embedded, in-memory or inside another archive), the path of OpenReader is
+// then a name of fsys. nil is the OS file system.
+func (rc *ReadCloser) SetFileSystem(fsys fs.FS) { rc.fsys = fsys }
+
 func (rc *ReadCloser) OpenReader(path string) (fs.FS, error) {
 	return rc.OpenReaderWithPassword(path, "")
 }
 
 func (rc *ReadCloser) OpenReaderWithPassword(path, _ string) (fs.FS, error) {
-	z, err := std_zip.OpenReader(path)
+	z, err := std_zip.OpenReaderFS(rc.fsys, path, rc.loc)
 	if err != nil {
 		return nil, err
 	}
+	z.SetLookup(rc.match)
 	rc.zip = z
 	return z, nil
 }
 
+// BaseOffset returns the size of the data found before the opened archive,
+// such as the stub of a self-extracting archive.
+func (rc *ReadCloser) BaseOffset() int64 {
+	if rc.zip != nil {
+		return rc.zip.BaseOffset()
+	}
+	return 0
+}
+
 func (rc *ReadCloser) GetDirEntries(_ string, _ int) ([]fs.DirEntry, error) {
 	return nil, nil
 }